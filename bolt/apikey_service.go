@@ -0,0 +1,100 @@
+package bolt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+type APIKeyService struct {
+	client *Client
+}
+
+// APIKey returns an API key by its key string.
+func (s *APIKeyService) APIKey(key string) (*fruit.APIKey, error) {
+	if key == "" {
+		return nil, fruit.ErrAPIKeyRequired
+	}
+
+	var k fruit.APIKey
+	if err := s.client.db.From("APIKeys").One("Key", token.Hash(key), &k); err == storm.ErrNotFound {
+		return nil, fruit.ErrAPIKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// CreateAPIKey creates a new API key. A random key is generated if one is
+// not provided.
+func (s *APIKeyService) CreateAPIKey(k *fruit.APIKey) error {
+	if k.Key == "" {
+		key, err := newKey()
+		if err != nil {
+			return err
+		}
+		k.Key = key
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("APIKeys").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify key doesn't already exist.
+	var other fruit.APIKey
+	if err := tx.One("Key", token.Hash(k.Key), &other); err == nil {
+		return fruit.ErrAPIKeyExists
+	} else if err != storm.ErrNotFound {
+		return err
+	}
+
+	k.ModTime = s.client.Now().UTC()
+
+	// Keys are stored by their hash, and k keeps the plain key.
+	stored := *k
+	stored.Key = token.Hash(k.Key)
+	if err := tx.Save(&stored); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteAPIKey revokes an existing API key.
+func (s *APIKeyService) DeleteAPIKey(key string) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("APIKeys").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record.
+	var k fruit.APIKey
+	if err := tx.One("Key", token.Hash(key), &k); err == storm.ErrNotFound {
+		return fruit.ErrAPIKeyNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if err := tx.DeleteStruct(&k); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// newKey returns a random hex encoded key.
+func newKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,82 @@
+package bolt_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.APIKeyService()
+
+	k := fruit.APIKey{UserID: "USERID", Admin: true}
+
+	// Create key with a generated key string.
+	if err := s.CreateAPIKey(&k); err != nil {
+		t.Fatal(err)
+	} else if len(k.Key) != 64 {
+		t.Fatalf("unexpected key: %q", k.Key)
+	}
+
+	// The key is stored as a hash.
+	other, err := s.APIKey(k.Key)
+	if err != nil {
+		t.Fatal(err)
+	} else if other.Key != token.Hash(k.Key) {
+		t.Fatalf("unexpected stored key: %s", other.Key)
+	} else if other.Key = k.Key; !reflect.DeepEqual(&k, other) {
+		t.Fatalf("unexpected key: %+v", other)
+	}
+}
+
+func TestAPIKeyService_CreateAPIKey_ErrAPIKeyExists(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.APIKeyService()
+
+	if err := s.CreateAPIKey(&fruit.APIKey{Key: "KEY"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateAPIKey(&fruit.APIKey{Key: "KEY"}); err != fruit.ErrAPIKeyExists {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIKeyService_APIKey_ErrAPIKeyNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if k, err := c.APIKeyService().APIKey("NO SUCH KEY"); err != fruit.ErrAPIKeyNotFound {
+		t.Fatal(err)
+	} else if k != nil {
+		t.Fatalf("unexpected key: %+v", k)
+	}
+}
+
+func TestAPIKeyService_DeleteAPIKey(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.APIKeyService()
+
+	if err := s.CreateAPIKey(&fruit.APIKey{Key: "KEY"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteAPIKey("KEY"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify key revoked.
+	if _, err := s.APIKey("KEY"); err != fruit.ErrAPIKeyNotFound {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteAPIKey("KEY"); err != fruit.ErrAPIKeyNotFound {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package bolt
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/notjrbauer/fruit"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrBackupEmpty is returned when restoring from an empty backup.
+const ErrBackupEmpty = fruit.Error("backup is empty")
+
+// Backup writes a consistent snapshot of the database to w. The snapshot is
+// taken in a read transaction so writes can continue while it streams.
+func (c *Client) Backup(w io.Writer) error {
+	return c.db.Bolt.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	})
+}
+
+// Restore replaces the database at path with the backup read from r. The
+// backup is written beside path and checked to open with a compatible
+// schema before it is moved into place, so a bad backup leaves the
+// existing database untouched. The database must not be open.
+func Restore(path string, r io.Reader) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".restore-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if n, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	} else if n == 0 {
+		// Bolt would initialize an empty file as a new database.
+		f.Close()
+		return ErrBackupEmpty
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Close(); err != nil {
+		return err
+	}
+
+	// Verify the backup opens and isn't from a newer schema.
+	c := NewClient()
+	c.Path = f.Name()
+	c.SkipMigrations = true
+	if err := c.Open(); err != nil {
+		return err
+	} else if err := c.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,90 @@
+package bolt_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/bolt"
+)
+
+// Ensure a backup can be restored over another database.
+func TestClient_Backup(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U", Name: "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Backup(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore over a database without the user.
+	other := MustOpenClient()
+	defer other.Close()
+	if err := other.Client.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := bolt.Restore(other.Path, &buf); err != nil {
+		t.Fatal(err)
+	} else if err := other.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if u, err := other.UserService().User(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if u.Name != "Alice" {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+// Ensure invalid backups leave the existing database in place.
+func TestRestore_Invalid(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U"}); err != nil {
+		t.Fatal(err)
+	} else if err := c.Client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A database written by a newer schema.
+	newer := NewClient()
+	defer os.Remove(newer.Path)
+	if db, err := storm.Open(newer.Path); err != nil {
+		t.Fatal(err)
+	} else if err := db.Set("Meta", "version", 1000); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(newer.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := bolt.Restore(c.Path, f); err != bolt.ErrSchemaTooNew {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := bolt.Restore(c.Path, strings.NewReader("")); err != bolt.ErrBackupEmpty {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := bolt.Restore(c.Path, strings.NewReader(strings.Repeat("X", 8192))); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.UserService().User(ctx, "U"); err != nil {
+		t.Fatal(err)
+	}
+}
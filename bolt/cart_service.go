@@ -0,0 +1,177 @@
+package bolt
+
+import (
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+type CartService struct {
+	client *Client
+}
+
+// Cart returns the cart for a user. Users without a cart get an empty one.
+func (s *CartService) Cart(id fruit.UserID) (*fruit.Cart, error) {
+	if id == "" {
+		return nil, fruit.ErrUserIDRequired
+	}
+	return findCart(s.client.db.From("Carts"), id)
+}
+
+// AddCartItem adds quantity of a product to a user's cart. The quantity is
+// added to any existing quantity of the same product.
+func (s *CartService) AddCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	return s.update(id, productID, func(c *fruit.Cart) error {
+		if quantity <= 0 {
+			return fruit.ErrInvalidQuantity
+		}
+
+		for i := range c.Items {
+			if c.Items[i].ProductID == productID {
+				c.Items[i].Quantity += quantity
+				return nil
+			}
+		}
+		c.Items = append(c.Items, fruit.CartItem{ProductID: productID, Quantity: quantity})
+		return nil
+	})
+}
+
+// UpdateCartItem sets the quantity of a product already in a user's cart.
+func (s *CartService) UpdateCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	return s.update(id, productID, func(c *fruit.Cart) error {
+		if quantity <= 0 {
+			return fruit.ErrInvalidQuantity
+		}
+
+		for i := range c.Items {
+			if c.Items[i].ProductID == productID {
+				c.Items[i].Quantity = quantity
+				return nil
+			}
+		}
+		return fruit.ErrCartItemNotFound
+	})
+}
+
+// RemoveCartItem removes a product from a user's cart.
+func (s *CartService) RemoveCartItem(id fruit.UserID, productID fruit.ProductID) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Carts").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := findCart(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Removing doesn't require the product to still exist.
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			c.ModTime = s.client.Now().UTC()
+
+			if err := tx.Save(c); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}
+	}
+	return fruit.ErrCartItemNotFound
+}
+
+// ClearCart removes all items from a user's cart.
+func (s *CartService) ClearCart(id fruit.UserID) error {
+	if id == "" {
+		return fruit.ErrUserIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Carts").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := clearCart(tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// update applies fn to a user's cart after verifying that the user and the
+// product exist.
+func (s *CartService) update(id fruit.UserID, productID fruit.ProductID, fn func(c *fruit.Cart) error) error {
+	if id == "" {
+		return fruit.ErrUserIDRequired
+	} else if productID == "" {
+		return fruit.ErrProductIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify user exists.
+	var u fruit.User
+	if err := tx.From("Users").One("ID", id, &u); err == storm.ErrNotFound {
+		return fruit.ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// Verify product exists.
+	var p fruit.Product
+	if err := findProduct(tx.From("Products"), productID, &p); err != nil {
+		return err
+	}
+
+	carts := tx.From("Carts")
+	c, err := findCart(carts, id)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(c); err != nil {
+		return err
+	}
+	c.ModTime = s.client.Now().UTC()
+
+	if err := carts.Save(c); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findCart returns the cart for a user, or an empty cart if none is stored.
+func findCart(n storm.Node, id fruit.UserID) (*fruit.Cart, error) {
+	var c fruit.Cart
+	if err := n.One("UserID", id, &c); err == storm.ErrNotFound {
+		return &fruit.Cart{UserID: id, Items: []fruit.CartItem{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if c.Items == nil {
+		c.Items = []fruit.CartItem{}
+	}
+	return &c, nil
+}
+
+// clearCart removes the stored cart for a user, if any.
+func clearCart(n storm.Node, id fruit.UserID) error {
+	var c fruit.Cart
+	if err := n.One("UserID", id, &c); err == storm.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return n.DeleteStruct(&c)
+}
@@ -0,0 +1,193 @@
+package bolt_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// MustCreateCartFixtures creates a user and two products and panics on error.
+func MustCreateCartFixtures(c *Client) {
+	ctx := context.Background()
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "USER"}); err != nil {
+		panic(err)
+	}
+	for _, p := range []*fruit.Product{
+		{ID: "APPLE", Token: "TOKEN", Price: &fruit.Money{Amount: 100, Currency: "USD"}},
+		{ID: "PEAR", Token: "TOKEN", Price: &fruit.Money{Amount: 250, Currency: "USD"}},
+	} {
+		if err := c.ProductService().CreateProduct(ctx, p); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestCartService_Cart_Empty(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if cart, err := c.CartService().Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart, &fruit.Cart{UserID: "USER", Items: []fruit.CartItem{}}) {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+}
+
+func TestCartService_AddCartItem(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CartService()
+
+	MustCreateCartFixtures(c)
+
+	// Adding the same product twice accumulates its quantity.
+	for _, item := range []fruit.CartItem{
+		{ProductID: "APPLE", Quantity: 1},
+		{ProductID: "PEAR", Quantity: 3},
+		{ProductID: "APPLE", Quantity: 2},
+	} {
+		if err := s.AddCartItem("USER", item.ProductID, item.Quantity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart.Items, []fruit.CartItem{{ProductID: "APPLE", Quantity: 3}, {ProductID: "PEAR", Quantity: 3}}) {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	} else if !cart.ModTime.Equal(Now) {
+		t.Fatalf("unexpected mod time: %s", cart.ModTime)
+	}
+}
+
+func TestCartService_AddCartItem_ErrProductNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CartService()
+
+	MustCreateCartFixtures(c)
+
+	if err := s.AddCartItem("USER", "NO SUCH PRODUCT", 1); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	// Deleted products can no longer be added.
+	if err := c.ProductService().DeleteProduct(ctx, "PEAR", "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if err := s.AddCartItem("USER", "PEAR", 1); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCartService_AddCartItem_ErrUserNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	MustCreateCartFixtures(c)
+
+	if err := c.CartService().AddCartItem("NO SUCH USER", "APPLE", 1); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCartService_AddCartItem_ErrInvalidQuantity(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	MustCreateCartFixtures(c)
+
+	if err := c.CartService().AddCartItem("USER", "APPLE", 0); err != fruit.ErrInvalidQuantity {
+		t.Fatal(err)
+	}
+}
+
+func TestCartService_UpdateCartItem(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CartService()
+
+	MustCreateCartFixtures(c)
+
+	if err := s.AddCartItem("USER", "APPLE", 1); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateCartItem("USER", "APPLE", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart.Items, []fruit.CartItem{{ProductID: "APPLE", Quantity: 5}}) {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+}
+
+func TestCartService_UpdateCartItem_ErrCartItemNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	MustCreateCartFixtures(c)
+
+	if err := c.CartService().UpdateCartItem("USER", "APPLE", 5); err != fruit.ErrCartItemNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCartService_RemoveCartItem(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CartService()
+
+	MustCreateCartFixtures(c)
+
+	if err := s.AddCartItem("USER", "APPLE", 1); err != nil {
+		t.Fatal(err)
+	} else if err := s.AddCartItem("USER", "PEAR", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Items can be removed even after the product is deleted.
+	if err := c.ProductService().DeleteProduct(ctx, "APPLE", "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if err := s.RemoveCartItem("USER", "APPLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart.Items, []fruit.CartItem{{ProductID: "PEAR", Quantity: 1}}) {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	if err := s.RemoveCartItem("USER", "APPLE"); err != fruit.ErrCartItemNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCartService_ClearCart(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CartService()
+
+	MustCreateCartFixtures(c)
+
+	if err := s.AddCartItem("USER", "APPLE", 1); err != nil {
+		t.Fatal(err)
+	} else if err := s.ClearCart("USER"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(cart.Items) != 0 {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	// Clearing an empty cart is a no-op.
+	if err := s.ClearCart("USER"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,245 @@
+package bolt
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+type CategoryService struct {
+	client *Client
+}
+
+// Category returns a category by ID.
+func (s *CategoryService) Category(id fruit.CategoryID) (*fruit.Category, error) {
+	var c fruit.Category
+	if err := s.client.db.From("Categories").One("ID", id, &c); err == storm.ErrNotFound {
+		return nil, fruit.ErrCategoryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Categories returns a list of all categories.
+func (s *CategoryService) Categories() ([]*fruit.Category, error) {
+	categories := []*fruit.Category{}
+	if err := s.client.db.From("Categories").All(&categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// CreateCategory creates a new category. The slug is derived from the name
+// when it is not provided.
+func (s *CategoryService) CreateCategory(c *fruit.Category) error {
+	// Validate arguments.
+	if c == nil {
+		return fruit.ErrCategoryRequired
+	} else if c.ID == "" {
+		return fruit.ErrCategoryIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Categories").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify category doesn't already exist.
+	var other fruit.Category
+	if err := tx.One("ID", c.ID, &other); err == nil {
+		return fruit.ErrCategoryExists
+	} else if err != storm.ErrNotFound {
+		return err
+	}
+
+	// Verify parent exists.
+	if c.ParentID != "" {
+		if err := tx.One("ID", c.ParentID, &other); err == storm.ErrNotFound {
+			return fruit.ErrCategoryParentNotFound
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if c.Slug == "" {
+		c.Slug = slugify(c.Name)
+	}
+	c.ModTime = s.client.Now().UTC()
+
+	if err := tx.Save(c); err == storm.ErrAlreadyExists {
+		return fruit.ErrCategorySlugExists
+	} else if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateCategory updates an existing category. Renaming a category does not
+// touch its products since they reference it by ID.
+func (s *CategoryService) UpdateCategory(id fruit.CategoryID, c *fruit.Category) error {
+	// Validate arguments.
+	if c == nil {
+		return fruit.ErrCategoryRequired
+	} else if id == "" {
+		return fruit.ErrCategoryIDRequired
+	}
+
+	// Start read-write transaction.
+	tx, err := s.client.db.From("Categories").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record.
+	var category fruit.Category
+	if err := tx.One("ID", id, &category); err == storm.ErrNotFound {
+		return fruit.ErrCategoryNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// Walk up from the new parent to make sure we don't create a cycle.
+	for parent := c.ParentID; parent != ""; {
+		if parent == id {
+			return fruit.ErrCategoryCycle
+		}
+
+		var p fruit.Category
+		if err := tx.One("ID", parent, &p); err == storm.ErrNotFound {
+			return fruit.ErrCategoryParentNotFound
+		} else if err != nil {
+			return err
+		}
+		parent = p.ParentID
+	}
+
+	// Apply changes.
+	category.Name = c.Name
+	category.Slug = c.Slug
+	if category.Slug == "" {
+		category.Slug = slugify(c.Name)
+	}
+	category.ParentID = c.ParentID
+	category.ModTime = s.client.Now().UTC()
+
+	if err := tx.Save(&category); err == storm.ErrAlreadyExists {
+		return fruit.ErrCategorySlugExists
+	} else if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	*c = category
+	return nil
+}
+
+// DeleteCategory removes an existing category. Categories which still have
+// subcategories or products cannot be removed.
+func (s *CategoryService) DeleteCategory(id fruit.CategoryID) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrCategoryIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	categories := tx.From("Categories")
+
+	// Find record.
+	var c fruit.Category
+	if err := categories.One("ID", id, &c); err == storm.ErrNotFound {
+		return fruit.ErrCategoryNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// Verify category is empty.
+	var children []*fruit.Category
+	if err := categories.Find("ParentID", id, &children, storm.Limit(1)); err == nil {
+		return fruit.ErrCategoryNotEmpty
+	} else if err != storm.ErrNotFound {
+		return err
+	}
+
+	// Deleted products don't count. They lose their category if restored.
+	var products []*fruit.Product
+	if err := tx.From("Products").Find("CategoryID", id, &products); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+	for _, p := range products {
+		if p.DeletedAt == nil {
+			return fruit.ErrCategoryNotEmpty
+		}
+	}
+
+	if err := categories.DeleteStruct(&c); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CategoryProducts returns the products in a category and its subcategories.
+func (s *CategoryService) CategoryProducts(id fruit.CategoryID) ([]*fruit.Product, error) {
+	// Start read-only transaction.
+	tx, err := s.client.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	categories := tx.From("Categories")
+
+	// Verify category exists.
+	var c fruit.Category
+	if err := categories.One("ID", id, &c); err == storm.ErrNotFound {
+		return nil, fruit.ErrCategoryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Collect products breadth-first through the category tree.
+	products := []*fruit.Product{}
+	for queue := []fruit.CategoryID{id}; len(queue) > 0; queue = queue[1:] {
+		var found []*fruit.Product
+		if err := tx.From("Products").Find("CategoryID", queue[0], &found); err != nil && err != storm.ErrNotFound {
+			return nil, err
+		}
+		for _, p := range found {
+			if p.DeletedAt == nil {
+				products = append(products, p)
+			}
+		}
+
+		var children []*fruit.Category
+		if err := categories.Find("ParentID", queue[0], &children); err != nil && err != storm.ErrNotFound {
+			return nil, err
+		}
+		for _, child := range children {
+			queue = append(queue, child.ID)
+		}
+	}
+
+	return products, nil
+}
+
+// slugify returns a lowercase, hyphen separated version of name.
+func slugify(name string) string {
+	f := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }
+	return strings.ToLower(strings.Join(strings.FieldsFunc(name, f), "-"))
+}
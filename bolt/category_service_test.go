@@ -0,0 +1,262 @@
+package bolt_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// MustCreateCategories creates a category tree and panics on error:
+//
+//	fruit
+//	├── citrus
+//	│   └── lemons
+//	└── berries
+func MustCreateCategories(s fruit.CategoryService) {
+	for _, c := range []*fruit.Category{
+		{ID: "FRUIT", Name: "Fruit"},
+		{ID: "CITRUS", Name: "Citrus", ParentID: "FRUIT"},
+		{ID: "LEMONS", Name: "Lemons", ParentID: "CITRUS"},
+		{ID: "BERRIES", Name: "Berries", ParentID: "FRUIT"},
+	} {
+		if err := s.CreateCategory(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	category := fruit.Category{ID: "ID", Name: "Stone Fruit"}
+
+	if err := s.CreateCategory(&category); err != nil {
+		t.Fatal(err)
+	} else if category.Slug != "stone-fruit" {
+		t.Fatalf("unexpected slug: %s", category.Slug)
+	}
+
+	other, err := s.Category("ID")
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(&category, other) {
+		t.Fatalf("unexpected category: %+v", other)
+	}
+}
+
+func TestCategoryService_CreateCategory_ErrCategoryIDRequired(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.CategoryService().CreateCategory(&fruit.Category{Name: "NAME"}); err != fruit.ErrCategoryIDRequired {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_CreateCategory_ErrCategoryExists(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	if err := s.CreateCategory(&fruit.Category{ID: "X", Name: "A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateCategory(&fruit.Category{ID: "X", Name: "B"}); err != fruit.ErrCategoryExists {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_CreateCategory_ErrCategorySlugExists(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	if err := s.CreateCategory(&fruit.Category{ID: "X", Name: "Citrus"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateCategory(&fruit.Category{ID: "Y", Name: "citrus"}); err != fruit.ErrCategorySlugExists {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_CreateCategory_ErrCategoryParentNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.CategoryService().CreateCategory(&fruit.Category{ID: "X", Name: "A", ParentID: "NO SUCH CATEGORY"}); err != fruit.ErrCategoryParentNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_Category_ErrCategoryNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if category, err := c.CategoryService().Category("NO SUCH CATEGORY"); err != fruit.ErrCategoryNotFound {
+		t.Fatal(err)
+	} else if category != nil {
+		t.Fatalf("unexpected category: %+v", category)
+	}
+}
+
+func TestCategoryService_Categories(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	MustCreateCategories(s)
+
+	if categories, err := s.Categories(); err != nil {
+		t.Fatal(err)
+	} else if len(categories) != 4 {
+		t.Fatalf("unexpected category count: %d", len(categories))
+	}
+}
+
+func TestCategoryService_UpdateCategory(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	MustCreateCategories(s)
+
+	// Rename and move lemons directly under fruit.
+	if err := s.UpdateCategory("LEMONS", &fruit.Category{Name: "Sour Lemons", ParentID: "FRUIT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if category, err := s.Category("LEMONS"); err != nil {
+		t.Fatal(err)
+	} else if category.Name != "Sour Lemons" || category.Slug != "sour-lemons" || category.ParentID != "FRUIT" {
+		t.Fatalf("unexpected category: %+v", category)
+	}
+}
+
+func TestCategoryService_UpdateCategory_ErrCategoryCycle(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	MustCreateCategories(s)
+
+	// Fruit cannot become a child of its own grandchild.
+	if err := s.UpdateCategory("FRUIT", &fruit.Category{Name: "Fruit", ParentID: "LEMONS"}); err != fruit.ErrCategoryCycle {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_UpdateCategory_ErrCategoryNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.CategoryService().UpdateCategory("X", &fruit.Category{Name: "A"}); err != fruit.ErrCategoryNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_DeleteCategory(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	MustCreateCategories(s)
+
+	if err := s.DeleteCategory("BERRIES"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Category("BERRIES"); err != fruit.ErrCategoryNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_DeleteCategory_ErrCategoryNotEmpty(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	MustCreateCategories(s)
+
+	// Category with subcategories.
+	if err := s.DeleteCategory("CITRUS"); err != fruit.ErrCategoryNotEmpty {
+		t.Fatal(err)
+	}
+
+	// Category with products.
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "P", Token: "TOKEN", CategoryID: "BERRIES"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteCategory("BERRIES"); err != fruit.ErrCategoryNotEmpty {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryService_CategoryProducts(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.CategoryService()
+
+	MustCreateCategories(s)
+
+	for _, p := range []*fruit.Product{
+		{ID: "ORANGE", Token: "TOKEN", CategoryID: "CITRUS"},
+		{ID: "LEMON", Token: "TOKEN", CategoryID: "LEMONS"},
+		{ID: "STRAWBERRY", Token: "TOKEN", CategoryID: "BERRIES"},
+		{ID: "BANANA", Token: "TOKEN", CategoryID: "FRUIT"},
+	} {
+		if err := c.ProductService().CreateProduct(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Citrus includes products from lemons.
+	products, err := s.CategoryProducts("CITRUS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, p := range products {
+		ids = append(ids, string(p.ID))
+	}
+	sort.Strings(ids)
+
+	if !reflect.DeepEqual(ids, []string{"LEMON", "ORANGE"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	}
+
+	// Fruit includes everything.
+	if products, err := s.CategoryProducts("FRUIT"); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 4 {
+		t.Fatalf("unexpected product count: %d", len(products))
+	}
+}
+
+func TestCategoryService_CategoryProducts_ErrCategoryNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if _, err := c.CategoryService().CategoryProducts("X"); err != fruit.ErrCategoryNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestProductService_CreateProduct_ErrCategoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "P", Token: "TOKEN", CategoryID: "NO SUCH CATEGORY"}); err != fruit.ErrCategoryNotFound {
+		t.Fatal(err)
+	}
+}
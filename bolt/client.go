@@ -1,10 +1,13 @@
 package bolt
 
 import (
+	"context"
 	"time"
 
 	"github.com/asdine/storm"
 	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/feed"
+	bolt "go.etcd.io/bbolt"
 )
 
 // Client represents a client to the underlying bolt db structure.
@@ -15,9 +18,26 @@ type Client struct {
 	// Returns the current time.
 	Now func() time.Time
 
+	// If set, Open leaves pending schema migrations for Migrate to apply.
+	SkipMigrations bool
+
 	// Services
-	productService ProductService
-	userService    UserService
+	productService     ProductService
+	userService        UserService
+	transactionService TransactionService
+	categoryService    CategoryService
+	apiKeyService      APIKeyService
+	cartService        CartService
+	orderService       OrderService
+	inventoryService   InventoryService
+	importService      ImportService
+	eventService       EventService
+	webhookService     WebhookService
+	deliveryService    DeliveryService
+	revisionService    RevisionService
+
+	// Wakes change feed subscribers after events are committed.
+	broker feed.Broker
 
 	db *storm.DB
 }
@@ -26,6 +46,17 @@ func NewClient() *Client {
 	c := &Client{Now: time.Now}
 	c.productService.client = c
 	c.userService.client = c
+	c.transactionService.client = c
+	c.categoryService.client = c
+	c.apiKeyService.client = c
+	c.cartService.client = c
+	c.orderService.client = c
+	c.inventoryService.client = c
+	c.importService.client = c
+	c.eventService.client = c
+	c.webhookService.client = c
+	c.deliveryService.client = c
+	c.revisionService.client = c
 	return c
 }
 
@@ -38,10 +69,24 @@ func (c *Client) Open() error {
 
 	c.db = db
 
+	// Refuse databases written by a newer schema.
+	if _, err := c.SchemaVersion(); err != nil {
+		db.Close()
+		return err
+	}
+
+	if !c.SkipMigrations {
+		if err := c.Migrate(); err != nil {
+			db.Close()
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (c *Client) Close() error {
+	c.broker.Close()
 	if c.db != nil {
 		return c.db.Close()
 	}
@@ -55,3 +100,74 @@ func (c *Client) ProductService() fruit.ProductService {
 func (c *Client) UserService() fruit.UserService {
 	return &c.userService
 }
+
+func (c *Client) TransactionService() fruit.TransactionService {
+	return &c.transactionService
+}
+
+func (c *Client) CategoryService() fruit.CategoryService {
+	return &c.categoryService
+}
+
+func (c *Client) APIKeyService() fruit.APIKeyService {
+	return &c.apiKeyService
+}
+
+func (c *Client) CartService() fruit.CartService {
+	return &c.cartService
+}
+
+func (c *Client) OrderService() fruit.OrderService {
+	return &c.orderService
+}
+
+func (c *Client) InventoryService() fruit.InventoryService {
+	return &c.inventoryService
+}
+
+func (c *Client) ImportService() fruit.ImportService {
+	return &c.importService
+}
+
+func (c *Client) EventService() fruit.EventService {
+	return &c.eventService
+}
+
+func (c *Client) WebhookService() fruit.WebhookService {
+	return &c.webhookService
+}
+
+func (c *Client) DeliveryService() fruit.DeliveryService {
+	return &c.deliveryService
+}
+
+func (c *Client) RevisionService() fruit.RevisionService {
+	return &c.revisionService
+}
+
+// begin starts a transaction on n unless ctx is already done.
+func begin(ctx context.Context, n storm.Node, writable bool) (storm.Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return n.Begin(writable)
+}
+
+// view starts a read-only bolt transaction unless ctx is already done. It
+// is for reads which walk keys with a cursor, which storm can't do; nodes
+// join it with WithTransaction.
+func view(ctx context.Context, db *storm.DB) (*bolt.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return db.Bolt.Begin(false)
+}
+
+// commit commits tx unless ctx is done, in which case nothing is saved and
+// the caller's deferred rollback discards the transaction.
+func commit(ctx context.Context, tx storm.Node) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
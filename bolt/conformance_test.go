@@ -0,0 +1,15 @@
+package bolt_test
+
+import (
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func() (fruit.Client, func()) {
+		c := MustOpenClient()
+		return c, func() { c.Close() }
+	})
+}
@@ -0,0 +1,39 @@
+package bolt
+
+import (
+	"math"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/feed"
+)
+
+type EventService struct {
+	client *Client
+}
+
+// Subscribe returns a subscription to events after the sequence number
+// since. The subscription ends when the client is closed.
+func (s *EventService) Subscribe(since uint64) (fruit.Subscription, error) {
+	return feed.Subscribe(&s.client.broker, since, s.events), nil
+}
+
+// events returns up to n events after the sequence number since.
+func (s *EventService) events(since uint64, n int) ([]*fruit.Event, error) {
+	var events []*fruit.Event
+	if err := s.client.db.From("Events").Range("Seq", since+1, uint64(math.MaxUint64), &events, storm.Limit(n)); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+	return events, nil
+}
+
+// appendEvent records e in the change feed within a root transaction and
+// queues it for webhooks. Subscribers must be notified once the
+// transaction commits.
+func (c *Client) appendEvent(tx storm.Node, e *fruit.Event) error {
+	e.Time = c.Now().UTC()
+	if err := tx.From("Events").Save(e); err != nil {
+		return err
+	}
+	return queueDeliveries(tx, e)
+}
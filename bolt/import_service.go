@@ -0,0 +1,86 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+type ImportService struct {
+	client *Client
+}
+
+// ImportProducts creates or updates products in a single transaction.
+func (s *ImportService) ImportProducts(a []*fruit.Product, dryRun bool) ([]fruit.ImportResult, error) {
+	return s.run(dryRun, len(a), func(ctx context.Context, tx storm.Node, i int) (string, string, error) {
+		p := a[i]
+
+		var other fruit.Product
+		if err := tx.From("Products").One("ID", p.ID, &other); err == storm.ErrNotFound || p.ID == "" {
+			return string(p.ID), fruit.ImportCreated, s.client.productService.createProduct(ctx, tx, p)
+		} else if err != nil {
+			return string(p.ID), "", err
+		}
+
+		// Imports overwrite the stored record whatever its version.
+		p.Version = other.Version
+		return string(p.ID), fruit.ImportUpdated, s.client.productService.updateProduct(ctx, tx, p.ID, p)
+	})
+}
+
+// ImportUsers creates or replaces users in a single transaction.
+func (s *ImportService) ImportUsers(a []*fruit.User, dryRun bool) ([]fruit.ImportResult, error) {
+	return s.run(dryRun, len(a), func(ctx context.Context, tx storm.Node, i int) (string, string, error) {
+		u := a[i]
+
+		var other fruit.User
+		if err := tx.From("Users").One("ID", u.ID, &other); err == storm.ErrNotFound || u.ID == "" {
+			return string(u.ID), fruit.ImportCreated, s.client.userService.createUser(ctx, tx, u)
+		} else if err != nil {
+			return string(u.ID), "", err
+		}
+
+		// Imports overwrite the stored record whatever its version.
+		u.Version = 0
+		return string(u.ID), fruit.ImportUpdated, s.client.userService.updateUser(ctx, tx, u.ID, u)
+	})
+}
+
+// run imports n records with fn in one transaction. fn returns the record's
+// ID and action. Domain errors reject only that record, which is safe as
+// they're returned before anything is written. Other errors abort the
+// import. Dry runs are rolled back.
+func (s *ImportService) run(dryRun bool, n int, fn func(ctx context.Context, tx storm.Node, i int) (string, string, error)) ([]fruit.ImportResult, error) {
+	tx, err := s.client.db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Imports have no caller, so their revisions have no actor.
+	ctx := context.Background()
+
+	results := make([]fruit.ImportResult, n)
+	for i := range results {
+		id, action, err := fn(ctx, tx, i)
+		results[i].ID = id
+		if e, ok := err.(fruit.Error); ok {
+			results[i].Err = e.Error()
+		} else if err != nil {
+			return nil, err
+		} else {
+			results[i].Action = action
+		}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.client.broker.Notify()
+	return results, nil
+}
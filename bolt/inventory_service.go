@@ -0,0 +1,179 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+// InventoryService stores stock records in a bucket nested under Products so
+// they're removed along with their product. Every mutation runs in its own
+// read-write transaction, which bolt serializes.
+type InventoryService struct {
+	client *Client
+}
+
+// Stock returns the stock record for a product. Untracked products return an
+// empty record.
+func (s *InventoryService) Stock(id fruit.ProductID) (*fruit.Stock, error) {
+	if id == "" {
+		return nil, fruit.ErrProductIDRequired
+	}
+
+	products := s.client.db.From("Products")
+
+	// Verify product exists.
+	var p fruit.Product
+	if err := findProduct(products, id, &p); err != nil {
+		return nil, err
+	}
+
+	st, err := findStock(products.From("Stock"), id)
+	if err != nil {
+		return nil, err
+	} else if st == nil {
+		st = &fruit.Stock{ProductID: id}
+	}
+	return st, nil
+}
+
+// AdjustStock changes the units on hand for a product, starting to track it
+// if needed. On-hand stock can't drop below what is already reserved.
+func (s *InventoryService) AdjustStock(id fruit.ProductID, delta int) error {
+	return s.update(id, func(st *fruit.Stock) error {
+		if st.OnHand+delta < st.Reserved {
+			return fruit.ErrInsufficientStock
+		}
+		st.OnHand += delta
+		return nil
+	})
+}
+
+// ReserveStock holds quantity units of a product.
+func (s *InventoryService) ReserveStock(id fruit.ProductID, quantity int) error {
+	if id == "" {
+		return fruit.ErrProductIDRequired
+	} else if quantity <= 0 {
+		return fruit.ErrInvalidQuantity
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Products").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify product exists.
+	var p fruit.Product
+	if err := findProduct(tx, id, &p); err != nil {
+		return err
+	}
+
+	if err := reserveStock(tx.From("Stock"), id, quantity, s.client.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseStock returns quantity reserved units to available stock.
+func (s *InventoryService) ReleaseStock(id fruit.ProductID, quantity int) error {
+	if quantity <= 0 {
+		return fruit.ErrInvalidQuantity
+	}
+
+	return s.update(id, func(st *fruit.Stock) error {
+		if quantity > st.Reserved {
+			return fruit.ErrReservationExceeded
+		}
+		st.Reserved -= quantity
+		return nil
+	})
+}
+
+// CommitStock removes quantity reserved units from stock.
+func (s *InventoryService) CommitStock(id fruit.ProductID, quantity int) error {
+	if quantity <= 0 {
+		return fruit.ErrInvalidQuantity
+	}
+
+	return s.update(id, func(st *fruit.Stock) error {
+		if quantity > st.Reserved {
+			return fruit.ErrReservationExceeded
+		}
+		st.Reserved -= quantity
+		st.OnHand -= quantity
+		return nil
+	})
+}
+
+// update applies fn to the stock record of an existing product.
+func (s *InventoryService) update(id fruit.ProductID, fn func(st *fruit.Stock) error) error {
+	if id == "" {
+		return fruit.ErrProductIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Products").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify product exists.
+	var p fruit.Product
+	if err := findProduct(tx, id, &p); err != nil {
+		return err
+	}
+
+	stock := tx.From("Stock")
+	st, err := findStock(stock, id)
+	if err != nil {
+		return err
+	} else if st == nil {
+		st = &fruit.Stock{ProductID: id}
+	}
+
+	if err := fn(st); err != nil {
+		return err
+	}
+	st.ModTime = s.client.Now().UTC()
+
+	if err := stock.Save(st); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findStock returns the stock record for a product, or nil if untracked.
+func findStock(n storm.Node, id fruit.ProductID) (*fruit.Stock, error) {
+	var st fruit.Stock
+	if err := n.One("ProductID", id, &st); err == storm.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// reserveStock holds quantity units of a tracked product. Untracked products
+// are always available.
+func reserveStock(n storm.Node, id fruit.ProductID, quantity int, now time.Time) error {
+	st, err := findStock(n, id)
+	if err != nil {
+		return err
+	} else if st == nil {
+		return nil
+	}
+
+	if st.Available() < quantity {
+		return fruit.ErrInsufficientStock
+	}
+	st.Reserved += quantity
+	st.ModTime = now
+
+	return n.Save(st)
+}
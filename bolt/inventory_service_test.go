@@ -0,0 +1,150 @@
+package bolt_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+func TestInventoryService_AdjustStock(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.InventoryService()
+
+	MustCreateCartFixtures(c)
+
+	// Untracked products have an empty record.
+	if st, err := s.Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(st, &fruit.Stock{ProductID: "APPLE"}) {
+		t.Fatalf("unexpected stock: %+v", st)
+	}
+
+	if err := s.AdjustStock("APPLE", 10); err != nil {
+		t.Fatal(err)
+	} else if err := s.AdjustStock("APPLE", -3); err != nil {
+		t.Fatal(err)
+	}
+
+	if st, err := s.Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(st, &fruit.Stock{ProductID: "APPLE", OnHand: 7, ModTime: Now}) {
+		t.Fatalf("unexpected stock: %+v", st)
+	}
+}
+
+func TestInventoryService_AdjustStock_ErrInsufficientStock(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.InventoryService()
+
+	MustCreateCartFixtures(c)
+	if err := s.AdjustStock("APPLE", 5); err != nil {
+		t.Fatal(err)
+	} else if err := s.ReserveStock("APPLE", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reserved units can't be removed.
+	if err := s.AdjustStock("APPLE", -3); err != fruit.ErrInsufficientStock {
+		t.Fatal(err)
+	}
+}
+
+func TestInventoryService_AdjustStock_ErrProductNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.InventoryService().AdjustStock("NO SUCH PRODUCT", 1); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure stock moves through reserve, release and commit.
+func TestInventoryService_Reservations(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.InventoryService()
+
+	MustCreateCartFixtures(c)
+	if err := s.AdjustStock("APPLE", 10); err != nil {
+		t.Fatal(err)
+	} else if err := s.ReserveStock("APPLE", 6); err != nil {
+		t.Fatal(err)
+	} else if err := s.ReserveStock("APPLE", 5); err != fruit.ErrInsufficientStock {
+		t.Fatal(err)
+	} else if err := s.ReleaseStock("APPLE", 2); err != nil {
+		t.Fatal(err)
+	} else if err := s.CommitStock("APPLE", 3); err != nil {
+		t.Fatal(err)
+	} else if err := s.CommitStock("APPLE", 2); err != fruit.ErrReservationExceeded {
+		t.Fatal(err)
+	} else if err := s.ReleaseStock("APPLE", 2); err != fruit.ErrReservationExceeded {
+		t.Fatal(err)
+	}
+
+	if st, err := s.Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if st.OnHand != 7 || st.Reserved != 1 || st.Available() != 6 {
+		t.Fatalf("unexpected stock: %+v", st)
+	}
+
+	// Untracked products are always available.
+	if err := s.ReserveStock("PEAR", 100); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInventoryService_ReserveStock_ErrInvalidQuantity(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	MustCreateCartFixtures(c)
+	if err := c.InventoryService().ReserveStock("APPLE", 0); err != fruit.ErrInvalidQuantity {
+		t.Fatal(err)
+	}
+}
+
+// Ensure parallel reservations never oversell.
+func TestInventoryService_ReserveStock_Concurrent(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.InventoryService()
+
+	MustCreateCartFixtures(c)
+	if err := s.AdjustStock("APPLE", 50); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch err := s.ReserveStock("APPLE", 1); err {
+			case nil:
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			case fruit.ErrInsufficientStock:
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 50 {
+		t.Fatalf("unexpected reservations: %d", reserved)
+	} else if st, err := s.Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if st.Reserved != 50 || st.Available() != 0 {
+		t.Fatalf("unexpected stock: %+v", st)
+	}
+}
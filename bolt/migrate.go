@@ -0,0 +1,356 @@
+package bolt
+
+import (
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+// ErrSchemaTooNew is returned when opening a database written by a newer
+// version of this package.
+const ErrSchemaTooNew = fruit.Error("database schema is newer than supported")
+
+// migration upgrades the database by one schema version.
+type migration struct {
+	name string
+	fn   func(tx storm.Node) error
+}
+
+// migrations are applied in order. The schema version is the number of
+// migrations applied, so migrations must only ever be appended.
+var migrations = []migration{
+	{"index products by category and transactions by user", reindex},
+	{"build product search index", buildSearchIndex},
+	{"set initial version of products and users", setVersions},
+	{"record existing products and users in the revision history", recordRevisions},
+	{"hash stored product owner tokens", hashTokens},
+	{"index products by sku, type and color", reindexProducts},
+	{"move webhook secrets out of the webhook records", moveSecrets},
+	{"hash stored API keys", hashAPIKeys},
+	{"point at the latest revision of each record", recordLatestRevisions},
+	{"index the sort order of products and users", buildOrderIndex},
+}
+
+// reindex rebuilds the storm indexes of records saved before their
+// indexed fields were added.
+func reindex(tx storm.Node) error {
+	if err := reindexBucket(tx.From("Products"), &fruit.Product{}); err != nil {
+		return err
+	}
+	return reindexBucket(tx.From("Transactions"), &fruit.Transaction{})
+}
+
+// reindexProducts rebuilds the product indexes after SKU, Type and Color
+// were indexed.
+func reindexProducts(tx storm.Node) error {
+	return reindexBucket(tx.From("Products"), &fruit.Product{})
+}
+
+// reindexBucket rebuilds the indexes of the records of data's type in n.
+// Empty buckets are skipped as storm can't reindex a missing bucket.
+func reindexBucket(n storm.Node, data interface{}) error {
+	if count, err := n.Count(data); err != nil && err != storm.ErrNotFound {
+		return err
+	} else if count == 0 {
+		return nil
+	}
+	return n.ReIndex(data)
+}
+
+// buildSearchIndex indexes products created before search was added.
+func buildSearchIndex(tx storm.Node) error {
+	products := tx.From("Products")
+
+	var a []*fruit.Product
+	if err := products.All(&a); err != nil {
+		return err
+	}
+
+	for _, p := range a {
+		if err := indexProduct(products.From("Search"), p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setVersions gives products and users saved before versioning their first
+// version, so that updates to them can be checked for conflicts.
+func setVersions(tx storm.Node) error {
+	var products []*fruit.Product
+	if err := tx.From("Products").All(&products); err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := tx.From("Products").UpdateField(p, "Version", 1); err != nil {
+			return err
+		}
+	}
+
+	var users []*fruit.User
+	if err := tx.From("Users").All(&users); err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := tx.From("Users").UpdateField(u, "Version", 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordRevisions gives products and users saved before revision history a
+// first revision, dated by their modified time, so later changes are
+// compared with what was stored rather than with nothing.
+func recordRevisions(tx storm.Node) error {
+	var products []*fruit.Product
+	if err := tx.From("Products").All(&products); err != nil {
+		return err
+	}
+	for _, p := range products {
+		changes, err := fruit.DiffProducts(nil, p)
+		if err != nil {
+			return err
+		}
+
+		r := &fruit.Revision{Type: fruit.EventProductCreated, ProductID: p.ID, Changes: changes, Product: p, Time: p.ModTime}
+		if err := tx.From("Revisions").Save(r); err != nil {
+			return err
+		}
+	}
+
+	var users []*fruit.User
+	if err := tx.From("Users").All(&users); err != nil {
+		return err
+	}
+	for _, u := range users {
+		changes, err := fruit.DiffUsers(nil, u)
+		if err != nil {
+			return err
+		}
+
+		r := &fruit.Revision{Type: fruit.EventUserCreated, UserID: u.ID, Changes: changes, User: u, Time: u.ModTime}
+		if err := tx.From("Revisions").Save(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashTokens replaces owner tokens stored in plain text with their hash.
+// Products saved without a token are left ownerless.
+func hashTokens(tx storm.Node) error {
+	products := tx.From("Products")
+
+	var a []*fruit.Product
+	if err := products.All(&a); err != nil {
+		return err
+	}
+
+	for _, p := range a {
+		var t string
+		if err := products.Get("Tokens", p.ID, &t); err == storm.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		} else if token.IsHash(t) {
+			continue
+		}
+
+		if err := products.Set("Tokens", p.ID, token.Hash(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveSecrets copies the secret stored inside each webhook record into the
+// Secrets bucket, then saves the record again without it.
+func moveSecrets(tx storm.Node) error {
+	webhooks := tx.From("Webhooks")
+
+	// Decode the previous encoding, which kept the secret in the record.
+	type Webhook struct {
+		ID     fruit.WebhookID `json:"webhookID" storm:"id"`
+		Secret string          `json:"secret"`
+	}
+	var a []*Webhook
+	if err := webhooks.All(&a); err != nil {
+		return err
+	}
+
+	for _, old := range a {
+		var w fruit.Webhook
+		if err := webhooks.One("ID", old.ID, &w); err != nil {
+			return err
+		} else if err := webhooks.Set("Secrets", w.ID, old.Secret); err != nil {
+			return err
+		} else if err := webhooks.Save(&w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashAPIKeys stores API keys kept in plain text by their hash instead.
+// Products created with a key as their owner token had the plain key
+// hashed, and are moved to the hash of the key's hash so the key still
+// owns them.
+func hashAPIKeys(tx storm.Node) error {
+	keys := tx.From("APIKeys")
+
+	var a []*fruit.APIKey
+	if err := keys.All(&a); err != nil {
+		return err
+	}
+
+	owners := make(map[string]string)
+	for _, k := range a {
+		if token.IsHash(k.Key) {
+			continue
+		}
+		owners[token.Hash(k.Key)] = token.Hash(token.Hash(k.Key))
+
+		if err := keys.DeleteStruct(k); err != nil {
+			return err
+		}
+		k.Key = token.Hash(k.Key)
+		if err := keys.Save(k); err != nil {
+			return err
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	products := tx.From("Products")
+	var ps []*fruit.Product
+	if err := products.All(&ps); err != nil {
+		return err
+	}
+	for _, p := range ps {
+		var t string
+		if err := products.Get("Tokens", p.ID, &t); err == storm.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		} else if owner, ok := owners[t]; ok {
+			if err := products.Set("Tokens", p.ID, owner); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordLatestRevisions fills the Latest bucket which appendRevision reads
+// in place of the whole history.
+func recordLatestRevisions(tx storm.Node) error {
+	revisions := tx.From("Revisions")
+
+	var a []*fruit.Revision
+	if err := revisions.All(&a); err != nil {
+		return err
+	}
+
+	latest := make(map[string]fruit.RevisionID)
+	for _, r := range a {
+		if k := latestKey(r); r.ID > latest[k] {
+			latest[k] = r.ID
+		}
+	}
+	for k, id := range latest {
+		if err := revisions.Set("Latest", k, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildOrderIndex adds the order keys which products and users are paged
+// through.
+func buildOrderIndex(tx storm.Node) error {
+	products := tx.From("Products")
+	var a []*fruit.Product
+	if err := products.All(&a); err != nil {
+		return err
+	}
+	for _, p := range a {
+		if err := indexOrder(products, p, productSortFields, productFilters...); err != nil {
+			return err
+		}
+	}
+
+	users := tx.From("Users")
+	var b []*fruit.User
+	if err := users.All(&b); err != nil {
+		return err
+	}
+	for _, u := range b {
+		if err := indexOrder(users, u, userSortFields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the schema version of the open database.
+func (c *Client) SchemaVersion() (int, error) {
+	return schemaVersion(c.db)
+}
+
+// PendingMigrations returns the names of migrations not yet applied.
+func (c *Client) PendingMigrations() ([]string, error) {
+	version, err := c.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, m := range migrations[version:] {
+		names = append(names, m.name)
+	}
+	return names, nil
+}
+
+// Migrate applies all pending migrations in a single transaction.
+func (c *Client) Migrate() error {
+	tx, err := c.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	version, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	} else if version == len(migrations) {
+		return nil
+	}
+
+	for _, m := range migrations[version:] {
+		if err := m.fn(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Set("Meta", "version", len(migrations)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// schemaVersion returns the stored schema version. Databases created before
+// versioning are at version zero.
+func schemaVersion(n storm.Node) (int, error) {
+	var version int
+	if err := n.Get("Meta", "version", &version); err != nil && err != storm.ErrNotFound {
+		return 0, err
+	}
+
+	if version > len(migrations) {
+		return 0, ErrSchemaTooNew
+	}
+	return version, nil
+}
@@ -0,0 +1,353 @@
+package bolt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/bolt"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+// Ensure a new database is created at the latest schema version.
+func TestClient_Migrate_New(t *testing.T) {
+	c := NewClient()
+	c.SkipMigrations = true
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	pending, err := c.PendingMigrations()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(pending) == 0 {
+		t.Fatal("expected pending migrations")
+	}
+
+	if err := c.Migrate(); err != nil {
+		t.Fatal(err)
+	} else if version, err := c.SchemaVersion(); err != nil {
+		t.Fatal(err)
+	} else if version != len(pending) {
+		t.Fatalf("unexpected version: %d", version)
+	} else if pending, err := c.PendingMigrations(); err != nil {
+		t.Fatal(err)
+	} else if len(pending) != 0 {
+		t.Fatalf("unexpected pending migrations: %v", pending)
+	}
+}
+
+// Ensure records written before versioning are migrated on open.
+func TestClient_Migrate_Unversioned(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+	defer c.Close()
+
+	// Write records the way earlier versions did, without search terms or
+	// versions.
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Save(&fruit.Product{ID: "A", Name: "Apple"}); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Users").Save(&fruit.User{ID: "U", Name: "Ursula"}); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if products, _, err := c.ProductService().Search(ctx, "apple", fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 1 || products[0].ID != "A" || products[0].Version != 1 {
+		t.Fatalf("unexpected products: %+v", products)
+	} else if u, err := c.UserService().User(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if u.Version != 1 {
+		t.Fatalf("unexpected version: %d", u.Version)
+	}
+
+	// Existing records start their revision history.
+	if a, err := c.RevisionService().ProductRevisions(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].Type != fruit.EventProductCreated || a[0].Product.Name != "Apple" {
+		t.Fatalf("unexpected revisions: %+v", a)
+	} else if a, err := c.RevisionService().UserRevisions(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].Type != fruit.EventUserCreated || a[0].User.Name != "Ursula" {
+		t.Fatalf("unexpected revisions: %+v", a)
+	}
+}
+
+// Ensure a database written by a newer schema is not opened.
+func TestClient_Open_ErrSchemaTooNew(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.Set("Meta", "version", 1000); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != bolt.ErrSchemaTooNew {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The check still applies when migrations are skipped.
+	c.SkipMigrations = true
+	if err := c.Open(); err != bolt.ErrSchemaTooNew {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure owner tokens stored in plain text are hashed on open and that
+// products saved without a token may only be changed by an admin.
+func TestClient_Migrate_Tokens(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+	defer c.Close()
+
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Save(&fruit.Product{ID: "A", Name: "Apple"}); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Save(&fruit.Product{ID: "B", Name: "Banana"}); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Set("Tokens", fruit.ProductID("B"), "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	s := c.ProductService()
+
+	// The owner's token still works but is never returned.
+	if err := s.UpdateProduct(ctx, "B", &fruit.Product{Name: "Blueberry", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if p, err := s.Product(ctx, "B"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Blueberry" || p.Token != "" {
+		t.Fatalf("unexpected product: %+v", p)
+	}
+
+	// An ownerless product can't be claimed by guessing a token.
+	user := fruit.NewPrincipalContext(ctx, &fruit.Principal{UserID: "U"})
+	if err := s.UpdateProduct(user, "A", &fruit.Product{Name: "Apricot", Token: "TOKEN", Version: 1}); err != fruit.ErrUnauthorized {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.DeleteProduct(ctx, "A", ""); err != fruit.ErrUnauthorized {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Admins may change it.
+	admin := fruit.NewPrincipalContext(ctx, &fruit.Principal{UserID: "ADMIN", Admin: true})
+	if err := s.UpdateProduct(admin, "A", &fruit.Product{Name: "Apricot", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := s.DeleteProduct(admin, "A", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure products saved before SKU, Type and Color were indexed can be
+// found by those filters.
+func TestClient_Migrate_ProductIndexes(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+	defer c.Close()
+
+	// Save a product the way the previous schema version did, without the
+	// index tags, so it is missing from the new indexes.
+	type Product struct {
+		ID   fruit.ProductID `json:"productID" storm:"id"`
+		SKU  string          `json:"sku"`
+		Type string          `json:"type"`
+	}
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Save(&Product{ID: "A", SKU: "APL", Type: "Apple"}); err != nil {
+		t.Fatal(err)
+	} else if err := db.Set("Meta", "version", 5); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opt := range []fruit.QueryOptions{{SKU: "APL"}, {Type: "Apple"}} {
+		if products, _, err := c.ProductService().Products(ctx, opt); err != nil {
+			t.Fatal(err)
+		} else if len(products) != 1 || products[0].ID != "A" {
+			t.Fatalf("%+v: unexpected products: %+v", opt, products)
+		}
+	}
+}
+
+// Ensure webhook secrets stored inside the webhook record survive the move
+// to the Secrets bucket.
+func TestClient_Migrate_WebhookSecrets(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	// Save a webhook the way the previous schema version did.
+	type Webhook struct {
+		ID     fruit.WebhookID `json:"webhookID" storm:"id"`
+		URL    string          `json:"url"`
+		Secret string          `json:"secret"`
+	}
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Webhooks").Save(&Webhook{ID: "W", URL: "http://example.com", Secret: "SECRET"}); err != nil {
+		t.Fatal(err)
+	} else if err := db.Set("Meta", "version", 6); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if w, err := c.WebhookService().Webhook("W"); err != nil {
+		t.Fatal(err)
+	} else if w.URL != "http://example.com" || w.Secret != "SECRET" {
+		t.Fatalf("unexpected webhook: %+v", w)
+	}
+}
+
+// Ensure API keys stored in plain text are hashed on open and keep owning
+// the products created with them.
+func TestClient_Migrate_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+	defer c.Close()
+
+	// Save a key and a product it owns the way the previous schema version
+	// did, with the plain key as the product's owner token.
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.From("APIKeys").Save(&fruit.APIKey{Key: "KEY", UserID: "U"}); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Save(&fruit.Product{ID: "A", Name: "Apple", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Set("Tokens", fruit.ProductID("A"), token.Hash("KEY")); err != nil {
+		t.Fatal(err)
+	} else if err := db.Set("Meta", "version", 7); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := c.APIKeyService().APIKey("KEY")
+	if err != nil {
+		t.Fatal(err)
+	} else if k.Key != token.Hash("KEY") {
+		t.Fatalf("unexpected stored key: %s", k.Key)
+	}
+
+	// The key's principal still owns the product.
+	user := fruit.NewPrincipalContext(ctx, k.Principal())
+	if err := c.ProductService().UpdateProduct(user, "A", &fruit.Product{Name: "Apricot", Token: k.Key, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure changes after upgrading are compared with the latest revision
+// recorded before it.
+func TestClient_Migrate_LatestRevisions(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+	defer c.Close()
+
+	// Save a product and its history the way the previous schema version
+	// did, without pointing at the latest revision.
+	p := &fruit.Product{ID: "A", Name: "Banana", Version: 2}
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Save(p); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Revisions").Save(&fruit.Revision{Type: fruit.EventProductCreated, ProductID: "A", Product: &fruit.Product{ID: "A", Name: "Apple", Version: 1}}); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Revisions").Save(&fruit.Revision{Type: fruit.EventProductUpdated, ProductID: "A", Product: p}); err != nil {
+		t.Fatal(err)
+	} else if err := db.Set("Meta", "version", 8); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	admin := fruit.NewPrincipalContext(ctx, &fruit.Principal{UserID: "ADMIN", Admin: true})
+	if err := c.ProductService().UpdateProduct(admin, "A", &fruit.Product{Name: "Banana", Description: "Ripe", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := c.RevisionService().ProductRevisions(ctx, "A")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 3 {
+		t.Fatalf("unexpected revisions: %+v", a)
+	} else if changes := a[2].Changes; len(changes) != 1 || changes[0].Field != "description" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
+
+// Ensure records saved before they had order keys are paged through.
+func TestClient_Migrate_OrderIndex(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+	defer c.Close()
+
+	db, err := storm.Open(c.Path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Products").Save(&fruit.Product{ID: "A", Name: "Apple", Type: "Pome", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := db.From("Users").Save(&fruit.User{ID: "U", Name: "Ursula", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := db.Set("Meta", "version", 9); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if products, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{Type: "Pome", Sort: "name"}); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 1 || products[0].ID != "A" {
+		t.Fatalf("unexpected products: %+v", products)
+	} else if users, _, err := c.UserService().Users(ctx, fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(users) != 1 || users[0].ID != "U" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+}
@@ -0,0 +1,126 @@
+package bolt
+
+import (
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+type OrderService struct {
+	client *Client
+}
+
+// Order returns an order by ID.
+func (s *OrderService) Order(id fruit.OrderID) (*fruit.Order, error) {
+	if id == "" {
+		return nil, fruit.ErrOrderIDRequired
+	}
+
+	var o fruit.Order
+	if err := s.client.db.From("Orders").One("ID", id, &o); err == storm.ErrNotFound {
+		return nil, fruit.ErrOrderNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Orders returns all orders placed by a user.
+func (s *OrderService) Orders(id fruit.UserID) ([]*fruit.Order, error) {
+	orders := []*fruit.Order{}
+	if err := s.client.db.From("Orders").Find("UserID", id, &orders); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// Checkout creates an order from the user's cart, reserves stock for it and
+// clears the cart. All changes are made in a single transaction.
+func (s *OrderService) Checkout(id fruit.UserID) (*fruit.Order, error) {
+	if id == "" {
+		return nil, fruit.ErrUserIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Verify user exists.
+	var u fruit.User
+	if err := tx.From("Users").One("ID", id, &u); err == storm.ErrNotFound {
+		return nil, fruit.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	carts := tx.From("Carts")
+	c, err := findCart(carts, id)
+	if err != nil {
+		return nil, err
+	} else if len(c.Items) == 0 {
+		return nil, fruit.ErrCartEmpty
+	}
+
+	orderID, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	o := &fruit.Order{
+		ID:      fruit.OrderID(orderID),
+		UserID:  id,
+		Items:   make([]fruit.OrderItem, 0, len(c.Items)),
+		ModTime: s.client.Now().UTC(),
+	}
+
+	// Snapshot each product as it is now and hold its stock.
+	products := tx.From("Products")
+	for _, item := range c.Items {
+		var p fruit.Product
+		if err := findProduct(products, item.ProductID, &p); err != nil {
+			return nil, err
+		}
+
+		if p.Price == nil {
+			return nil, fruit.ErrProductPriceRequired
+		}
+
+		if err := reserveStock(products.From("Stock"), p.ID, item.Quantity, o.ModTime); err != nil {
+			return nil, err
+		}
+
+		o.Items = append(o.Items, fruit.OrderItem{
+			ProductID: p.ID,
+			Name:      p.Name,
+			SKU:       p.SKU,
+			Price:     *p.Price,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	// Total the order in the currency of its first item.
+	o.Total = fruit.Money{Currency: o.Items[0].Price.Currency}
+	for _, item := range o.Items {
+		subtotal, err := item.Price.Mul(item.Quantity)
+		if err != nil {
+			return nil, err
+		} else if o.Total, err = o.Total.Add(subtotal); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.From("Orders").Save(o); err != nil {
+		return nil, err
+	} else if err := clearCart(carts, id); err != nil {
+		return nil, err
+	} else if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventOrderCreated, UserID: id, OrderID: o.ID, Order: o}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.client.broker.Notify()
+	return o, nil
+}
@@ -0,0 +1,196 @@
+package bolt_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+func TestOrderService_Checkout(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.OrderService()
+
+	MustCreateCartFixtures(c)
+	if err := c.ProductService().UpdateProduct(ctx, "APPLE", &fruit.Product{Name: "Apple", SKU: "A-1", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "APPLE", 2); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "PEAR", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := s.Checkout("USER")
+	if err != nil {
+		t.Fatal(err)
+	} else if o.ID == "" {
+		t.Fatal("expected order id")
+	} else if !reflect.DeepEqual(o.Items, []fruit.OrderItem{
+		{ProductID: "APPLE", Name: "Apple", SKU: "A-1", Price: fruit.NewMoney(100, "USD"), Quantity: 2},
+		{ProductID: "PEAR", Price: fruit.NewMoney(250, "USD"), Quantity: 1},
+	}) {
+		t.Fatalf("unexpected items: %+v", o.Items)
+	} else if o.Total != fruit.NewMoney(450, "USD") {
+		t.Fatalf("unexpected total: %+v", o.Total)
+	} else if !o.ModTime.Equal(Now) {
+		t.Fatalf("unexpected mod time: %s", o.ModTime)
+	}
+
+	// Later product changes don't alter the order.
+	if err := c.ProductService().UpdateProduct(ctx, "APPLE", &fruit.Product{Name: "Green Apple", Price: &fruit.Money{Amount: 999, Currency: "USD"}, Token: "TOKEN", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if other, err := s.Order(o.ID); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, o) {
+		t.Fatalf("unexpected order: %+v", other)
+	}
+
+	// Cart is emptied.
+	if cart, err := c.CartService().Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(cart.Items) != 0 {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	if orders, err := s.Orders("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(orders) != 1 || orders[0].ID != o.ID {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+}
+
+func TestOrderService_Checkout_ErrCartEmpty(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	MustCreateCartFixtures(c)
+
+	if _, err := c.OrderService().Checkout("USER"); err != fruit.ErrCartEmpty {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderService_Checkout_ErrUserNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if _, err := c.OrderService().Checkout("NO SUCH USER"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a failed checkout leaves the cart untouched and creates no order.
+func TestOrderService_Checkout_ErrProductNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.OrderService()
+
+	MustCreateCartFixtures(c)
+	if err := c.CartService().AddCartItem("USER", "APPLE", 1); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "PEAR", 1); err != nil {
+		t.Fatal(err)
+	} else if err := c.ProductService().DeleteProduct(ctx, "PEAR", "TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Checkout("USER"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	if cart, err := c.CartService().Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(cart.Items) != 2 {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	if orders, err := s.Orders("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(orders) != 0 {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+}
+
+func TestOrderService_Order_ErrOrderNotFound(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	if _, err := c.OrderService().Order("NO SUCH ORDER"); err != fruit.ErrOrderNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure checkout reserves stock and fails without changes when short.
+func TestOrderService_Checkout_ErrInsufficientStock(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.OrderService()
+
+	MustCreateCartFixtures(c)
+	if err := c.InventoryService().AdjustStock("APPLE", 3); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "APPLE", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Checkout("USER"); err != nil {
+		t.Fatal(err)
+	} else if st, err := c.InventoryService().Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if st.Reserved != 2 {
+		t.Fatalf("unexpected stock: %+v", st)
+	}
+
+	// Only one unit is left.
+	if err := c.CartService().AddCartItem("USER", "APPLE", 2); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Checkout("USER"); err != fruit.ErrInsufficientStock {
+		t.Fatal(err)
+	} else if st, err := c.InventoryService().Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if st.Reserved != 2 {
+		t.Fatalf("unexpected stock: %+v", st)
+	}
+}
+
+func TestOrderService_Checkout_ErrCurrencyMismatch(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	MustCreateCartFixtures(c)
+	if err := c.ProductService().UpdateProduct(ctx, "PEAR", &fruit.Product{Price: &fruit.Money{Amount: 250, Currency: "EUR"}, Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "APPLE", 1); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "PEAR", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.OrderService().Checkout("USER"); err != fruit.ErrCurrencyMismatch {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderService_Checkout_ErrProductPriceRequired(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	MustCreateCartFixtures(c)
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "PLUM", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "PLUM", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.OrderService().Checkout("USER"); err != fruit.ErrProductPriceRequired {
+		t.Fatal(err)
+	}
+}
@@ -1,9 +1,14 @@
 package bolt
 
 import (
+	"context"
 	"time"
 
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
 	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/search"
+	"github.com/notjrbauer/fruit/internal/token"
 )
 
 type ProductService struct {
@@ -11,115 +16,603 @@ type ProductService struct {
 }
 
 // Product returns a product by ID.
-func (s *ProductService) Product(id fruit.ProductID) (*fruit.Product, error) {
+func (s *ProductService) Product(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Find and unmarshal product.
 	var p fruit.Product
 	products := s.client.db.From("Products")
 
-	if err := products.One("ID", id, &p); err != nil {
+	if err := findProduct(products, id, &p); err != nil {
 		return nil, err
-	} else if &p == nil {
-		return nil, nil
 	}
 
 	return &p, nil
 }
 
-func (s *ProductService) Products() ([]*fruit.Product, error) {
-	var products []*fruit.Product
-	if err := s.client.db.From("Products").All(&products); err != nil {
-		return nil, err
+// Products returns a page of products matching opt.
+func (s *ProductService) Products(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	// Start read-only transaction.
+	tx, err := view(ctx, s.client.db)
+	if err != nil {
+		return nil, "", err
 	}
-	return products, nil
+	defer tx.Rollback()
+
+	// Walk only the products with an indexed filter if there is one.
+	products := []*fruit.Product{}
+	index, value := productIndex(opt)
+	next, err := find(tx, s.client.db.From("Products").WithTransaction(tx), opt, productSortFields, &products, index, value, productMatchers(opt)...)
+	if err != nil {
+		return nil, "", err
+	}
+	return products, next, nil
 }
 
-// CreateProduct creates a new product.
-func (s *ProductService) CreateProduct(p *fruit.Product) error {
-	// Require id
-	if p.ID == "" {
-		return fruit.ErrProductIDRequired
+// Search returns a page of products matching query, best match first. The
+// sort options are ignored.
+func (s *ProductService) Search(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	if len(search.Tokenize(query)) == 0 {
+		return nil, "", fruit.ErrSearchQueryRequired
+	} else if opt.Limit < 0 {
+		return nil, "", fruit.ErrInvalidLimit
+	}
+
+	// Results resume after the score and ID of the last product of the
+	// previous page.
+	var lastScore int
+	var lastID fruit.ProductID
+	if opt.Cursor != "" {
+		if err := decodeCursor(opt.Cursor, &lastScore, &lastID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Start read-only transaction.
+	tx, err := begin(ctx, s.client.db.From("Products"), false)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	ids, scores, err := searchIndex(tx.From("Search"), query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filter := q.And(productMatchers(opt)...)
+	products := []*fruit.Product{}
+	for _, id := range ids {
+		// Skip to the cursor.
+		if opt.Cursor != "" && (scores[id] > lastScore || (scores[id] == lastScore && id <= lastID)) {
+			continue
+		}
+
+		var p fruit.Product
+		if err := tx.One("ID", id, &p); err != nil {
+			return nil, "", err
+		}
+
+		// Apply filters.
+		if ok, err := filter.Match(&p); err != nil {
+			return nil, "", err
+		} else if !ok {
+			continue
+		}
+
+		if opt.Limit > 0 && len(products) == opt.Limit {
+			last := products[len(products)-1]
+			next, err := encodeCursor(scores[last.ID], last.ID)
+			return products, next, err
+		}
+		products = append(products, &p)
 	}
+	return products, "", nil
+}
 
-	bucket := s.client.db.From("Products")
+// CreateProduct creates a new product. The product's token identifies its
+// owner and must be supplied on later updates and deletes.
+func (s *ProductService) CreateProduct(ctx context.Context, p *fruit.Product) error {
 	// Start the read-write transaction.
-	tx, err := bucket.Begin(true)
+	tx, err := begin(ctx, s.client.db, true)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Verify product doesn't already exist.
-	product, err := s.Product(p.ID)
+	if err := s.createProduct(ctx, tx, p); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+	s.client.broker.Notify()
+	return nil
+}
+
+// createProduct creates a product within a root transaction. Validation
+// errors are returned before anything is written.
+func (s *ProductService) createProduct(ctx context.Context, tx storm.Node, p *fruit.Product) error {
+	// Require id
+	if p.ID == "" {
+		return fruit.ErrProductIDRequired
+	}
 
-	if err != nil && err.Error() != "not found" {
+	// Require owner token.
+	if p.Token == "" {
+		return fruit.ErrUnauthorized
+	}
+
+	// Validate price.
+	if p.Price != nil {
+		if err := p.Price.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Verify category exists.
+	if err := verifyCategory(tx, p.CategoryID); err != nil {
 		return err
 	}
 
-	if product != nil {
+	// Verify product doesn't already exist.
+	products := tx.From("Products")
+	var other fruit.Product
+	if err := products.One("ID", p.ID, &other); err == nil {
 		return fruit.ErrProductExists
+	} else if err != storm.ErrNotFound {
+		return err
 	}
 
-	// Update modified time.
-	p.ModTime = time.Now().UTC()
+	// Set initial version and modified time. New products are never in the
+	// trash.
+	p.Version = 1
+	p.ModTime = s.client.Now().UTC()
+	p.DeletedAt = nil
 
-	if err := tx.Save(p); err != nil {
+	if err := products.Save(p); err != nil {
+		return err
+	}
+
+	// Tokens are hidden from JSON so they're stored beside the product, and
+	// only as a hash.
+	if err := products.Set("Tokens", p.ID, token.Hash(p.Token)); err != nil {
+		return err
+	}
+
+	if err := indexProduct(products.From("Search"), p); err != nil {
+		return err
+	} else if err := indexOrder(products, p, productSortFields, productFilters...); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventProductCreated, ProductID: p.ID, Product: p}); err != nil {
+		return err
+	}
+	return s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventProductCreated, ProductID: p.ID, Product: p})
 }
 
 // UpdateProduct updates an existing product.
-func (s *ProductService) UpdateProduct(id fruit.ProductID, p *fruit.Product) error {
+func (s *ProductService) UpdateProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
 	// Start read-write transaction.
-
-	tx, err := s.client.db.From("Products").Begin(true)
+	tx, err := begin(ctx, s.client.db, true)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := s.updateProduct(ctx, tx, id, p); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+	s.client.broker.Notify()
+	return nil
+}
+
+// updateProduct updates a product within a root transaction. Blank fields
+// are left unchanged, and the new version and modified time are copied to
+// p. Validation errors are returned before anything is written.
+func (s *ProductService) updateProduct(ctx context.Context, tx storm.Node, id fruit.ProductID, p *fruit.Product) error {
+	// Require the version the changes are based on.
+	if p.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	// Validate price.
+	if p.Price != nil {
+		if err := p.Price.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Verify category exists.
+	if err := verifyCategory(tx, p.CategoryID); err != nil {
+		return err
+	}
+
 	// Find record.
+	products := tx.From("Products")
 	var product fruit.Product
-	if err := tx.One("ID", p.ID, &product); err != nil {
-		return fruit.ErrProductNotFound
+	if err := findProduct(products, id, &product); err != nil {
+		return err
+	}
+
+	// Only the owner may update the product.
+	if err := authorize(ctx, products, id, p.Token); err != nil {
+		return err
+	}
+
+	// Reject changes based on an old version.
+	if p.Version != product.Version {
+		return fruit.ErrConflict
 	}
 
 	// Apply changes.
 	var d fruit.Product
-	d.ID = p.ID
+	d.ID = id
 	d.Color = p.Color
 	d.Description = p.Description
 	d.Name = p.Name
 	d.SKU = p.SKU
 	d.Type = p.Type
-	d.ModTime = time.Now().UTC()
+	d.Price = p.Price
+	d.CategoryID = p.CategoryID
+	d.Version = product.Version + 1
+	d.ModTime = s.client.Now().UTC()
+
+	if err := products.Update(&d); err != nil {
+		return err
+	}
+
+	// Reindex the product as it is stored.
+	if err := products.One("ID", id, &d); err != nil {
+		return err
+	} else if err := indexProduct(products.From("Search"), &d); err != nil {
+		return err
+	} else if err := indexOrder(products, &d, productSortFields, productFilters...); err != nil {
+		return err
+	}
+	p.Version, p.ModTime = d.Version, d.ModTime
+
+	if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventProductUpdated, ProductID: id, Product: &d}); err != nil {
+		return err
+	}
+	return s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventProductUpdated, ProductID: id, Product: &d})
+}
+
+// PatchProduct changes the fields of a product named in mask.
+func (s *ProductService) PatchProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
+	// Require the version the changes are based on.
+	if p.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	// Start read-write transaction.
+	tx, err := begin(ctx, s.client.db, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.patchProduct(ctx, tx, id, p, mask); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+	s.client.broker.Notify()
+	return nil
+}
+
+// patchProduct applies a partial update within a root transaction and
+// copies the stored product to p. Validation errors are returned before
+// anything is written.
+func (s *ProductService) patchProduct(ctx context.Context, tx storm.Node, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
+	// Find record.
+	products := tx.From("Products")
+	var product fruit.Product
+	if err := findProduct(products, id, &product); err != nil {
+		return err
+	}
+
+	// Only the owner may update the product.
+	if err := authorize(ctx, products, id, p.Token); err != nil {
+		return err
+	}
+
+	// Reject changes based on an old version.
+	if p.Version != 0 && p.Version != product.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes and validate the result.
+	if err := product.Patch(p, mask); err != nil {
+		return err
+	} else if product.Price != nil {
+		if err := product.Price.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := verifyCategory(tx, product.CategoryID); err != nil {
+		return err
+	}
+	product.Version++
+	product.ModTime = s.client.Now().UTC()
 
-	if err := tx.Update(&d); err != nil {
+	// Masked fields may be patched to empty, unlike with UpdateProduct.
+	if err := products.Save(&product); err != nil {
+		return err
+	} else if err := indexProduct(products.From("Search"), &product); err != nil {
+		return err
+	} else if err := indexOrder(products, &product, productSortFields, productFilters...); err != nil {
+		return err
+	} else if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventProductUpdated, ProductID: id, Product: &product}); err != nil {
+		return err
+	} else if err := s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventProductUpdated, ProductID: id, Product: &product}); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	product.Token = p.Token
+	*p = product
+	return nil
 }
 
 // DeleteProduct removes an existing product.
-func (s *ProductService) DeleteProduct(id fruit.ProductID, token string) error {
+func (s *ProductService) DeleteProduct(ctx context.Context, id fruit.ProductID, token string) error {
 	// Start the read-write transaction.
-	tx, err := s.client.db.From("Products").Begin(true)
+	tx, err := begin(ctx, s.client.db, true)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := s.deleteProduct(ctx, tx, id, token); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+	s.client.broker.Notify()
+	return nil
+}
+
+// deleteProduct removes a product within a root transaction. Validation
+// errors are returned before anything is written.
+func (s *ProductService) deleteProduct(ctx context.Context, tx storm.Node, id fruit.ProductID, token string) error {
 	// Find record.
+	products := tx.From("Products")
+	var product fruit.Product
+	if err := findProduct(products, id, &product); err != nil {
+		return err
+	}
+
+	// Only the owner may delete the product.
+	if err := authorize(ctx, products, id, token); err != nil {
+		return err
+	}
+
+	// Keep the record, along with its token, stock and search terms, so it
+	// can be restored.
+	now := s.client.Now().UTC()
+	product.DeletedAt = &now
+	if err := products.Save(&product); err != nil {
+		return err
+	}
+
+	if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventProductDeleted, ProductID: id}); err != nil {
+		return err
+	}
+	return s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventProductDeleted, ProductID: id, Product: &product})
+}
+
+// RestoreProduct moves a deleted product out of the trash.
+func (s *ProductService) RestoreProduct(ctx context.Context, id fruit.ProductID) error {
+	// Start the read-write transaction.
+	tx, err := begin(ctx, s.client.db, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record, including deleted records.
+	products := tx.From("Products")
 	var product fruit.Product
-	if err := tx.One("ID", id, &product); err != nil {
+	if err := products.One("ID", id, &product); err == storm.ErrNotFound {
+		return fruit.ErrProductNotFound
+	} else if err != nil {
+		return err
+	} else if product.DeletedAt == nil {
+		return fruit.ErrProductNotDeleted
+	}
+
+	// Its category may have been deleted while it was in the trash.
+	if err := verifyCategory(tx, product.CategoryID); err == fruit.ErrCategoryNotFound {
+		product.CategoryID = ""
+	} else if err != nil {
+		return err
+	}
+
+	// Clear the deletion time. A nil field needs Save rather than Update.
+	product.DeletedAt = nil
+	if err := products.Save(&product); err != nil {
+		return err
+	} else if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventProductRestored, ProductID: id, Product: &product}); err != nil {
+		return err
+	} else if err := s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventProductRestored, ProductID: id, Product: &product}); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+	s.client.broker.Notify()
+	return nil
+}
+
+// PurgeProducts permanently removes products deleted before t.
+func (s *ProductService) PurgeProducts(ctx context.Context, before time.Time) (int, error) {
+	// Start the read-write transaction.
+	tx, err := begin(ctx, s.client.db, true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	products := tx.From("Products")
+	var deleted []*fruit.Product
+	if err := products.Select(q.NewFieldMatcher("DeletedAt", deletedBefore(before))).Find(&deleted); err != nil && err != storm.ErrNotFound {
+		return 0, err
+	}
+
+	for _, p := range deleted {
+		if err := products.DeleteStruct(p); err != nil {
+			return 0, err
+		} else if err := products.Delete("Tokens", p.ID); err != nil {
+			return 0, err
+		}
+
+		// Stop tracking its stock.
+		if err := products.From("Stock").DeleteStruct(&fruit.Stock{ProductID: p.ID}); err != nil && err != storm.ErrNotFound {
+			return 0, err
+		}
+
+		if err := unindexProduct(products.From("Search"), p.ID); err != nil {
+			return 0, err
+		} else if err := unindexOrder(products, string(p.ID)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return 0, err
+	}
+	return len(deleted), nil
+}
+
+// Batch applies a series of operations in one transaction. Failed
+// operations are skipped unless atomic is set, in which case the whole
+// batch is rolled back.
+func (s *ProductService) Batch(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+	// Start the read-write transaction.
+	tx, err := begin(ctx, s.client.db, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]fruit.BatchResult, len(ops))
+	failed := false
+	for i, op := range ops {
+		// Stop early if the caller has gone away.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Domain errors are returned before anything is written, so only
+		// other errors leave the transaction in an unknown state.
+		err := s.batchOp(ctx, tx, op)
+		if _, ok := err.(fruit.Error); err != nil && !ok {
+			return nil, err
+		}
+		results[i] = fruit.NewBatchResult(i, op, err)
+		failed = failed || err != nil
+	}
+
+	// Report the successful operations of a failed atomic batch as aborted.
+	if atomic && failed {
+		for i := range results {
+			if results[i].Err == "" {
+				results[i] = fruit.NewBatchResult(i, ops[i], fruit.ErrBatchAborted)
+			}
+		}
+		return results, nil
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	s.client.broker.Notify()
+	return results, nil
+}
+
+// batchOp applies a single batch operation within a root transaction.
+func (s *ProductService) batchOp(ctx context.Context, tx storm.Node, op fruit.BatchOp) error {
+	switch op.Op {
+	case fruit.BatchCreate, fruit.BatchUpdate:
+		if op.Product == nil {
+			return fruit.ErrProductRequired
+		}
+		op.Product.Token = op.Token
+
+		if op.Op == fruit.BatchCreate {
+			return s.createProduct(ctx, tx, op.Product)
+		} else if op.ID == "" {
+			return fruit.ErrProductIDRequired
+		}
+		return s.updateProduct(ctx, tx, op.ID, op.Product)
+	case fruit.BatchDelete:
+		if op.ID == "" {
+			return fruit.ErrProductIDRequired
+		}
+		return s.deleteProduct(ctx, tx, op.ID, op.Token)
+	default:
+		return fruit.ErrInvalidBatchOp
+	}
+}
+
+// verifyCategory returns ErrCategoryNotFound if id is set and doesn't
+// exist.
+func verifyCategory(tx storm.Node, id fruit.CategoryID) error {
+	if id == "" {
+		return nil
+	}
+
+	var c fruit.Category
+	if err := tx.From("Categories").One("ID", id, &c); err == storm.ErrNotFound {
+		return fruit.ErrCategoryNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// findProduct loads a product from the Products bucket n. Deleted products
+// are reported as not found.
+func findProduct(n storm.Node, id fruit.ProductID, p *fruit.Product) error {
+	if err := n.One("ID", id, p); err == storm.ErrNotFound {
+		return fruit.ErrProductNotFound
+	} else if err != nil {
+		return err
+	} else if p.DeletedAt != nil {
 		return fruit.ErrProductNotFound
 	}
+	return nil
+}
 
-	if err := tx.DeleteStruct(&product); err != nil {
+// authorize returns ErrUnauthorized if t does not match the owner token
+// stored for the product. Products without an owner token may only be
+// changed by an admin.
+func authorize(ctx context.Context, n storm.Node, id fruit.ProductID, t string) error {
+	var owner string
+	if err := n.Get("Tokens", id, &owner); err == storm.ErrNotFound {
+		if p := fruit.PrincipalFromContext(ctx); p != nil && p.Admin {
+			return nil
+		}
+		return fruit.ErrUnauthorized
+	} else if err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	if !token.Verify(owner, t) {
+		return fruit.ErrUnauthorized
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package bolt_test
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -10,25 +11,31 @@ import (
 )
 
 func TestProductService_CreateProduct(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 	s := c.ProductService()
 
 	product := fruit.Product{
 		ID:          "ID",
+		Token:       "TOKEN",
 		SKU:         "SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
 		Color:       "COLOR",
 		Description: "DESCRIPTION",
+		Price:       &fruit.Money{Amount: 1299, Currency: "USD"},
 		ModTime:     time.Now().UTC(),
 	}
 
-	if err := s.CreateProduct(&product); err != nil {
+	if err := s.CreateProduct(ctx, &product); err != nil {
 		t.Fatal(err)
 	}
 
-	other, err := s.Product("ID")
+	// The owner token is never returned.
+	product.Token = ""
+
+	other, err := s.Product(ctx, "ID")
 	if err != nil {
 		t.Fatal(err)
 	} else if !reflect.DeepEqual(&product, other) {
@@ -37,11 +44,13 @@ func TestProductService_CreateProduct(t *testing.T) {
 }
 
 func TestProductService_CreateProduct_ErrProductIDRequired(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
 	product := fruit.Product{
 		ID:          "",
+		Token:       "TOKEN",
 		SKU:         "SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
@@ -50,25 +59,27 @@ func TestProductService_CreateProduct_ErrProductIDRequired(t *testing.T) {
 		ModTime:     time.Now().UTC(),
 	}
 
-	if err := c.ProductService().CreateProduct(&product); err != fruit.ErrProductIDRequired {
+	if err := c.ProductService().CreateProduct(ctx, &product); err != fruit.ErrProductIDRequired {
 		t.Fatalf("expected error with without id: %+v", product)
 	}
 }
 
 func TestProductService_CreateProduct_ErrProductExists(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
-	if err := c.ProductService().CreateProduct(&fruit.Product{ID: "X"}); err != nil {
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "X", Token: "TOKEN"}); err != nil {
 		t.Fatal(err)
 	}
 
-	if err := c.ProductService().CreateProduct(&fruit.Product{ID: "X"}); err != fruit.ErrProductExists {
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "X", Token: "TOKEN"}); err != fruit.ErrProductExists {
 		t.Fatal(errors.New("expected error when creating same product"))
 	}
 }
 
 func TestProductService_UpdateProduct(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 	s := c.ProductService()
@@ -76,6 +87,7 @@ func TestProductService_UpdateProduct(t *testing.T) {
 	// Create new product.
 	product := fruit.Product{
 		ID:          "XXX",
+		Token:       "TOKEN",
 		SKU:         "OLD_SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
@@ -84,26 +96,43 @@ func TestProductService_UpdateProduct(t *testing.T) {
 		ModTime:     time.Now().UTC(),
 	}
 
-	if err := s.CreateProduct(&product); err != nil {
+	if err := s.CreateProduct(ctx, &product); err != nil {
 		t.Fatal(err)
 	}
 
 	product.SKU = "NEW_SKU"
+	product.Price = &fruit.Money{Amount: 500, Currency: "EUR"}
 
 	// Update product
-	if err := s.UpdateProduct("XXX", &product); err != nil {
+	if err := s.UpdateProduct(ctx, "XXX", &product); err != nil {
 		t.Fatal(err)
 	}
 
 	// Verify product updated.
-	if p, err := s.Product(product.ID); err != nil {
+	if p, err := s.Product(ctx, product.ID); err != nil {
 		t.Fatal(err)
 	} else if p.SKU != "NEW_SKU" {
 		t.Fatalf("unexpected product sku: %s", p.SKU)
+	} else if *p.Price != fruit.NewMoney(500, "EUR") {
+		t.Fatalf("unexpected product price: %+v", p.Price)
+	}
+}
+
+func TestProductService_CreateProduct_ErrInvalidPrice(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "X", Token: "TOKEN", Price: &fruit.Money{Amount: -1, Currency: "USD"}}); err != fruit.ErrInvalidPrice {
+		t.Fatal(err)
+	} else if err := s.CreateProduct(ctx, &fruit.Product{ID: "X", Token: "TOKEN", Price: &fruit.Money{Amount: 1, Currency: "usd"}}); err != fruit.ErrInvalidCurrency {
+		t.Fatal(err)
 	}
 }
 
 func TestProductService_UpdateProduct_ErrProductNotFound(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 	s := c.ProductService()
@@ -111,30 +140,34 @@ func TestProductService_UpdateProduct_ErrProductNotFound(t *testing.T) {
 	// Create new product.
 	product := fruit.Product{
 		ID:          "XXX",
+		Token:       "TOKEN",
 		SKU:         "OLD_SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
 		Color:       "COLOR",
 		Description: "DESCRIPTION",
+		Version:     1,
 		ModTime:     time.Now().UTC(),
 	}
 
 	// Update product
-	if err := s.UpdateProduct("XXX", &product); err != fruit.ErrProductNotFound {
+	if err := s.UpdateProduct(ctx, "XXX", &product); err != fruit.ErrProductNotFound {
 		t.Fatal(err)
 	}
 }
 
 func TestProductService_Update_ErrProductDoesNotExist(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
-	if err := c.ProductService().UpdateProduct("XXX", &fruit.Product{ID: "X"}); err == nil {
+	if err := c.ProductService().UpdateProduct(ctx, "XXX", &fruit.Product{ID: "X", Token: "TOKEN"}); err == nil {
 		t.Fatal("product should not update non-existing product")
 	}
 }
 
 func TestProductService_Delete(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 	s := c.ProductService()
@@ -142,6 +175,7 @@ func TestProductService_Delete(t *testing.T) {
 	// Create new product.
 	product := fruit.Product{
 		ID:          "XXX",
+		Token:       "TOKEN",
 		SKU:         "OLD_SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
@@ -150,22 +184,23 @@ func TestProductService_Delete(t *testing.T) {
 		ModTime:     time.Now().UTC(),
 	}
 
-	if err := s.CreateProduct(&product); err != nil {
+	if err := s.CreateProduct(ctx, &product); err != nil {
 		t.Fatal(err)
 	}
 
 	// Delete product.
-	if err := s.DeleteProduct(product.ID, product.Token); err != nil {
+	if err := s.DeleteProduct(ctx, product.ID, product.Token); err != nil {
 		t.Fatal(err)
 	}
 
 	// Verify removal of product..
-	if _, err := s.Product(product.ID); err == nil {
+	if _, err := s.Product(ctx, product.ID); err == nil {
 		t.Fatal(errors.New("product was not removed"))
 	}
 }
 
 func TestProductService_Delete_ErrProductNotFound(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 	s := c.ProductService()
@@ -173,6 +208,7 @@ func TestProductService_Delete_ErrProductNotFound(t *testing.T) {
 	// Create new product.
 	product := fruit.Product{
 		ID:          "XXX",
+		Token:       "TOKEN",
 		SKU:         "OLD_SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
@@ -182,12 +218,13 @@ func TestProductService_Delete_ErrProductNotFound(t *testing.T) {
 	}
 
 	// Delete product.
-	if err := s.DeleteProduct(product.ID, product.Token); err != fruit.ErrProductNotFound {
+	if err := s.DeleteProduct(ctx, product.ID, product.Token); err != fruit.ErrProductNotFound {
 		t.Fatalf("expected error with non-existing product: %+v", product)
 	}
 }
 
 func TestProductService_Products(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 	s := c.ProductService()
@@ -195,6 +232,7 @@ func TestProductService_Products(t *testing.T) {
 	// Create new product.
 	product := fruit.Product{
 		ID:          "XXX",
+		Token:       "TOKEN",
 		SKU:         "OLD_SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
@@ -203,13 +241,14 @@ func TestProductService_Products(t *testing.T) {
 		ModTime:     time.Now().UTC(),
 	}
 
-	if err := s.CreateProduct(&product); err != nil {
+	if err := s.CreateProduct(ctx, &product); err != nil {
 		t.Fatal(err)
 	}
 
 	// Create second product.
 	product = fruit.Product{
 		ID:          "YYY",
+		Token:       "TOKEN",
 		SKU:         "OLD_SKU",
 		Name:        "NAME",
 		Type:        "TYPE",
@@ -218,12 +257,12 @@ func TestProductService_Products(t *testing.T) {
 		ModTime:     time.Now().UTC(),
 	}
 
-	if err := s.CreateProduct(&product); err != nil {
+	if err := s.CreateProduct(ctx, &product); err != nil {
 		t.Fatal(err)
 	}
 
 	// Fetch products.
-	products, err := s.Products()
+	products, _, err := s.Products(ctx, fruit.QueryOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -234,11 +273,223 @@ func TestProductService_Products(t *testing.T) {
 	}
 }
 
+func TestProductService_Products_Options(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	for _, p := range []*fruit.Product{
+		{ID: "A", Token: "TOKEN", SKU: "3", Type: "Apple", Color: "Red"},
+		{ID: "B", Token: "TOKEN", SKU: "1", Type: "Apple", Color: "Green"},
+		{ID: "C", Token: "TOKEN", SKU: "2", Type: "Apple", Color: "Red"},
+		{ID: "D", Token: "TOKEN", SKU: "4", Type: "Pear", Color: "Red"},
+	} {
+		if err := s.CreateProduct(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(products []*fruit.Product) (a []fruit.ProductID) {
+		for _, p := range products {
+			a = append(a, p.ID)
+		}
+		return a
+	}
+
+	// Filter, sort and page.
+	products, next, err := s.Products(ctx, fruit.QueryOptions{Limit: 1, Sort: "sku", Desc: true, Type: "Apple", Color: "Red"})
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(ids(products), []fruit.ProductID{"A"}) {
+		t.Fatalf("unexpected products: %v", ids(products))
+	} else if next == "" {
+		t.Fatal("expected next cursor")
+	}
+
+	products, next, err = s.Products(ctx, fruit.QueryOptions{Limit: 1, Cursor: next, Sort: "sku", Desc: true, Type: "Apple", Color: "Red"})
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(ids(products), []fruit.ProductID{"C"}) {
+		t.Fatalf("unexpected products: %v", ids(products))
+	} else if next != "" {
+		t.Fatalf("unexpected next cursor: %s", next)
+	} else if products[0].Token != "" {
+		t.Fatalf("unexpected token: %s", products[0].Token)
+	}
+
+	// Filter by SKU.
+	if products, _, err := s.Products(ctx, fruit.QueryOptions{SKU: "4"}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(ids(products), []fruit.ProductID{"D"}) {
+		t.Fatalf("unexpected products: %v", ids(products))
+	}
+}
+
+// Ensure pages don't shift when products before the cursor are removed.
+func TestProductService_Products_CursorStable(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	for _, p := range []*fruit.Product{
+		{ID: "A", Token: "TOKEN", SKU: "3"},
+		{ID: "B", Token: "TOKEN", SKU: "1"},
+		{ID: "C", Token: "TOKEN", SKU: "2"},
+		{ID: "D", Token: "TOKEN", SKU: "2"},
+	} {
+		if err := s.CreateProduct(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first page ends part way through the products sharing a SKU.
+	opt := fruit.QueryOptions{Limit: 2, Sort: "sku"}
+	products, next, err := s.Products(ctx, opt)
+	if err != nil {
+		t.Fatal(err)
+	} else if products[0].ID != "B" || products[1].ID != "C" {
+		t.Fatalf("unexpected products: %s, %s", products[0].ID, products[1].ID)
+	}
+
+	if err := s.DeleteProduct(ctx, "B", "TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	opt.Cursor = next
+	if products, next, err := s.Products(ctx, opt); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 2 || products[0].ID != "D" || products[1].ID != "A" {
+		t.Fatalf("unexpected products: %+v", products)
+	} else if next != "" {
+		t.Fatalf("unexpected next cursor: %s", next)
+	}
+}
+
+// Ensure pages follow changes to the sorted and filtered fields.
+func TestProductService_Products_Reordered(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	for _, p := range []*fruit.Product{
+		{ID: "A", Token: "TOKEN", Name: "Apple", Type: "Pome"},
+		{ID: "B", Token: "TOKEN", Name: "Banana", Type: "Berry"},
+		{ID: "C", Token: "TOKEN", Name: "Cherry", Type: "Drupe"},
+	} {
+		if err := s.CreateProduct(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.UpdateProduct(ctx, "A", &fruit.Product{Name: "Quince", Type: "Pome", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateProduct(ctx, "C", &fruit.Product{Name: "Cherry", Type: "Pome", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		opt  fruit.QueryOptions
+		want []fruit.ProductID
+	}{
+		{fruit.QueryOptions{Sort: "name"}, []fruit.ProductID{"B", "C", "A"}},
+		{fruit.QueryOptions{Sort: "name", Desc: true, Limit: 2}, []fruit.ProductID{"A", "C"}},
+		{fruit.QueryOptions{Sort: "name", Type: "Pome"}, []fruit.ProductID{"C", "A"}},
+		{fruit.QueryOptions{Type: "Drupe"}, nil},
+	} {
+		products, _, err := s.Products(ctx, tt.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []fruit.ProductID
+		for _, p := range products {
+			ids = append(ids, p.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%+v: unexpected products: %v", tt.opt, ids)
+		}
+	}
+}
+
+func TestProductService_Products_ErrInvalidOptions(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	if _, _, err := s.Products(ctx, fruit.QueryOptions{Limit: -1}); err != fruit.ErrInvalidLimit {
+		t.Fatal(err)
+	} else if _, _, err := s.Products(ctx, fruit.QueryOptions{Cursor: "XXX"}); err != fruit.ErrInvalidCursor {
+		t.Fatal(err)
+	} else if _, _, err := s.Products(ctx, fruit.QueryOptions{Sort: "token"}); err != fruit.ErrInvalidSort {
+		t.Fatal(err)
+	}
+}
+
 func TestProductService_Products_Empty(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
-	if p, _ := c.ProductService().Products(); p == nil {
+	if p, _, _ := c.ProductService().Products(ctx, fruit.QueryOptions{}); p == nil {
 		t.Fatal("expected empty product array")
 	}
 }
+
+func TestProductService_CreateProduct_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "X"}); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	}
+}
+
+func TestProductService_UpdateProduct_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "X", Token: "TOKEN", SKU: "OLD_SKU"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update with another owner's token.
+	if err := s.UpdateProduct(ctx, "X", &fruit.Product{Token: "OTHER", SKU: "NEW_SKU", Version: 1}); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	}
+
+	// Verify product unchanged.
+	if p, err := s.Product(ctx, "X"); err != nil {
+		t.Fatal(err)
+	} else if p.SKU != "OLD_SKU" || p.Token != "" {
+		t.Fatalf("unexpected product: %+v", p)
+	}
+}
+
+func TestProductService_Delete_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "X", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete without a token and with the wrong token.
+	for _, token := range []string{"", "OTHER"} {
+		if err := s.DeleteProduct(ctx, "X", token); err != fruit.ErrUnauthorized {
+			t.Fatalf("unexpected error for token %q: %v", token, err)
+		}
+	}
+
+	// Verify product still exists.
+	if _, err := s.Product(ctx, "X"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,298 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/notjrbauer/fruit"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Sortable fields by their JSON name.
+var (
+	productSortFields = map[string]string{
+		"productID": "ID",
+		"name":      "Name",
+		"sku":       "SKU",
+		"type":      "Type",
+		"color":     "Color",
+		"modTime":   "ModTime",
+	}
+
+	userSortFields = map[string]string{
+		"userID":  "ID",
+		"name":    "Name",
+		"modTime": "ModTime",
+	}
+)
+
+// Records are paged through order keys kept in the Order bucket of their
+// node. Each record has a key per sortable field, plus one per field for
+// each indexed filter it has a value for. A key holds the filter, the sort
+// field, the sort value and the record's ID, encoded so keys sort the way
+// pages do, and maps to the ID. Pages are read by walking the keys from the
+// cursor, so only the records of one page are loaded.
+
+// productFilters are the indexed product filters with order keys of their
+// own.
+var productFilters = []string{"SKU", "Type", "Color"}
+
+// find stores a page of the records in n matching matchers in to, which
+// must be a pointer to a slice of struct pointers, and returns the cursor
+// for the next page. If index is set only records whose indexed field has
+// value are walked. n must use tx.
+func find(tx *bolt.Tx, n storm.Node, opt fruit.QueryOptions, sortFields map[string]string, to interface{}, index, value string, matchers ...q.Matcher) (string, error) {
+	field, err := sortField(opt, sortFields)
+	if err != nil {
+		return "", err
+	}
+
+	v := reflect.ValueOf(to).Elem()
+	typ := v.Type().Elem().Elem()
+	sf, ok := typ.FieldByName(field)
+	if !ok {
+		return "", fruit.ErrInvalidSort
+	}
+	idf, _ := typ.FieldByName("ID")
+
+	// Cursors hold the sort value and ID of the last record returned, so
+	// pages don't shift when records before the cursor are added or removed.
+	prefix := orderPrefix(index, value, field)
+	var after []byte
+	if opt.Cursor != "" {
+		last, lastID := reflect.New(sf.Type), reflect.New(idf.Type)
+		if err := decodeCursor(opt.Cursor, last.Interface(), lastID.Interface()); err != nil {
+			return "", err
+		}
+		after = orderKey(prefix, last.Elem(), lastID.Elem())
+	}
+
+	b := n.GetBucket(tx, "Order")
+	if b == nil {
+		return "", nil
+	}
+
+	// Read one matching record past the page to tell if there is another.
+	c, filter := b.Cursor(), q.And(matchers...)
+	for k, id := seekOrder(c, prefix, after, opt.Desc); k != nil && bytes.HasPrefix(k, prefix); k, id = nextOrder(c, opt.Desc) {
+		r := reflect.New(typ)
+		if err := n.One("ID", reflect.ValueOf(string(id)).Convert(idf.Type).Interface(), r.Interface()); err != nil {
+			return "", err
+		} else if ok, err := filter.Match(r.Interface()); err != nil {
+			return "", err
+		} else if !ok {
+			continue
+		}
+
+		v.Set(reflect.Append(v, r))
+		if opt.Limit > 0 && v.Len() > opt.Limit {
+			break
+		}
+	}
+
+	if opt.Limit == 0 || v.Len() <= opt.Limit {
+		return "", nil
+	}
+	v.Set(v.Slice(0, opt.Limit))
+
+	last := v.Index(opt.Limit - 1).Elem()
+	return encodeCursor(last.FieldByName(field).Interface(), last.FieldByName("ID").Interface())
+}
+
+// seekOrder moves c to the first key with prefix in the requested order, or
+// to the first key past after if it is set.
+func seekOrder(c *bolt.Cursor, prefix, after []byte, desc bool) ([]byte, []byte) {
+	if !desc {
+		if after == nil {
+			return c.Seek(prefix)
+		}
+		k, id := c.Seek(after)
+		if bytes.Equal(k, after) {
+			return c.Next()
+		}
+		return k, id
+	}
+
+	// Keys with prefix sort before the prefix with its trailing separator
+	// incremented.
+	if after == nil {
+		after = append(append([]byte{}, prefix[:len(prefix)-1]...), 1)
+	}
+	if k, _ := c.Seek(after); k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+// nextOrder moves c to the next key in the requested order.
+func nextOrder(c *bolt.Cursor, desc bool) ([]byte, []byte) {
+	if desc {
+		return c.Prev()
+	}
+	return c.Next()
+}
+
+// indexOrder replaces the order keys of record, a struct pointer, in n.
+func indexOrder(n storm.Node, record interface{}, sortFields map[string]string, filters ...string) error {
+	r := reflect.ValueOf(record).Elem()
+	id := r.FieldByName("ID")
+	if err := unindexOrder(n, id.String()); err != nil {
+		return err
+	}
+
+	var keys [][]byte
+	for _, field := range sortFields {
+		keys = append(keys, orderKey(orderPrefix("", "", field), r.FieldByName(field), id))
+		for _, filter := range filters {
+			if value := r.FieldByName(filter).String(); value != "" {
+				keys = append(keys, orderKey(orderPrefix(filter, value, field), r.FieldByName(field), id))
+			}
+		}
+	}
+
+	for _, k := range keys {
+		if err := n.SetBytes("Order", k, []byte(id.String())); err != nil {
+			return err
+		}
+	}
+	return n.Set("OrderKeys", id.String(), keys)
+}
+
+// unindexOrder removes the order keys of the record with ID id from n.
+func unindexOrder(n storm.Node, id string) error {
+	var keys [][]byte
+	if err := n.Get("OrderKeys", id, &keys); err == storm.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := n.Delete("Order", k); err != nil {
+			return err
+		}
+	}
+	return n.Delete("OrderKeys", id)
+}
+
+// orderPrefix returns the prefix of the order keys for sorting by field,
+// narrowed to records whose indexed filter has value if filter is set.
+func orderPrefix(filter, value, field string) []byte {
+	return []byte(filter + "\x00" + value + "\x00" + field + "\x00")
+}
+
+// orderKey returns the order key with prefix of the record with ID id whose
+// sort field holds value.
+func orderKey(prefix []byte, value, id reflect.Value) []byte {
+	key := append([]byte{}, prefix...)
+	if t, ok := value.Interface().(time.Time); ok {
+		// Times sort by their instant, as a biased big endian number.
+		var b [12]byte
+		binary.BigEndian.PutUint64(b[:8], uint64(t.Unix())^1<<63)
+		binary.BigEndian.PutUint32(b[8:], uint32(t.Nanosecond()))
+		key = append(key, b[:]...)
+	} else {
+		key = append(key, value.String()...)
+	}
+	key = append(key, 0)
+	return append(key, id.String()...)
+}
+
+// sortField validates the paging options in opt and returns the struct
+// field to sort by. Records are sorted by ID when no sort is given.
+func sortField(opt fruit.QueryOptions, sortFields map[string]string) (string, error) {
+	if opt.Limit < 0 {
+		return "", fruit.ErrInvalidLimit
+	} else if opt.Sort == "" {
+		return "ID", nil
+	}
+
+	field, ok := sortFields[opt.Sort]
+	if !ok {
+		return "", fruit.ErrInvalidSort
+	}
+	return field, nil
+}
+
+// encodeCursor returns a cursor holding the sort value and ID of the last
+// record of a page.
+func encodeCursor(value, id interface{}) (string, error) {
+	buf, err := json.Marshal([]interface{}{value, id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// decodeCursor reads the sort value and ID held by cursor into value and
+// id, which must be pointers.
+func decodeCursor(cursor string, value, id interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fruit.ErrInvalidCursor
+	}
+
+	var a []json.RawMessage
+	if err := json.Unmarshal(buf, &a); err != nil || len(a) != 2 {
+		return fruit.ErrInvalidCursor
+	} else if err := json.Unmarshal(a[0], value); err != nil {
+		return fruit.ErrInvalidCursor
+	} else if err := json.Unmarshal(a[1], id); err != nil {
+		return fruit.ErrInvalidCursor
+	}
+	return nil
+}
+
+// productIndex returns the most selective indexed field filtered on by opt
+// and the value it must have. Returns a blank field if opt filters on none.
+func productIndex(opt fruit.QueryOptions) (string, string) {
+	switch {
+	case opt.SKU != "":
+		return "SKU", opt.SKU
+	case opt.Type != "":
+		return "Type", opt.Type
+	case opt.Color != "":
+		return "Color", opt.Color
+	}
+	return "", ""
+}
+
+// productMatchers returns matchers for the product filters in opt.
+func productMatchers(opt fruit.QueryOptions) []q.Matcher {
+	var matchers []q.Matcher
+	if opt.Type != "" {
+		matchers = append(matchers, q.Eq("Type", opt.Type))
+	}
+	if opt.Color != "" {
+		matchers = append(matchers, q.Eq("Color", opt.Color))
+	}
+	if opt.SKU != "" {
+		matchers = append(matchers, q.Eq("SKU", opt.SKU))
+	}
+	if !opt.IncludeDeleted {
+		matchers = append(matchers, q.NewFieldMatcher("DeletedAt", notDeleted{}))
+	}
+	return matchers
+}
+
+// notDeleted matches products which haven't been deleted.
+type notDeleted struct{}
+
+func (notDeleted) MatchField(v interface{}) (bool, error) {
+	t, _ := v.(*time.Time)
+	return t == nil, nil
+}
+
+// deletedBefore matches products deleted before a time.
+type deletedBefore time.Time
+
+func (b deletedBefore) MatchField(v interface{}) (bool, error) {
+	t, _ := v.(*time.Time)
+	return t != nil && t.Before(time.Time(b)), nil
+}
@@ -0,0 +1,200 @@
+package bolt
+
+import (
+	"context"
+	"sort"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+type RevisionService struct {
+	client *Client
+}
+
+// ProductRevisions returns the revisions of a product, oldest first.
+func (s *RevisionService) ProductRevisions(ctx context.Context, id fruit.ProductID) ([]*fruit.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	revisions, err := findRevisions(s.client.db, "ProductID", id)
+	if err != nil {
+		return nil, err
+	} else if len(revisions) == 0 {
+		return nil, fruit.ErrProductNotFound
+	}
+	return revisions, nil
+}
+
+// UserRevisions returns the revisions of a user, oldest first.
+func (s *RevisionService) UserRevisions(ctx context.Context, id fruit.UserID) ([]*fruit.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	revisions, err := findRevisions(s.client.db, "UserID", id)
+	if err != nil {
+		return nil, err
+	} else if len(revisions) == 0 {
+		return nil, fruit.ErrUserNotFound
+	}
+	return revisions, nil
+}
+
+// RevertProduct sets the fields of a product back to those saved in
+// revision rev.
+func (s *RevisionService) RevertProduct(ctx context.Context, id fruit.ProductID, rev fruit.RevisionID, version int) (*fruit.Product, error) {
+	// Require the version the revert is based on.
+	if version == 0 {
+		return nil, fruit.ErrVersionRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := begin(ctx, s.client.db, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Find record.
+	products := tx.From("Products")
+	var product fruit.Product
+	if err := findProduct(products, id, &product); err != nil {
+		return nil, err
+	}
+
+	// Reject changes based on an old version.
+	if version != product.Version {
+		return nil, fruit.ErrConflict
+	}
+
+	// The revision must belong to the product.
+	var r fruit.Revision
+	if err := tx.From("Revisions").One("ID", rev, &r); err == storm.ErrNotFound {
+		return nil, fruit.ErrRevisionNotFound
+	} else if err != nil {
+		return nil, err
+	} else if r.ProductID != id || r.Product == nil {
+		return nil, fruit.ErrRevisionNotFound
+	}
+
+	// Its category may have been deleted since.
+	if err := verifyCategory(tx, r.Product.CategoryID); err != nil {
+		return nil, err
+	}
+
+	// Apply the saved fields.
+	product.Name = r.Product.Name
+	product.SKU = r.Product.SKU
+	product.Type = r.Product.Type
+	product.Color = r.Product.Color
+	product.Description = r.Product.Description
+	product.Price = r.Product.Price
+	product.CategoryID = r.Product.CategoryID
+	product.Version++
+	product.ModTime = s.client.Now().UTC()
+
+	// A revert restores fields which were empty in that revision too.
+	if err := products.Save(&product); err != nil {
+		return nil, err
+	} else if err := indexProduct(products.From("Search"), &product); err != nil {
+		return nil, err
+	} else if err := indexOrder(products, &product, productSortFields, productFilters...); err != nil {
+		return nil, err
+	} else if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventProductUpdated, ProductID: id, Product: &product}); err != nil {
+		return nil, err
+	} else if err := s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventProductUpdated, ProductID: id, Product: &product}); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	s.client.broker.Notify()
+	return &product, nil
+}
+
+// appendRevision records r in the revision history within a root
+// transaction. The caller in ctx is recorded as the actor, and the changes
+// are found by comparing r with the latest revision of the same record.
+func (c *Client) appendRevision(ctx context.Context, tx storm.Node, r *fruit.Revision) error {
+	if p := fruit.PrincipalFromContext(ctx); p != nil {
+		r.Actor = p.UserID
+	}
+	r.Time = c.Now().UTC()
+
+	// Created records are compared with nothing, as the latest revision
+	// may belong to a record purged or deleted under the same ID.
+	var prev *fruit.Revision
+	created := r.Type == fruit.EventProductCreated || r.Type == fruit.EventUserCreated
+	if !created {
+		var err error
+		if prev, err = latestRevision(tx, r); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if r.ProductID != "" {
+		var old *fruit.Product
+		if prev != nil {
+			old = prev.Product
+		}
+		r.Changes, err = fruit.DiffProducts(old, r.Product)
+	} else {
+		var old *fruit.User
+		if prev != nil {
+			old = prev.User
+		}
+		r.Changes, err = fruit.DiffUsers(old, r.User)
+	}
+	if err != nil {
+		return err
+	}
+
+	revisions := tx.From("Revisions")
+	if err := revisions.Save(r); err != nil {
+		return err
+	}
+	return revisions.Set("Latest", latestKey(r), r.ID)
+}
+
+// latestRevision returns the latest revision of the record r belongs to,
+// or nil if it has none. The Latest bucket points at it so the history
+// isn't read on every write.
+func latestRevision(n storm.Node, r *fruit.Revision) (*fruit.Revision, error) {
+	revisions := n.From("Revisions")
+
+	var id fruit.RevisionID
+	if err := revisions.Get("Latest", latestKey(r), &id); err == storm.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var latest fruit.Revision
+	if err := revisions.One("ID", id, &latest); err != nil {
+		return nil, err
+	}
+	return &latest, nil
+}
+
+// latestKey returns the key of the record r belongs to in the Latest bucket.
+func latestKey(r *fruit.Revision) string {
+	if r.ProductID != "" {
+		return "product/" + string(r.ProductID)
+	}
+	return "user/" + string(r.UserID)
+}
+
+// findRevisions returns the revisions whose indexed field matches value,
+// oldest first.
+func findRevisions(n storm.Node, field string, value interface{}) ([]*fruit.Revision, error) {
+	revisions := []*fruit.Revision{}
+	if err := n.From("Revisions").Find(field, value, &revisions); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].ID < revisions[j].ID })
+	return revisions, nil
+}
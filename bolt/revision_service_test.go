@@ -0,0 +1,139 @@
+package bolt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// Ensure every change to a product is recorded with its actor and changes.
+func TestRevisionService_ProductRevisions(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	ctx := fruit.NewPrincipalContext(context.Background(), &fruit.Principal{UserID: "ALICE"})
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "A", Name: "Apple", SKU: "APL", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateProduct(ctx, "A", &fruit.Product{Name: "Apple", SKU: "APL-2", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := s.DeleteProduct(context.Background(), "A", "TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := c.RevisionService().ProductRevisions(context.Background(), "A")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 3 {
+		t.Fatalf("unexpected revisions: %d", len(a))
+	}
+
+	if r := a[0]; r.Type != fruit.EventProductCreated || r.Actor != "ALICE" || !r.Time.Equal(Now) {
+		t.Fatalf("unexpected revision: %+v", r)
+	} else if r.Product.SKU != "APL" || r.Product.Token != "" {
+		t.Fatalf("unexpected product: %+v", r.Product)
+	}
+
+	if r := a[1]; r.Type != fruit.EventProductUpdated || r.Actor != "ALICE" {
+		t.Fatalf("unexpected revision: %+v", r)
+	} else if len(r.Changes) != 1 || r.Changes[0].Field != "sku" || string(r.Changes[0].Old) != `"APL"` || string(r.Changes[0].New) != `"APL-2"` {
+		t.Fatalf("unexpected changes: %+v", r.Changes)
+	}
+
+	// Changes made without a principal have no actor.
+	if r := a[2]; r.Type != fruit.EventProductDeleted || r.Actor != "" {
+		t.Fatalf("unexpected revision: %+v", r)
+	} else if len(r.Changes) != 1 || r.Changes[0].Field != "deletedAt" {
+		t.Fatalf("unexpected changes: %+v", r.Changes)
+	}
+
+	if _, err := c.RevisionService().ProductRevisions(context.Background(), "B"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure changes to users are recorded, including deletes.
+func TestRevisionService_UserRevisions(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.UserService()
+
+	if err := s.CreateUser(ctx, &fruit.User{ID: "U", Name: "Ursula"}); err != nil {
+		t.Fatal(err)
+	} else if err := s.PatchUser(ctx, "U", &fruit.User{Name: "Uma", Version: 1}, fruit.FieldMask{"name"}); err != nil {
+		t.Fatal(err)
+	} else if err := s.DeleteUser(ctx, "U"); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := c.RevisionService().UserRevisions(ctx, "U")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 3 {
+		t.Fatalf("unexpected revisions: %d", len(a))
+	} else if r := a[1]; r.Type != fruit.EventUserUpdated || len(r.Changes) != 1 || string(r.Changes[0].New) != `"Uma"` {
+		t.Fatalf("unexpected revision: %+v", r)
+	} else if r := a[2]; r.Type != fruit.EventUserDeleted || r.User != nil || len(r.Changes) != 2 {
+		t.Fatalf("unexpected revision: %+v", r)
+	}
+
+	if _, err := c.RevisionService().UserRevisions(ctx, "X"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a product can be reverted to an earlier revision.
+func TestRevisionService_RevertProduct(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "A", Name: "Apple", Description: "Crisp", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateProduct(ctx, "A", &fruit.Product{Name: "Banana", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := c.RevisionService().ProductRevisions(ctx, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing and stale versions and other products' revisions are rejected.
+	if _, err := c.RevisionService().RevertProduct(ctx, "A", a[0].ID, 0); err != fruit.ErrVersionRequired {
+		t.Fatal(err)
+	} else if _, err := c.RevisionService().RevertProduct(ctx, "A", a[0].ID, 1); err != fruit.ErrConflict {
+		t.Fatal(err)
+	} else if _, err := c.RevisionService().RevertProduct(ctx, "A", 1000, 2); err != fruit.ErrRevisionNotFound {
+		t.Fatal(err)
+	} else if _, err := c.RevisionService().RevertProduct(ctx, "B", a[0].ID, 2); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	p, err := c.RevisionService().RevertProduct(ctx, "A", a[0].ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apple" || p.Description != "Crisp" || p.Version != 3 || p.Token != "" {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if other, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if other.Name != "Apple" || other.Version != 3 {
+		t.Fatalf("unexpected product: %+v", other)
+	} else if products, _, err := s.Search(ctx, "apple", fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 1 {
+		t.Fatalf("unexpected products: %+v", products)
+	}
+
+	// The revert is itself a revision.
+	if a, err := c.RevisionService().ProductRevisions(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 3 || a[2].Type != fruit.EventProductUpdated {
+		t.Fatalf("unexpected revisions: %+v", a)
+	} else if len(a[2].Changes) != 1 || string(a[2].Changes[0].New) != `"Apple"` {
+		t.Fatalf("unexpected changes: %+v", a[2].Changes)
+	}
+}
@@ -0,0 +1,100 @@
+package bolt
+
+import (
+	"sort"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/search"
+)
+
+// searchTerm is an entry in the product search index. Terms are stored in a
+// bucket nested under Products so they're updated in the same transaction
+// as the product they point to.
+type searchTerm struct {
+	ID        string          `storm:"id"`
+	Term      string          `storm:"index"`
+	ProductID fruit.ProductID `storm:"index"`
+	Weight    int
+}
+
+// indexProduct replaces the index entries for p.
+func indexProduct(n storm.Node, p *fruit.Product) error {
+	if err := unindexProduct(n, p.ID); err != nil {
+		return err
+	}
+
+	for term, weight := range search.Terms(p) {
+		if err := n.Save(&searchTerm{
+			ID:        term + "\x00" + string(p.ID),
+			Term:      term,
+			ProductID: p.ID,
+			Weight:    weight,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unindexProduct removes all index entries for a product.
+func unindexProduct(n storm.Node, id fruit.ProductID) error {
+	var terms []*searchTerm
+	if err := n.Find("ProductID", id, &terms); err == storm.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, t := range terms {
+		if err := n.DeleteStruct(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchIndex returns the IDs of products matching every word in query,
+// best match first, along with their scores.
+func searchIndex(n storm.Node, query string) ([]fruit.ProductID, map[fruit.ProductID]int, error) {
+	var scores map[fruit.ProductID]int
+	for _, word := range search.Tokenize(query) {
+		var terms []*searchTerm
+		if err := n.Prefix("Term", word, &terms); err != nil && err != storm.ErrNotFound {
+			return nil, nil, err
+		}
+
+		// Score each product by its best matching term.
+		matches := make(map[fruit.ProductID]int)
+		for _, t := range terms {
+			if score := search.Score(word, t.Term, t.Weight); score > matches[t.ProductID] {
+				matches[t.ProductID] = score
+			}
+		}
+
+		// Keep only products matched by every word so far.
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for id := range scores {
+			if score, ok := matches[id]; ok {
+				scores[id] += score
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	ids := make([]fruit.ProductID, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids, scores, nil
+}
@@ -0,0 +1,126 @@
+package bolt_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// MustCreateSearchFixtures creates products to search and panics on error.
+func MustCreateSearchFixtures(c *Client) {
+	ctx := context.Background()
+	for _, p := range []*fruit.Product{
+		{ID: "1", Token: "TOKEN", Name: "Granny Smith Apple", SKU: "APL-GS", Color: "Green", Type: "Apple", Description: "Tart and crisp."},
+		{ID: "2", Token: "TOKEN", Name: "Red Delicious", SKU: "APL-RD", Color: "Red", Type: "Apple", Description: "A sweet apple."},
+		{ID: "3", Token: "TOKEN", Name: "Bartlett Pear", SKU: "PR-BT", Color: "Green", Type: "Pear", Description: "Juicy and sweet."},
+	} {
+		if err := c.ProductService().CreateProduct(ctx, p); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func searchIDs(t *testing.T, s fruit.ProductService, query string, opt fruit.QueryOptions) []fruit.ProductID {
+	ctx := context.Background()
+	products, _, err := s.Search(ctx, query, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []fruit.ProductID{}
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestProductService_Search(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	MustCreateSearchFixtures(c)
+
+	for _, tt := range []struct {
+		query string
+		ids   []fruit.ProductID
+	}{
+		// Name matches outrank description matches.
+		{"apple", []fruit.ProductID{"1", "2"}},
+		// Matching is case insensitive and by prefix.
+		{"SWE", []fruit.ProductID{"2", "3"}},
+		{"gre", []fruit.ProductID{"1", "3"}},
+		// Every word must match.
+		{"green pear", []fruit.ProductID{"3"}},
+		{"apl rd", []fruit.ProductID{"2"}},
+		{"banana", []fruit.ProductID{}},
+	} {
+		if ids := searchIDs(t, s, tt.query, fruit.QueryOptions{}); !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("%q: unexpected results: %v", tt.query, ids)
+		}
+	}
+}
+
+func TestProductService_Search_Options(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	MustCreateSearchFixtures(c)
+
+	// Filters apply to results.
+	if ids := searchIDs(t, s, "sweet", fruit.QueryOptions{Type: "Pear"}); !reflect.DeepEqual(ids, []fruit.ProductID{"3"}) {
+		t.Fatalf("unexpected results: %v", ids)
+	}
+
+	// Results are paged.
+	products, next, err := s.Search(ctx, "green", fruit.QueryOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(products) != 1 || products[0].ID != "1" || products[0].Token != "" {
+		t.Fatalf("unexpected results: %+v", products)
+	} else if next == "" {
+		t.Fatal("expected next cursor")
+	}
+
+	if ids := searchIDs(t, s, "green", fruit.QueryOptions{Limit: 1, Cursor: next}); !reflect.DeepEqual(ids, []fruit.ProductID{"3"}) {
+		t.Fatalf("unexpected results: %v", ids)
+	}
+}
+
+// Ensure the index follows product updates and deletes.
+func TestProductService_Search_Sync(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.ProductService()
+
+	MustCreateSearchFixtures(c)
+
+	if err := s.UpdateProduct(ctx, "2", &fruit.Product{Name: "Fuji", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if ids := searchIDs(t, s, "delicious", fruit.QueryOptions{}); len(ids) != 0 {
+		t.Fatalf("unexpected results: %v", ids)
+	} else if ids := searchIDs(t, s, "fuji", fruit.QueryOptions{}); !reflect.DeepEqual(ids, []fruit.ProductID{"2"}) {
+		t.Fatalf("unexpected results: %v", ids)
+	}
+
+	if err := s.DeleteProduct(ctx, "3", "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if ids := searchIDs(t, s, "pear", fruit.QueryOptions{}); len(ids) != 0 {
+		t.Fatalf("unexpected results: %v", ids)
+	}
+}
+
+func TestProductService_Search_ErrSearchQueryRequired(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if _, _, err := c.ProductService().Search(ctx, " - ", fruit.QueryOptions{}); err != fruit.ErrSearchQueryRequired {
+		t.Fatal(err)
+	}
+}
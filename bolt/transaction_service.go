@@ -0,0 +1,148 @@
+package bolt
+
+import (
+	"context"
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+type TransactionService struct {
+	client *Client
+}
+
+// Transaction returns a transaction by ID.
+func (s *TransactionService) Transaction(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Find and unmarshal transaction.
+	var t fruit.Transaction
+	if err := s.client.db.From("Transactions").One("ID", id, &t); err == storm.ErrNotFound {
+		return nil, fruit.ErrTransactionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// Transactions returns all transactions belonging to a user.
+func (s *TransactionService) Transactions(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Look up transactions by the user index.
+	transactions := []*fruit.Transaction{}
+	if err := s.client.db.From("Transactions").Find("UserID", id, &transactions); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// CreateTransaction creates a new transaction.
+func (s *TransactionService) CreateTransaction(ctx context.Context, t *fruit.Transaction) error {
+	// Validate arguments.
+	if t == nil {
+		return fruit.ErrTransactionRequired
+	} else if t.ID == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := begin(ctx, s.client.db.From("Transactions"), true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify transaction doesn't already exist.
+	var other fruit.Transaction
+	if err := tx.One("ID", t.ID, &other); err == nil {
+		return fruit.ErrTransactionExists
+	} else if err != storm.ErrNotFound {
+		return err
+	}
+
+	// Update modified time.
+	t.ModTime = s.client.Now().UTC()
+
+	if err := tx.Save(t); err != nil {
+		return err
+	}
+
+	return commit(ctx, tx)
+}
+
+// UpdateTransaction updates an existing transaction.
+func (s *TransactionService) UpdateTransaction(ctx context.Context, id fruit.TransactionID, t *fruit.Transaction) error {
+	// Validate arguments.
+	if t == nil {
+		return fruit.ErrTransactionRequired
+	} else if id == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	// Start read-write transaction.
+	tx, err := begin(ctx, s.client.db.From("Transactions"), true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record.
+	var other fruit.Transaction
+	if err := tx.One("ID", id, &other); err == storm.ErrNotFound {
+		return fruit.ErrTransactionNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// Apply changes.
+	other.UserID = t.UserID
+	other.Count = t.Count
+	other.Active = t.Active
+	other.ModTime = s.client.Now().UTC()
+
+	// Update would skip Active when it is being switched off.
+	if err := tx.Save(&other); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+
+	*t = other
+	return nil
+}
+
+// DeleteTransaction removes an existing transaction.
+func (s *TransactionService) DeleteTransaction(ctx context.Context, id fruit.TransactionID) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := begin(ctx, s.client.db.From("Transactions"), true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record.
+	var t fruit.Transaction
+	if err := tx.One("ID", id, &t); err == storm.ErrNotFound {
+		return fruit.ErrTransactionNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if err := tx.DeleteStruct(&t); err != nil {
+		return err
+	}
+
+	return commit(ctx, tx)
+}
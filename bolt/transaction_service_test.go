@@ -0,0 +1,197 @@
+package bolt_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+func TestTransactionService_CreateTransaction(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.TransactionService()
+
+	transaction := fruit.Transaction{
+		ID:     "ID",
+		UserID: "USERID",
+		Count:  2,
+		Active: true,
+	}
+
+	if err := s.CreateTransaction(ctx, &transaction); err != nil {
+		t.Fatal(err)
+	} else if !transaction.ModTime.Equal(Now) {
+		t.Fatalf("unexpected mod time: %s", transaction.ModTime)
+	}
+
+	other, err := s.Transaction(ctx, "ID")
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(&transaction, other) {
+		t.Fatalf("unexpected transaction: %+v", other)
+	}
+}
+
+func TestTransactionService_CreateTransaction_ErrTransactionRequired(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.TransactionService().CreateTransaction(ctx, nil); err != fruit.ErrTransactionRequired {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionService_CreateTransaction_ErrTransactionIDRequired(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.TransactionService().CreateTransaction(ctx, &fruit.Transaction{UserID: "USERID"}); err != fruit.ErrTransactionIDRequired {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionService_CreateTransaction_ErrTransactionExists(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.TransactionService()
+
+	if err := s.CreateTransaction(ctx, &fruit.Transaction{ID: "X"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateTransaction(ctx, &fruit.Transaction{ID: "X"}); err != fruit.ErrTransactionExists {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionService_Transaction_ErrTransactionNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if tr, err := c.TransactionService().Transaction(ctx, "NO SUCH TRANSACTION"); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	} else if tr != nil {
+		t.Fatalf("unexpected transaction: %+v", tr)
+	}
+}
+
+func TestTransactionService_Transactions(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.TransactionService()
+
+	// Create transactions across two users.
+	for _, tr := range []*fruit.Transaction{
+		{ID: "A", UserID: "USER1"},
+		{ID: "B", UserID: "USER2"},
+		{ID: "C", UserID: "USER1"},
+	} {
+		if err := s.CreateTransaction(ctx, tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Fetch transactions for the first user only.
+	transactions, err := s.Transactions(ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(transactions) != 2 {
+		t.Fatalf("unexpected transaction count: %d", len(transactions))
+	}
+
+	for _, tr := range transactions {
+		if tr.UserID != "USER1" {
+			t.Fatalf("unexpected transaction: %+v", tr)
+		}
+	}
+}
+
+func TestTransactionService_Transactions_Empty(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if transactions, err := c.TransactionService().Transactions(ctx, "USER1"); err != nil {
+		t.Fatal(err)
+	} else if transactions == nil || len(transactions) != 0 {
+		t.Fatalf("expected empty transaction array: %+v", transactions)
+	}
+}
+
+func TestTransactionService_UpdateTransaction(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.TransactionService()
+
+	if err := s.CreateTransaction(ctx, &fruit.Transaction{ID: "X", UserID: "USER1", Count: 1, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the transaction to another user and deactivate it.
+	if err := s.UpdateTransaction(ctx, "X", &fruit.Transaction{UserID: "USER2", Count: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify transaction updated.
+	if tr, err := s.Transaction(ctx, "X"); err != nil {
+		t.Fatal(err)
+	} else if tr.UserID != "USER2" || tr.Count != 3 || tr.Active {
+		t.Fatalf("unexpected transaction: %+v", tr)
+	}
+
+	// Verify user index updated.
+	if transactions, err := s.Transactions(ctx, "USER1"); err != nil {
+		t.Fatal(err)
+	} else if len(transactions) != 0 {
+		t.Fatalf("unexpected transactions: %+v", transactions)
+	}
+}
+
+func TestTransactionService_UpdateTransaction_ErrTransactionNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.TransactionService().UpdateTransaction(ctx, "X", &fruit.Transaction{}); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionService_DeleteTransaction(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.TransactionService()
+
+	if err := s.CreateTransaction(ctx, &fruit.Transaction{ID: "X", UserID: "USER1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteTransaction(ctx, "X"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify removal of transaction.
+	if _, err := s.Transaction(ctx, "X"); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionService_DeleteTransaction_ErrTransactionNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.TransactionService().DeleteTransaction(ctx, "X"); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	}
+}
@@ -1,8 +1,8 @@
 package bolt
 
 import (
-	"time"
-
+	"context"
+	"github.com/asdine/storm"
 	"github.com/notjrbauer/fruit"
 )
 
@@ -11,9 +11,9 @@ type UserService struct {
 }
 
 // User returns a user by ID.
-func (s *UserService) User(id fruit.UserID) (*fruit.User, error) {
+func (s *UserService) User(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
 	// Start read-only transaction.
-	tx, err := s.client.db.Begin(true)
+	tx, err := begin(ctx, s.client.db, true)
 	if err != nil {
 		return nil, err
 	}
@@ -23,7 +23,9 @@ func (s *UserService) User(id fruit.UserID) (*fruit.User, error) {
 	var u fruit.User
 	bucket := s.client.db.From("Users")
 
-	if err := bucket.One("ID", id, &u); err != nil {
+	if err := bucket.One("ID", id, &u); err == storm.ErrNotFound {
+		return nil, fruit.ErrUserNotFound
+	} else if err != nil {
 		return nil, err
 	} else if &u == nil {
 		return nil, nil
@@ -32,98 +34,214 @@ func (s *UserService) User(id fruit.UserID) (*fruit.User, error) {
 	return &u, nil
 }
 
-// Users returns a list of users.
-// TODO: Add params
-func (s *UserService) Users() ([]*fruit.User, error) {
-	panic("not implemented")
-}
-
-// CreateUser creates a new user.
-func (s *UserService) CreateUser(u *fruit.User) error {
-	// Require id
-	// TODO: Don't require ID, have the DB generate it
-	if u.ID == "" {
-		return fruit.ErrUserIDRequired
+// Users returns a page of users.
+func (s *UserService) Users(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error) {
+	// Start read-only transaction.
+	tx, err := view(ctx, s.client.db)
+	if err != nil {
+		return nil, "", err
 	}
+	defer tx.Rollback()
 
-	bucket := s.client.db.From("Users")
+	users := []*fruit.User{}
+	next, err := find(tx, s.client.db.From("Users").WithTransaction(tx), opt, userSortFields, &users, "", "")
+	if err != nil {
+		return nil, "", err
+	}
+	return users, next, nil
+}
 
+// CreateUser creates a new user.
+func (s *UserService) CreateUser(ctx context.Context, u *fruit.User) error {
 	// Start the read-write transaction.
-	tx, err := bucket.Begin(true)
+	tx, err := begin(ctx, s.client.db, true)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := s.createUser(ctx, tx, u); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+	s.client.broker.Notify()
+	return nil
+}
+
+// createUser creates a user within a root transaction.
+func (s *UserService) createUser(ctx context.Context, tx storm.Node, u *fruit.User) error {
+	// Require id
+	// TODO: Don't require ID, have the DB generate it
+	if u.ID == "" {
+		return fruit.ErrUserIDRequired
+	}
+
 	// Verify user doesn't already exist.
+	users := tx.From("Users")
 	var user fruit.User
-	tx.One("ID", u.ID, &user)
-
-	if user.ID != "" {
+	if err := users.One("ID", u.ID, &user); err == nil {
 		return fruit.ErrUserExists
+	} else if err != storm.ErrNotFound {
+		return err
 	}
 
+	// Set initial version and modified time.
+	u.Version = 1
+	u.ModTime = s.client.Now().UTC()
+
 	// Save the user.
-	if err := tx.Save(u); err != nil {
+	if err := users.Save(u); err != nil {
+		return err
+	} else if err := indexOrder(users, u, userSortFields); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventUserCreated, UserID: u.ID, User: u}); err != nil {
+		return err
+	}
+	return s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventUserCreated, UserID: u.ID, User: u})
 }
 
 // DeleteUser removes an existing user.
-func (s *UserService) DeleteUser(id fruit.UserID) error {
-	bucket := s.client.db.From("Users")
+func (s *UserService) DeleteUser(ctx context.Context, id fruit.UserID) error {
+	// Start transaction.
+	tx, err := begin(ctx, s.client.db, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
 	// Find user.
-	user, err := s.User(id)
-	if err != nil {
+	users := tx.From("Users")
+	var user fruit.User
+	if err := users.One("ID", id, &user); err == storm.ErrNotFound {
+		return fruit.ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if err := users.DeleteStruct(&user); err != nil {
+		return err
+	} else if err := unindexOrder(users, string(id)); err != nil {
+		return err
+	} else if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventUserDeleted, UserID: id}); err != nil {
+		return err
+	} else if err := s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventUserDeleted, UserID: id}); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
 		return err
 	}
+	s.client.broker.Notify()
+	return nil
+}
+
+// UpdateUser updates an existing user.
+func (s *UserService) UpdateUser(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+	// Require the version the changes are based on.
+	if u.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
 
 	// Start transaction.
-	tx, err := bucket.Begin(true)
+	tx, err := begin(ctx, s.client.db, true)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	if err := tx.DeleteStruct(user); err != nil {
+	if err := s.updateUser(ctx, tx, id, u); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	if err := commit(ctx, tx); err != nil {
+		return err
+	}
+	s.client.broker.Notify()
+	return nil
 }
 
-// UpdateUser removes an existing user.
-func (s *UserService) UpdateUser(id fruit.UserID, u *fruit.User) error {
-	bucket := s.client.db.From("Users")
-
+// updateUser replaces a user within a root transaction and copies the
+// stored user back to u.
+func (s *UserService) updateUser(ctx context.Context, tx storm.Node, id fruit.UserID, u *fruit.User) error {
 	// Find user.
-	user, err := s.User(id)
-	if err != nil {
+	users := tx.From("Users")
+	var user fruit.User
+	if err := users.One("ID", id, &user); err == storm.ErrNotFound {
+		return fruit.ErrUserNotFound
+	} else if err != nil {
 		return err
 	}
-	if user == nil {
-		return fruit.ErrUserNotFound
+
+	// Reject changes based on an old version.
+	if u.Version != 0 && u.Version != user.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes
+	user.Name = u.Name
+	user.CardID = u.CardID
+	user.Address = u.Address
+	user.Version++
+	user.ModTime = s.client.Now().UTC()
+
+	// A user may remove their card or address, which Update would ignore.
+	if err := users.Save(&user); err != nil {
+		return err
+	} else if err := indexOrder(users, &user, userSortFields); err != nil {
+		return err
+	} else if err := s.client.appendEvent(tx, &fruit.Event{Type: fruit.EventUserUpdated, UserID: id, User: &user}); err != nil {
+		return err
+	} else if err := s.client.appendRevision(ctx, tx, &fruit.Revision{Type: fruit.EventUserUpdated, UserID: id, User: &user}); err != nil {
+		return err
+	}
+
+	*u = user
+	return nil
+}
+
+// PatchUser changes the fields of a user named in mask.
+func (s *UserService) PatchUser(ctx context.Context, id fruit.UserID, u *fruit.User, mask fruit.FieldMask) error {
+	// Require the version the changes are based on.
+	if u.Version == 0 {
+		return fruit.ErrVersionRequired
 	}
 
 	// Start transaction.
-	tx, err := bucket.Begin(true)
+	tx, err := begin(ctx, s.client.db, true)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Apply changes
-	user.Name = u.Name
-	user.CardID = u.CardID
-	user.Address = u.Address
-	user.ModTime = time.Now().UTC()
+	// Find user.
+	users := tx.From("Users")
+	var user fruit.User
+	if err := users.One("ID", id, &user); err == storm.ErrNotFound {
+		return fruit.ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// Apply changes to the stored user, then update as usual.
+	version := u.Version
+	if err := user.Patch(u, mask); err != nil {
+		return err
+	}
+	user.Version = version
+	if err := s.updateUser(ctx, tx, id, &user); err != nil {
+		return err
+	}
 
-	if err := tx.Update(u); err != nil {
+	if err := commit(ctx, tx); err != nil {
 		return err
 	}
+	s.client.broker.Notify()
 
-	return tx.Commit()
+	*u = user
+	return nil
 }
@@ -1,6 +1,7 @@
 package bolt_test
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -15,6 +16,7 @@ func TestCreateUser(t *testing.T) {
 }
 
 func testUserService_CreateUser(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
@@ -27,11 +29,11 @@ func testUserService_CreateUser(t *testing.T) {
 		CardID:  "CARDID",
 	}
 
-	if err := s.CreateUser(&user); err != nil {
+	if err := s.CreateUser(ctx, &user); err != nil {
 		t.Fatal(err)
 	}
 
-	other, err := s.User("ID")
+	other, err := s.User(ctx, "ID")
 	if err != nil {
 		t.Fatal(err)
 	} else if !reflect.DeepEqual(&user, other) {
@@ -40,6 +42,7 @@ func testUserService_CreateUser(t *testing.T) {
 }
 
 func testUserService_CreateUser_ErrUserIDRequired(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
@@ -51,12 +54,13 @@ func testUserService_CreateUser_ErrUserIDRequired(t *testing.T) {
 		CardID:  "CARDID",
 	}
 
-	if err := s.CreateUser(&user); err != fruit.ErrUserIDRequired {
+	if err := s.CreateUser(ctx, &user); err != fruit.ErrUserIDRequired {
 		t.Fatal(err)
 	}
 }
 
 func testUserService_CreateUser_ErrUserExists(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
@@ -69,12 +73,12 @@ func testUserService_CreateUser_ErrUserExists(t *testing.T) {
 		CardID:  "CARDID",
 	}
 
-	if err := s.CreateUser(&user); err != nil {
+	if err := s.CreateUser(ctx, &user); err != nil {
 		t.Fatal(err)
 	}
 
 	// Create same user.
-	if err := s.CreateUser(&user); err != fruit.ErrUserExists {
+	if err := s.CreateUser(ctx, &user); err != fruit.ErrUserExists {
 		t.Fatal(errors.New("expected error when creating duplicate user"))
 	}
 }
@@ -84,6 +88,7 @@ func TestDeleteUser(t *testing.T) {
 }
 
 func testUserService_DeleteUser(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
@@ -96,15 +101,15 @@ func testUserService_DeleteUser(t *testing.T) {
 		CardID:  "CARDID",
 	}
 
-	if err := s.CreateUser(&user); err != nil {
+	if err := s.CreateUser(ctx, &user); err != nil {
 		t.Fatal(err)
 	}
-	if err := s.DeleteUser(user.ID); err != nil {
+	if err := s.DeleteUser(ctx, user.ID); err != nil {
 		t.Fatal(err)
 	}
 
 	// User should not exist
-	if _, err := s.User(user.ID); err == nil {
+	if _, err := s.User(ctx, user.ID); err == nil {
 		t.Fatal(errors.New("expected error when removing user"))
 	}
 }
@@ -114,6 +119,7 @@ func TestUpdateUser(t *testing.T) {
 }
 
 func testUserService_UpdateUser(t *testing.T) {
+	ctx := context.Background()
 	c := MustOpenClient()
 	defer c.Close()
 
@@ -126,20 +132,110 @@ func testUserService_UpdateUser(t *testing.T) {
 		CardID:  "CARDID",
 	}
 
-	if err := s.CreateUser(&user); err != nil {
+	if err := s.CreateUser(ctx, &user); err != nil {
 		t.Fatal(err)
 	}
 
 	user.Name = "UPDATE_NAME"
 	user.CardID = "UPDATE_CARDID"
-	if err := s.UpdateUser(user.ID, &user); err != nil {
+	if err := s.UpdateUser(ctx, user.ID, &user); err != nil {
 		t.Fatal(err)
 	}
 
 	// User should be updated.
-	if u, err := s.User(user.ID); err != nil {
+	if u, err := s.User(ctx, user.ID); err != nil {
 		t.Fatal(errors.New("expected error when updating user"))
 	} else if u.Name != "UPDATE_NAME" {
 		t.Fatalf("unexpected product sku: %s", u.Name)
 	}
 }
+
+func TestUser(t *testing.T) {
+	t.Run("ErrUserNotFound", testUserService_User_ErrUserNotFound)
+}
+
+func testUserService_User_ErrUserNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if u, err := c.UserService().User(ctx, "NO SUCH USER"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	} else if u != nil {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	t.Run("OK", testUserService_Users)
+	t.Run("Empty", testUserService_Users_Empty)
+	t.Run("Paged", testUserService_Users_Paged)
+}
+
+func testUserService_Users(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	s := c.UserService()
+
+	for _, id := range []fruit.UserID{"A", "B"} {
+		if err := s.CreateUser(ctx, &fruit.User{ID: id, Name: "NAME"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if users, _, err := s.Users(ctx, fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(users) != 2 {
+		t.Fatalf("unexpected user count: %d", len(users))
+	}
+}
+
+func testUserService_Users_Empty(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if users, _, err := c.UserService().Users(ctx, fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if users == nil {
+		t.Fatal("expected empty user array")
+	}
+}
+
+func testUserService_Users_Paged(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	s := c.UserService()
+
+	for _, u := range []*fruit.User{{ID: "A", Name: "Carol"}, {ID: "B", Name: "Alice"}, {ID: "C", Name: "Bob"}} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Walk pages sorted by name.
+	var names []string
+	opt := fruit.QueryOptions{Limit: 2, Sort: "name"}
+	for {
+		users, next, err := s.Users(ctx, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range users {
+			names = append(names, u.Name)
+		}
+
+		if next == "" {
+			break
+		}
+		opt.Cursor = next
+	}
+
+	if !reflect.DeepEqual(names, []string{"Alice", "Bob", "Carol"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+}
@@ -0,0 +1,354 @@
+package bolt
+
+import (
+	"sort"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/notjrbauer/fruit"
+)
+
+// WebhookService keeps webhook secrets in a "Secrets" bucket nested under
+// Webhooks because the secret isn't part of a webhook's JSON encoding.
+type WebhookService struct {
+	client *Client
+}
+
+// Webhook returns a webhook by ID.
+func (s *WebhookService) Webhook(id fruit.WebhookID) (*fruit.Webhook, error) {
+	webhooks := s.client.db.From("Webhooks")
+
+	var w fruit.Webhook
+	if err := webhooks.One("ID", id, &w); err == storm.ErrNotFound {
+		return nil, fruit.ErrWebhookNotFound
+	} else if err != nil {
+		return nil, err
+	} else if err := loadSecret(webhooks, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Webhooks returns every webhook sorted by ID.
+func (s *WebhookService) Webhooks() ([]*fruit.Webhook, error) {
+	webhooks := s.client.db.From("Webhooks")
+
+	var a []*fruit.Webhook
+	if err := webhooks.All(&a); err != nil {
+		return nil, err
+	}
+	for _, w := range a {
+		if err := loadSecret(webhooks, w); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// CreateWebhook creates a new webhook. A random ID and secret are generated
+// if they are not provided.
+func (s *WebhookService) CreateWebhook(w *fruit.Webhook) error {
+	if w == nil {
+		return fruit.ErrWebhookRequired
+	} else if err := w.Validate(); err != nil {
+		return err
+	}
+
+	if w.ID == "" {
+		id, err := newKey()
+		if err != nil {
+			return err
+		}
+		w.ID = fruit.WebhookID(id[:16])
+	}
+	if w.Secret == "" {
+		secret, err := newKey()
+		if err != nil {
+			return err
+		}
+		w.Secret = secret
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Webhooks").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify webhook doesn't already exist.
+	var other fruit.Webhook
+	if err := tx.One("ID", w.ID, &other); err == nil {
+		return fruit.ErrWebhookExists
+	} else if err != storm.ErrNotFound {
+		return err
+	}
+
+	w.ModTime = s.client.Now().UTC()
+
+	if err := tx.Save(w); err != nil {
+		return err
+	} else if err := tx.Set("Secrets", w.ID, w.Secret); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateWebhook replaces the URL and event types of a webhook. The secret
+// is only replaced if one is given. The stored webhook is copied to w.
+func (s *WebhookService) UpdateWebhook(id fruit.WebhookID, w *fruit.Webhook) error {
+	if w == nil {
+		return fruit.ErrWebhookRequired
+	} else if err := w.Validate(); err != nil {
+		return err
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Webhooks").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var webhook fruit.Webhook
+	if err := tx.One("ID", id, &webhook); err == storm.ErrNotFound {
+		return fruit.ErrWebhookNotFound
+	} else if err != nil {
+		return err
+	} else if err := loadSecret(tx, &webhook); err != nil {
+		return err
+	}
+
+	// Apply changes.
+	webhook.URL = w.URL
+	webhook.Events = w.Events
+	if w.Secret != "" {
+		webhook.Secret = w.Secret
+		if err := tx.Set("Secrets", id, w.Secret); err != nil {
+			return err
+		}
+	}
+	webhook.ModTime = s.client.Now().UTC()
+
+	// No event types means every type, so an emptied list must be stored.
+	if err := tx.Save(&webhook); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*w = webhook
+	return nil
+}
+
+// DeleteWebhook removes a webhook along with its delivery log.
+func (s *WebhookService) DeleteWebhook(id fruit.WebhookID) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var w fruit.Webhook
+	if err := tx.From("Webhooks").One("ID", id, &w); err == storm.ErrNotFound {
+		return fruit.ErrWebhookNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if err := tx.From("Webhooks").DeleteStruct(&w); err != nil {
+		return err
+	} else if err := tx.From("Webhooks").Delete("Secrets", id); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	deliveries, err := findDeliveries(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		if err := tx.From("Deliveries").DeleteStruct(d); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Deliveries returns the delivery log of a webhook, oldest first.
+func (s *WebhookService) Deliveries(id fruit.WebhookID) ([]*fruit.Delivery, error) {
+	if _, err := s.Webhook(id); err != nil {
+		return nil, err
+	}
+	return findDeliveries(s.client.db, id)
+}
+
+// ReplayDelivery queues a delivery to be sent again with a fresh set of
+// attempts.
+func (s *WebhookService) ReplayDelivery(id fruit.DeliveryID) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Deliveries").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var d fruit.Delivery
+	if err := tx.One("ID", id, &d); err == storm.ErrNotFound {
+		return fruit.ErrDeliveryNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if err := tx.Save(resetDelivery(&d, s.client.Now().UTC())); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplayFailed queues every failed delivery of a webhook to be sent again.
+func (s *WebhookService) ReplayFailed(id fruit.WebhookID) (int, error) {
+	// Start the read-write transaction.
+	tx, err := s.client.db.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var w fruit.Webhook
+	if err := tx.From("Webhooks").One("ID", id, &w); err == storm.ErrNotFound {
+		return 0, fruit.ErrWebhookNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	deliveries, err := findDeliveries(tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	now := s.client.Now().UTC()
+	for _, d := range deliveries {
+		if d.Status != fruit.DeliveryFailed {
+			continue
+		} else if err := tx.From("Deliveries").Save(resetDelivery(d, now)); err != nil {
+			return 0, err
+		}
+		n++
+	}
+
+	return n, tx.Commit()
+}
+
+type DeliveryService struct {
+	client *Client
+}
+
+// DueDeliveries returns up to n pending deliveries due by t, oldest first.
+func (s *DeliveryService) DueDeliveries(t time.Time, n int) ([]*fruit.Delivery, error) {
+	var pending []*fruit.Delivery
+	if err := s.client.db.From("Deliveries").Find("Status", fruit.DeliveryPending, &pending); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+
+	deliveries := make([]*fruit.Delivery, 0, n)
+	for _, d := range pending {
+		if len(deliveries) == n {
+			break
+		} else if !d.NextAttempt.After(t) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery saves the outcome of an attempt. Returns
+// ErrDeliveryNotFound if the webhook was deleted in the meantime.
+func (s *DeliveryService) UpdateDelivery(d *fruit.Delivery) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.From("Deliveries").Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var other fruit.Delivery
+	if err := tx.One("ID", d.ID, &other); err == storm.ErrNotFound {
+		return fruit.ErrDeliveryNotFound
+	} else if err != nil {
+		return err
+	}
+
+	d.ModTime = s.client.Now().UTC()
+
+	// A successful retry clears the LastError of earlier attempts.
+	if err := tx.Save(d); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queueDeliveries queues e for every matching webhook within a root
+// transaction. Webhooks are sent the redacted event.
+func queueDeliveries(tx storm.Node, e *fruit.Event) error {
+	var webhooks []*fruit.Webhook
+	if err := tx.From("Webhooks").All(&webhooks); err != nil {
+		return err
+	}
+
+	e = e.Redacted()
+	for _, w := range webhooks {
+		if !w.Matches(e.Type) {
+			continue
+		}
+
+		d := &fruit.Delivery{
+			WebhookID:   w.ID,
+			Event:       e,
+			Status:      fruit.DeliveryPending,
+			NextAttempt: e.Time,
+			ModTime:     e.Time,
+		}
+		if err := tx.From("Deliveries").Save(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDeliveries returns the deliveries of a webhook, oldest first.
+func findDeliveries(n storm.Node, id fruit.WebhookID) ([]*fruit.Delivery, error) {
+	deliveries := []*fruit.Delivery{}
+	if err := n.From("Deliveries").Find("WebhookID", id, &deliveries); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+// resetDelivery returns d queued to be sent at t with a fresh set of
+// attempts.
+func resetDelivery(d *fruit.Delivery, t time.Time) *fruit.Delivery {
+	d.Status = fruit.DeliveryPending
+	d.Attempts = 0
+	d.ResponseCode = 0
+	d.LastError = ""
+	d.NextAttempt = t
+	d.ModTime = t
+	return d
+}
+
+// loadSecret sets the secret of w from the Secrets bucket.
+func loadSecret(n storm.Node, w *fruit.Webhook) error {
+	if err := n.Get("Secrets", w.ID, &w.Secret); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package bolt_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+)
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.WebhookService()
+
+	w := fruit.Webhook{URL: "https://example.com/hook", Events: []string{fruit.EventOrderCreated}}
+
+	// Create webhook with a generated ID and secret.
+	if err := s.CreateWebhook(&w); err != nil {
+		t.Fatal(err)
+	} else if w.ID == "" || len(w.Secret) != 64 {
+		t.Fatalf("unexpected webhook: %+v", w)
+	}
+
+	if other, err := s.Webhook(w.ID); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(&w, other) {
+		t.Fatalf("unexpected webhook: %+v", other)
+	} else if webhooks, err := s.Webhooks(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(webhooks, []*fruit.Webhook{&w}) {
+		t.Fatalf("unexpected webhooks: %+v", webhooks)
+	}
+}
+
+func TestWebhookService_CreateWebhook_Errors(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.WebhookService()
+
+	if err := s.CreateWebhook(&fruit.Webhook{ID: "W", URL: "http://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		w   *fruit.Webhook
+		err error
+	}{
+		{nil, fruit.ErrWebhookRequired},
+		{&fruit.Webhook{ID: "W", URL: "http://example.com"}, fruit.ErrWebhookExists},
+		{&fruit.Webhook{URL: "/hook"}, fruit.ErrInvalidWebhookURL},
+		{&fruit.Webhook{URL: "ftp://example.com"}, fruit.ErrInvalidWebhookURL},
+		{&fruit.Webhook{URL: "http://example.com", Events: []string{"product.renamed"}}, fruit.ErrInvalidEventType},
+	} {
+		if err := s.CreateWebhook(tt.w); err != tt.err {
+			t.Errorf("%+v: unexpected error: %v", tt.w, err)
+		}
+	}
+}
+
+func TestWebhookService_UpdateWebhook(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.WebhookService()
+
+	if err := s.CreateWebhook(&fruit.Webhook{ID: "W", URL: "http://example.com", Events: []string{fruit.EventOrderCreated}, Secret: "SECRET"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The secret is kept when none is given.
+	w := fruit.Webhook{URL: "https://example.com/v2"}
+	if err := s.UpdateWebhook("W", &w); err != nil {
+		t.Fatal(err)
+	} else if w.ID != "W" || w.URL != "https://example.com/v2" || w.Events != nil || w.Secret != "SECRET" {
+		t.Fatalf("unexpected webhook: %+v", w)
+	}
+
+	if err := s.UpdateWebhook("X", &fruit.Webhook{URL: "http://example.com"}); err != fruit.ErrWebhookNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookService_Deliveries(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.WebhookService()
+
+	if err := s.CreateWebhook(&fruit.Webhook{ID: "ALL", URL: "http://example.com"}); err != nil {
+		t.Fatal(err)
+	} else if err := s.CreateWebhook(&fruit.Webhook{ID: "USERS", URL: "http://example.com", Events: []string{fruit.EventUserCreated}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Events are queued for each matching webhook.
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "A", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	} else if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U", Name: "Ursula", CardID: "CARD", Address: &fruit.Address{Line1: "1 Main St"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user's card and address are left out.
+	if a, err := s.Deliveries("ALL"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 || a[0].Event.ProductID != "A" || a[1].Event.UserID != "U" {
+		t.Fatalf("unexpected deliveries: %+v", a)
+	} else if u := a[1].Event.User; u.Name != "Ursula" || u.CardID != "" || u.Address != nil {
+		t.Fatalf("unexpected user: %+v", u)
+	} else if a[0].Status != fruit.DeliveryPending || !a[0].NextAttempt.Equal(c.Now()) {
+		t.Fatalf("unexpected delivery: %+v", a[0])
+	}
+
+	if a, err := s.Deliveries("USERS"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].Event.Type != fruit.EventUserCreated {
+		t.Fatalf("unexpected deliveries: %+v", a)
+	}
+
+	// Deleting a webhook removes its log.
+	if err := s.DeleteWebhook("USERS"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Deliveries("USERS"); err != fruit.ErrWebhookNotFound {
+		t.Fatal(err)
+	} else if a, err := c.DeliveryService().DueDeliveries(c.Now(), 10); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 || a[0].WebhookID != "ALL" || a[1].WebhookID != "ALL" {
+		t.Fatalf("unexpected deliveries: %+v", a)
+	}
+}
+
+func TestDeliveryService_DueDeliveries(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.DeliveryService()
+
+	if err := c.WebhookService().CreateWebhook(&fruit.Webhook{ID: "W", URL: "http://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []fruit.ProductID{"A", "B", "C"} {
+		if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: id, Token: "TOKEN"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := s.DueDeliveries(c.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 3 {
+		t.Fatalf("unexpected deliveries: %+v", a)
+	}
+
+	// Retry the first later and fail the second.
+	a[0].Attempts, a[0].LastError, a[0].NextAttempt = 1, "timeout", c.Now().Add(time.Minute)
+	a[1].Attempts, a[1].Status, a[1].ResponseCode = 1, fruit.DeliveryFailed, 500
+	if err := s.UpdateDelivery(a[0]); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateDelivery(a[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if due, err := s.DueDeliveries(c.Now(), 10); err != nil {
+		t.Fatal(err)
+	} else if len(due) != 1 || due[0].ID != a[2].ID {
+		t.Fatalf("unexpected deliveries: %+v", due)
+	} else if due, err := s.DueDeliveries(c.Now().Add(time.Minute), 1); err != nil {
+		t.Fatal(err)
+	} else if len(due) != 1 || due[0].ID != a[0].ID {
+		t.Fatalf("unexpected deliveries: %+v", due)
+	}
+
+	if err := s.UpdateDelivery(&fruit.Delivery{ID: 100}); err != fruit.ErrDeliveryNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookService_Replay(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	s := c.WebhookService()
+
+	if err := s.CreateWebhook(&fruit.Webhook{ID: "W", URL: "http://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []fruit.ProductID{"A", "B"} {
+		if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: id, Token: "TOKEN"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Fail both deliveries.
+	a, err := s.Deliveries("W")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range a {
+		d.Status, d.Attempts, d.LastError = fruit.DeliveryFailed, 8, "connection refused"
+		if err := c.DeliveryService().UpdateDelivery(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.ReplayDelivery(a[0].ID); err != nil {
+		t.Fatal(err)
+	} else if n, err := s.ReplayFailed("W"); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("unexpected replay count: %d", n)
+	}
+
+	if a, err := s.Deliveries("W"); err != nil {
+		t.Fatal(err)
+	} else if a[0].Status != fruit.DeliveryPending || a[0].Attempts != 0 || a[0].LastError != "" || a[1].Status != fruit.DeliveryPending {
+		t.Fatalf("unexpected deliveries: %+v, %+v", a[0], a[1])
+	}
+
+	if err := s.ReplayDelivery(100); err != fruit.ErrDeliveryNotFound {
+		t.Fatal(err)
+	} else if _, err := s.ReplayFailed("X"); err != fruit.ErrWebhookNotFound {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,420 @@
+// Package bulk streams products and users between the store and NDJSON or
+// CSV files.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// Bulk errors.
+const (
+	ErrInvalidFormat  = fruit.Error("invalid format")
+	ErrInvalidMapping = fruit.Error("invalid column mapping")
+	ErrInvalidRow     = fruit.Error("invalid row")
+)
+
+// Format represents a file format.
+type Format string
+
+// Supported formats. NDJSON files hold one JSON object per line, and CSV
+// files start with a header row naming the columns.
+const (
+	NDJSON Format = "ndjson"
+	CSV    Format = "csv"
+)
+
+// DefaultChunkSize is the number of records imported per transaction.
+const DefaultChunkSize = 500
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case NDJSON, CSV:
+		return f, nil
+	case "json":
+		return NDJSON, nil
+	}
+	return "", ErrInvalidFormat
+}
+
+// ParseMapping parses a column mapping written as "column=field" pairs
+// separated by commas, such as "Item Name=name,Colour=color".
+func ParseMapping(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	if s == "" {
+		return m, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		i := strings.Index(pair, "=")
+		if i <= 0 || i == len(pair)-1 {
+			return nil, ErrInvalidMapping
+		}
+		m[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+	}
+	return m, nil
+}
+
+// FormatMapping returns m in the form read by ParseMapping.
+func FormatMapping(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for column, field := range m {
+		pairs = append(pairs, column+"="+field)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Options represents options for importing and exporting.
+type Options struct {
+	Format Format
+
+	// Maps source column names, which are CSV headers or top-level NDJSON
+	// keys, to field names. Other columns are read by field name, and
+	// unknown columns are ignored. Exports use the mapped column names.
+	Mapping map[string]string
+
+	// If set, imports are validated but not saved.
+	DryRun bool
+
+	// Number of records per transaction. Defaults to DefaultChunkSize.
+	ChunkSize int
+
+	// Owner token set on imported products.
+	Token string
+}
+
+func (opt *Options) chunkSize() int {
+	if opt.ChunkSize <= 0 {
+		return DefaultChunkSize
+	}
+	return opt.ChunkSize
+}
+
+// column returns the column name for a field.
+func (opt *Options) column(field string) string {
+	for column, f := range opt.Mapping {
+		if f == field {
+			return column
+		}
+	}
+	return field
+}
+
+// field returns the field name for a column.
+func (opt *Options) field(column string) string {
+	if f, ok := opt.Mapping[column]; ok {
+		return f
+	}
+	return column
+}
+
+// validate returns an error if the options are invalid for a record type
+// with the given fields.
+func (opt *Options) validate(fields []string) error {
+	if _, err := ParseFormat(string(opt.Format)); err != nil {
+		return err
+	}
+
+	for _, f := range opt.Mapping {
+		if !contains(fields, f) {
+			return ErrInvalidMapping
+		}
+	}
+	return nil
+}
+
+// Report summarizes an import.
+type Report struct {
+	DryRun  bool `json:"dryRun,omitempty"`
+	Created int  `json:"created"`
+	Updated int  `json:"updated"`
+	Failed  int  `json:"failed"`
+
+	// Rejected rows, in file order.
+	Errors []fruit.ImportResult `json:"errors,omitempty"`
+}
+
+// add records the outcome of one row.
+func (r *Report) add(result fruit.ImportResult) {
+	switch {
+	case result.Err != "":
+		r.Failed++
+		r.Errors = append(r.Errors, result)
+	case result.Action == fruit.ImportCreated:
+		r.Created++
+	case result.Action == fruit.ImportUpdated:
+		r.Updated++
+	}
+}
+
+// record represents one row of an import file, keyed by field name.
+type record struct {
+	row    int
+	fields map[string]string          // CSV
+	object map[string]json.RawMessage // NDJSON
+}
+
+// decode unmarshals an NDJSON record into v.
+func (rec *record) decode(v interface{}) error {
+	buf, err := json.Marshal(rec.object)
+	if err != nil {
+		return err
+	} else if err := json.Unmarshal(buf, v); err != nil {
+		return ErrInvalidRow
+	}
+	return nil
+}
+
+// recordReader reads the records of an import file.
+type recordReader interface {
+	// Next returns the next record, or io.EOF at the end of the file. A
+	// non-nil rowErr rejects only the returned row.
+	Next() (rec *record, rowErr error, err error)
+}
+
+func newRecordReader(r io.Reader, opt *Options) (recordReader, error) {
+	if opt.Format == CSV {
+		return newCSVReader(r, opt)
+	}
+	return &ndjsonReader{scanner: newLineScanner(r), opt: opt}, nil
+}
+
+// ndjsonReader reads one JSON object per line. Blank lines are skipped.
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	opt     *Options
+	line    int
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return scanner
+}
+
+func (r *ndjsonReader) Next() (*record, error, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		rec := &record{row: r.line}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(line, &object); err != nil {
+			return rec, ErrInvalidRow, nil
+		}
+
+		rec.object = make(map[string]json.RawMessage, len(object))
+		for k, v := range object {
+			rec.object[r.opt.field(k)] = v
+		}
+		return rec, nil, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, io.EOF
+}
+
+// csvReader reads rows following a header row.
+type csvReader struct {
+	reader *csv.Reader
+	fields []string
+}
+
+func newCSVReader(r io.Reader, opt *Options) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrInvalidRow
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Spreadsheets often save a byte order mark before the header.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	fields := make([]string, len(header))
+	for i, column := range header {
+		fields[i] = opt.field(strings.TrimSpace(column))
+	}
+	return &csvReader{reader: reader, fields: fields}, nil
+}
+
+func (r *csvReader) Next() (*record, error, error) {
+	values, err := r.reader.Read()
+	if e, ok := err.(*csv.ParseError); ok {
+		return &record{row: e.StartLine}, ErrInvalidRow, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	rec := &record{row: line, fields: make(map[string]string, len(values))}
+	for i, v := range values {
+		rec.fields[r.fields[i]] = strings.TrimSpace(v)
+	}
+	return rec, nil, nil
+}
+
+// importer reads records, converts them, and imports them in chunks.
+type importer struct {
+	opt *Options
+
+	// Converts a record to the value imported.
+	convert func(rec *record) (interface{}, string, error)
+
+	// Imports a chunk of converted values.
+	flush func(a []interface{}) ([]fruit.ImportResult, error)
+}
+
+func (imp *importer) run(r io.Reader) (*Report, error) {
+	reader, err := newRecordReader(r, imp.opt)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: imp.opt.DryRun}
+	var chunk []interface{}
+	var rows []int
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		results, err := imp.flush(chunk)
+		if err != nil {
+			return err
+		}
+		for i, result := range results {
+			result.Row = rows[i]
+			report.add(result)
+		}
+
+		chunk, rows = chunk[:0], rows[:0]
+		return nil
+	}
+
+	for {
+		rec, rowErr, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		var v interface{}
+		var id string
+		if rowErr == nil {
+			v, id, rowErr = imp.convert(rec)
+		}
+		if rowErr != nil {
+			report.add(fruit.ImportResult{Row: rec.row, ID: id, Err: rowErr.Error()})
+			continue
+		}
+
+		chunk, rows = append(chunk, v), append(rows, rec.row)
+		if len(chunk) == imp.opt.chunkSize() {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	// Unreadable rows are reported before their chunk is imported.
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	return report, nil
+}
+
+// writer writes records to an export file.
+type writer struct {
+	opt    *Options
+	fields []string
+	json   *json.Encoder
+	csv    *csv.Writer
+}
+
+func newWriter(w io.Writer, opt *Options, fields []string) (*writer, error) {
+	ew := &writer{opt: opt, fields: fields}
+	if opt.Format != CSV {
+		ew.json = json.NewEncoder(w)
+		return ew, nil
+	}
+
+	ew.csv = csv.NewWriter(w)
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = opt.column(f)
+	}
+	return ew, ew.csv.Write(header)
+}
+
+// write writes one record. values holds the CSV value of each field, and
+// v is encoded as JSON.
+func (w *writer) write(v interface{}, values map[string]string) error {
+	if w.csv != nil {
+		row := make([]string, len(w.fields))
+		for i, f := range w.fields {
+			row[i] = values[f]
+		}
+		return w.csv.Write(row)
+	}
+
+	if len(w.opt.Mapping) == 0 {
+		return w.json.Encode(v)
+	}
+
+	// Rename keys to their mapped columns.
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &object); err != nil {
+		return err
+	}
+
+	other := make(map[string]json.RawMessage, len(object))
+	for k, v := range object {
+		other[w.opt.column(k)] = v
+	}
+	return w.json.Encode(other)
+}
+
+// Flush writes any buffered data.
+func (w *writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+func contains(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package bulk_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/bulk"
+	"github.com/notjrbauer/fruit/inmem"
+)
+
+// Ensure CSV rows are mapped, imported in chunks, and rejected per row.
+func TestImportProducts_CSV(t *testing.T) {
+	ctx := context.Background()
+	c := inmem.NewClient()
+
+	r := strings.NewReader("\ufeffSKU Code,Item Name,Colour,price,currency,notes\n" +
+		"APL,Apple,Red,100,USD,crisp\n" +
+		"Ba\"d,Bad\n" +
+		"PR,Pear,Green,abc,USD,\n" +
+		"BN,Banana,Yellow,,,\n" +
+		",Nameless,,,,\n")
+
+	report, err := bulk.ImportProducts(r, c.ImportService(), bulk.Options{
+		Format:    bulk.CSV,
+		Mapping:   map[string]string{"SKU Code": "productID", "Item Name": "name", "Colour": "color"},
+		ChunkSize: 2,
+		Token:     "TOKEN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if report.Created != 2 || report.Failed != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	} else if !reflect.DeepEqual(report.Errors, []fruit.ImportResult{
+		{Row: 3, Err: bulk.ErrInvalidRow.Error()},
+		{Row: 4, Err: fruit.ErrInvalidPrice.Error()},
+		{Row: 6, Err: fruit.ErrProductIDRequired.Error()},
+	}) {
+		t.Fatalf("unexpected errors: %+v", report.Errors)
+	}
+
+	if p, err := c.ProductService().Product(ctx, "APL"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apple" || p.Color != "Red" || *p.Price != fruit.NewMoney(100, "USD") || p.Token != "" {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if p, err := c.ProductService().Product(ctx, "BN"); err != nil {
+		t.Fatal(err)
+	} else if p.Price != nil {
+		t.Fatalf("unexpected price: %+v", p.Price)
+	}
+}
+
+// Ensure a dry run reports results without saving.
+func TestImportProducts_DryRun(t *testing.T) {
+	ctx := context.Background()
+	c := inmem.NewClient()
+
+	r := strings.NewReader(`{"productID":"A","name":"Apple"}` + "\n\n" +
+		`{"productID":"B","price":{"amount":1,"currency":"usd"}}` + "\n" +
+		`not json` + "\n")
+
+	report, err := bulk.ImportProducts(r, c.ImportService(), bulk.Options{Format: bulk.NDJSON, DryRun: true, Token: "TOKEN"})
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(report, &bulk.Report{DryRun: true, Created: 1, Failed: 2, Errors: []fruit.ImportResult{
+		{Row: 3, ID: "B", Err: fruit.ErrInvalidCurrency.Error()},
+		{Row: 4, Err: bulk.ErrInvalidRow.Error()},
+	}}) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	if _, err := c.ProductService().Product(ctx, "A"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure exported products can be imported again.
+func TestExportProducts(t *testing.T) {
+	ctx := context.Background()
+	c := inmem.NewClient()
+	for _, p := range []*fruit.Product{
+		{ID: "A", Token: "TOKEN", Name: "Apple, Red", Price: &fruit.Money{Amount: 100, Currency: "USD"}},
+		{ID: "B", Token: "TOKEN", Name: "Banana", Description: "Long\nand yellow"},
+		{ID: "C", Token: "TOKEN", Name: "Cherry"},
+	} {
+		if err := c.ProductService().CreateProduct(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, format := range []bulk.Format{bulk.CSV, bulk.NDJSON} {
+		opt := bulk.Options{Format: format, ChunkSize: 2, Mapping: map[string]string{"Item Name": "name"}, Token: "TOKEN"}
+
+		var buf bytes.Buffer
+		if err := bulk.ExportProducts(ctx, &buf, c.ProductService(), opt); err != nil {
+			t.Fatal(err)
+		} else if !strings.Contains(buf.String(), "Item Name") {
+			t.Fatalf("%s: expected mapped column: %s", format, buf.String())
+		}
+
+		other := inmem.NewClient()
+		if report, err := bulk.ImportProducts(&buf, other.ImportService(), opt); err != nil {
+			t.Fatal(err)
+		} else if report.Created != 3 || report.Failed != 0 {
+			t.Fatalf("%s: unexpected report: %+v", format, report)
+		}
+
+		want, _, _ := c.ProductService().Products(ctx, fruit.QueryOptions{})
+		got, _, err := other.ProductService().Products(ctx, fruit.QueryOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range got {
+			got[i].ModTime, want[i].ModTime = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(got[i], want[i]) {
+				t.Fatalf("%s: unexpected product: %+v", format, got[i])
+			}
+		}
+	}
+}
+
+// Ensure users round trip through CSV with their addresses.
+func TestImportUsers_CSV(t *testing.T) {
+	ctx := context.Background()
+	c := inmem.NewClient()
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "A", Name: "Alice", CardID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := strings.NewReader("userID,name,city,zipCode\nA,Alicia,Denver,80202\nB,Bob,,\n")
+	if report, err := bulk.ImportUsers(r, c.ImportService(), bulk.Options{Format: bulk.CSV}); err != nil {
+		t.Fatal(err)
+	} else if report.Created != 1 || report.Updated != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	if u, err := c.UserService().User(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if u.Name != "Alicia" || u.CardID != "" || !reflect.DeepEqual(u.Address, &fruit.Address{City: "Denver", ZipCode: "80202"}) {
+		t.Fatalf("unexpected user: %+v", u)
+	} else if u, err := c.UserService().User(ctx, "B"); err != nil {
+		t.Fatal(err)
+	} else if u.Address != nil {
+		t.Fatalf("unexpected address: %+v", u.Address)
+	}
+
+	var buf bytes.Buffer
+	if err := bulk.ExportUsers(ctx, &buf, c.UserService(), bulk.Options{Format: bulk.CSV}); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "userID,name,card,line1,line2,city,state,zipCode,country\nA,Alicia,,,,Denver,,80202,\nB,Bob,,,,,,,\n" {
+		t.Fatalf("unexpected export: %q", buf.String())
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	if m, err := bulk.ParseMapping("Item Name=name, Colour=color"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(m, map[string]string{"Item Name": "name", "Colour": "color"}) {
+		t.Fatalf("unexpected mapping: %v", m)
+	} else if _, err := bulk.ParseMapping("name"); err != bulk.ErrInvalidMapping {
+		t.Fatal(err)
+	}
+
+	// Mappings must name known fields.
+	if _, err := bulk.ImportProducts(strings.NewReader(""), nil, bulk.Options{Format: bulk.CSV, Mapping: map[string]string{"X": "token"}}); err != bulk.ErrInvalidMapping {
+		t.Fatal(err)
+	} else if _, err := bulk.ImportProducts(strings.NewReader(""), nil, bulk.Options{Format: "xml"}); err != bulk.ErrInvalidFormat {
+		t.Fatal(err)
+	}
+}
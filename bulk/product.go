@@ -0,0 +1,120 @@
+package bulk
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// productFields are the columns of a product file. Prices are in minor
+// units, with the currency in its own CSV column.
+var productFields = []string{"productID", "name", "sku", "type", "color", "description", "price", "currency", "categoryID"}
+
+// ImportProducts reads products from r and imports them in chunks. Rows
+// which can't be read or are rejected by the store are listed in the
+// report.
+func ImportProducts(r io.Reader, s fruit.ImportService, opt Options) (*Report, error) {
+	if err := opt.validate(productFields); err != nil {
+		return nil, err
+	}
+
+	imp := &importer{
+		opt: &opt,
+		convert: func(rec *record) (interface{}, string, error) {
+			p, err := decodeProduct(rec)
+			if err != nil {
+				return nil, "", err
+			}
+			p.Token = opt.Token
+			return p, string(p.ID), nil
+		},
+		flush: func(a []interface{}) ([]fruit.ImportResult, error) {
+			products := make([]*fruit.Product, len(a))
+			for i, v := range a {
+				products[i] = v.(*fruit.Product)
+			}
+			return s.ImportProducts(products, opt.DryRun)
+		},
+	}
+	return imp.run(r)
+}
+
+// ExportProducts writes every product to w, reading a page at a time.
+func ExportProducts(ctx context.Context, w io.Writer, s fruit.ProductService, opt Options) error {
+	if err := opt.validate(productFields); err != nil {
+		return err
+	}
+
+	ew, err := newWriter(w, &opt, productFields)
+	if err != nil {
+		return err
+	}
+
+	for q := (fruit.QueryOptions{Limit: opt.chunkSize()}); ; {
+		products, next, err := s.Products(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range products {
+			if err := ew.write(p, encodeProduct(p)); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		q.Cursor = next
+	}
+	return ew.Flush()
+}
+
+// decodeProduct returns the product in rec.
+func decodeProduct(rec *record) (*fruit.Product, error) {
+	var p fruit.Product
+	if rec.object != nil {
+		if err := rec.decode(&p); err != nil {
+			return nil, err
+		}
+		return &p, nil
+	}
+
+	f := rec.fields
+	p.ID = fruit.ProductID(f["productID"])
+	p.Name = f["name"]
+	p.SKU = f["sku"]
+	p.Type = f["type"]
+	p.Color = f["color"]
+	p.Description = f["description"]
+	p.CategoryID = fruit.CategoryID(f["categoryID"])
+
+	if f["price"] != "" || f["currency"] != "" {
+		amount, err := strconv.ParseInt(f["price"], 10, 64)
+		if err != nil {
+			return nil, fruit.ErrInvalidPrice
+		}
+		p.Price = &fruit.Money{Amount: amount, Currency: f["currency"]}
+	}
+	return &p, nil
+}
+
+// encodeProduct returns the CSV values of p.
+func encodeProduct(p *fruit.Product) map[string]string {
+	values := map[string]string{
+		"productID":   string(p.ID),
+		"name":        p.Name,
+		"sku":         p.SKU,
+		"type":        p.Type,
+		"color":       p.Color,
+		"description": p.Description,
+		"categoryID":  string(p.CategoryID),
+	}
+	if p.Price != nil {
+		values["price"] = strconv.FormatInt(p.Price.Amount, 10)
+		values["currency"] = p.Price.Currency
+	}
+	return values
+}
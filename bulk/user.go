@@ -0,0 +1,126 @@
+package bulk
+
+import (
+	"context"
+	"io"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// userFields are the columns of a user file. Address fields are flattened
+// into their own CSV columns.
+var userFields = []string{"userID", "name", "card", "line1", "line2", "city", "state", "zipCode", "country", "address"}
+
+// addressFields are the CSV columns of a user's address.
+var addressFields = []string{"line1", "line2", "city", "state", "zipCode", "country"}
+
+// ImportUsers reads users from r and imports them in chunks. Existing users
+// are replaced. Rows which can't be read or are rejected by the store are
+// listed in the report.
+func ImportUsers(r io.Reader, s fruit.ImportService, opt Options) (*Report, error) {
+	if err := opt.validate(userFields); err != nil {
+		return nil, err
+	}
+
+	imp := &importer{
+		opt: &opt,
+		convert: func(rec *record) (interface{}, string, error) {
+			u, err := decodeUser(rec)
+			if err != nil {
+				return nil, "", err
+			}
+			return u, string(u.ID), nil
+		},
+		flush: func(a []interface{}) ([]fruit.ImportResult, error) {
+			users := make([]*fruit.User, len(a))
+			for i, v := range a {
+				users[i] = v.(*fruit.User)
+			}
+			return s.ImportUsers(users, opt.DryRun)
+		},
+	}
+	return imp.run(r)
+}
+
+// ExportUsers writes every user to w, reading a page at a time.
+func ExportUsers(ctx context.Context, w io.Writer, s fruit.UserService, opt Options) error {
+	if err := opt.validate(userFields); err != nil {
+		return err
+	}
+
+	// The nested address is only written to NDJSON files.
+	fields := userFields[:len(userFields)-1]
+	ew, err := newWriter(w, &opt, fields)
+	if err != nil {
+		return err
+	}
+
+	for q := (fruit.QueryOptions{Limit: opt.chunkSize()}); ; {
+		users, next, err := s.Users(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			if err := ew.write(u, encodeUser(u)); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		q.Cursor = next
+	}
+	return ew.Flush()
+}
+
+// decodeUser returns the user in rec. A CSV user has an address if any
+// address column is set.
+func decodeUser(rec *record) (*fruit.User, error) {
+	var u fruit.User
+	if rec.object != nil {
+		if err := rec.decode(&u); err != nil {
+			return nil, err
+		}
+		return &u, nil
+	}
+
+	f := rec.fields
+	u.ID = fruit.UserID(f["userID"])
+	u.Name = f["name"]
+	u.CardID = f["card"]
+
+	for _, name := range addressFields {
+		if f[name] != "" {
+			u.Address = &fruit.Address{
+				Line1:   f["line1"],
+				Line2:   f["line2"],
+				City:    f["city"],
+				State:   f["state"],
+				ZipCode: f["zipCode"],
+				Country: f["country"],
+			}
+			break
+		}
+	}
+	return &u, nil
+}
+
+// encodeUser returns the CSV values of u.
+func encodeUser(u *fruit.User) map[string]string {
+	values := map[string]string{
+		"userID": string(u.ID),
+		"name":   u.Name,
+		"card":   u.CardID,
+	}
+	if a := u.Address; a != nil {
+		values["line1"] = a.Line1
+		values["line2"] = a.Line2
+		values["city"] = a.City
+		values["state"] = a.State
+		values["zipCode"] = a.ZipCode
+		values["country"] = a.Country
+	}
+	return values
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/notjrbauer/fruit/bolt"
 	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/trash"
+	"github.com/notjrbauer/fruit/webhook"
 )
 
 func main() {
@@ -16,9 +18,43 @@ func main() {
 
 	s := http.NewServer()
 	s.Handler = &http.Handler{
-		ProductHandler: http.NewProductHandler(),
+		ProductHandler:     http.NewProductHandler(),
+		UserHandler:        http.NewUserHandler(),
+		TransactionHandler: http.NewTransactionHandler(),
+		CartHandler:        http.NewCartHandler(),
+		OrderHandler:       http.NewOrderHandler(),
+		BackupHandler:      http.NewBackupHandler(),
+		EventHandler:       http.NewEventHandler(),
+		WebhookHandler:     http.NewWebhookHandler(),
+		RevisionHandler:    http.NewRevisionHandler(),
 	}
 	s.Handler.ProductHandler.ProductService = c.ProductService()
+	s.Handler.ProductHandler.ImportService = c.ImportService()
+	s.Handler.UserHandler.UserService = c.UserService()
+	s.Handler.TransactionHandler.TransactionService = c.TransactionService()
+	s.Handler.CartHandler.CartService = c.CartService()
+	s.Handler.OrderHandler.OrderService = c.OrderService()
+	s.Handler.BackupHandler.BackupService = c
+	s.Handler.EventHandler.EventService = c.EventService()
+	s.Handler.WebhookHandler.WebhookService = c.WebhookService()
+	s.Handler.RevisionHandler.RevisionService = c.RevisionService()
+	s.Handler.APIKeyService = c.APIKeyService()
+
+	// Send queued webhook deliveries in the background.
+	d := webhook.NewDispatcher()
+	d.WebhookService = c.WebhookService()
+	d.DeliveryService = c.DeliveryService()
+	if err := d.Open(); err != nil {
+		panic(err)
+	}
+
+	// Purge expired products from the trash in the background.
+	p := trash.NewPurger()
+	p.ProductService = c.ProductService()
+	if err := p.Open(); err != nil {
+		panic(err)
+	}
+
 	s.Addr = ":3000"
 	_ = s.Open()
 	spew.Dump(s)
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/notjrbauer/fruit/bolt"
+	"github.com/notjrbauer/fruit/bulk"
+)
+
+const usage = `usage:
+  fruit migrate status|up -db PATH
+  fruit restore -db PATH -from BACKUP
+  fruit import products|users -db PATH [-format ndjson|csv] [-map COLUMN=FIELD,...] [-dry-run] [-token TOKEN] [-file PATH]
+  fruit export products|users -db PATH [-format ndjson|csv] [-map COLUMN=FIELD,...] [-file PATH]`
+
+func main() {
+	// Parse command line arguments
+	migrateCommand := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbPath := migrateCommand.String("db", "", "bolt database path")
+
+	restoreCommand := flag.NewFlagSet("restore", flag.ExitOnError)
+	restoreDBPath := restoreCommand.String("db", "", "bolt database path")
+	backupPath := restoreCommand.String("from", "", "backup file path")
+
+	importCommand := flag.NewFlagSet("import", flag.ExitOnError)
+	importOpt := bulkFlags(importCommand)
+	importDryRun := importCommand.Bool("dry-run", false, "validate without saving")
+	importToken := importCommand.String("token", "", "owner token for imported products")
+
+	exportCommand := flag.NewFlagSet("export", flag.ExitOnError)
+	exportOpt := bulkFlags(exportCommand)
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// First argument specifies a subcommand to run.
+	switch os.Args[1] {
+	case "migrate":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		migrateCommand.Parse(os.Args[3:])
+		if err := migrate(os.Args[2], *dbPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "restore":
+		restoreCommand.Parse(os.Args[2:])
+		if err := restore(*restoreDBPath, *backupPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "import", "export":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+
+		var err error
+		if os.Args[1] == "import" {
+			importCommand.Parse(os.Args[3:])
+			importOpt.DryRun, importOpt.Token = *importDryRun, *importToken
+			err = runImport(os.Args[2], importOpt)
+		} else {
+			exportCommand.Parse(os.Args[3:])
+			err = runExport(os.Args[2], exportOpt)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// bulkCommand holds the flags shared by import and export.
+type bulkCommand struct {
+	bulk.Options
+
+	db      *string
+	format  *string
+	mapping *string
+	file    *string
+}
+
+func bulkFlags(fs *flag.FlagSet) *bulkCommand {
+	return &bulkCommand{
+		db:      fs.String("db", "", "bolt database path"),
+		format:  fs.String("format", "ndjson", "file format: ndjson or csv"),
+		mapping: fs.String("map", "", "column mapping, such as 'Item Name=name'"),
+		file:    fs.String("file", "", "file path, defaults to stdin or stdout"),
+	}
+}
+
+// open parses the flags and opens the database.
+func (cmd *bulkCommand) open() (*bolt.Client, error) {
+	var err error
+	if cmd.Format, err = bulk.ParseFormat(*cmd.format); err != nil {
+		return nil, err
+	} else if cmd.Mapping, err = bulk.ParseMapping(*cmd.mapping); err != nil {
+		return nil, err
+	} else if *cmd.db == "" {
+		return nil, fmt.Errorf("database path required")
+	}
+
+	c := bolt.NewClient()
+	c.Path = *cmd.db
+	if err := c.Open(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// runImport imports products or users from a file.
+func runImport(kind string, cmd *bulkCommand) error {
+	c, err := cmd.open()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	r := os.Stdin
+	if *cmd.file != "" {
+		if r, err = os.Open(*cmd.file); err != nil {
+			return err
+		}
+		defer r.Close()
+	}
+
+	var report *bulk.Report
+	switch kind {
+	case "products":
+		report, err = bulk.ImportProducts(r, c.ImportService(), cmd.Options)
+	case "users":
+		report, err = bulk.ImportUsers(r, c.ImportService(), cmd.Options)
+	default:
+		return fmt.Errorf("unknown record type: %s", kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, result := range report.Errors {
+		fmt.Fprintf(os.Stdout, "row %d: %s: %s\n", result.Row, result.ID, result.Err)
+	}
+	if report.DryRun {
+		fmt.Fprint(os.Stdout, "dry run: ")
+	}
+	fmt.Fprintf(os.Stdout, "%d created, %d updated, %d failed\n", report.Created, report.Updated, report.Failed)
+	return nil
+}
+
+// runExport exports products or users to a file.
+func runExport(kind string, cmd *bulkCommand) error {
+	ctx := context.Background()
+	c, err := cmd.open()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *cmd.file == "" {
+		return export(ctx, kind, os.Stdout, c, cmd.Options)
+	}
+
+	// Only a file we created is ours to close. Its close error matters
+	// because buffered writes can fail there.
+	f, err := os.Create(*cmd.file)
+	if err != nil {
+		return err
+	} else if err := export(ctx, kind, f, c, cmd.Options); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// export writes every record of kind to w.
+func export(ctx context.Context, kind string, w io.Writer, c *bolt.Client, opt bulk.Options) error {
+	switch kind {
+	case "products":
+		return bulk.ExportProducts(ctx, w, c.ProductService(), opt)
+	case "users":
+		return bulk.ExportUsers(ctx, w, c.UserService(), opt)
+	default:
+		return fmt.Errorf("unknown record type: %s", kind)
+	}
+}
+
+// migrate reports or applies the schema migrations of the database at path.
+func migrate(action, path string) error {
+	if action != "status" && action != "up" {
+		return fmt.Errorf("unknown migrate action: %s", action)
+	} else if path == "" {
+		return fmt.Errorf("database path required")
+	}
+
+	// Open without migrating so status reports the database as it is.
+	c := bolt.NewClient()
+	c.Path = path
+	c.SkipMigrations = true
+	if err := c.Open(); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	pending, err := c.PendingMigrations()
+	if err != nil {
+		return err
+	}
+
+	version, err := c.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "status":
+		fmt.Fprintf(os.Stdout, "schema version %d, %d pending\n", version, len(pending))
+		for _, name := range pending {
+			fmt.Fprintln(os.Stdout, "  pending:", name)
+		}
+	case "up":
+		if err := c.Migrate(); err != nil {
+			return err
+		}
+		for _, name := range pending {
+			fmt.Fprintln(os.Stdout, "applied:", name)
+		}
+		fmt.Fprintf(os.Stdout, "schema version %d\n", version+len(pending))
+	}
+	return nil
+}
+
+// restore replaces the database at path with a backup. The server using the
+// database must be stopped first.
+func restore(path, backup string) error {
+	if path == "" {
+		return fmt.Errorf("database path required")
+	} else if backup == "" {
+		return fmt.Errorf("backup path required")
+	}
+
+	f, err := os.Open(backup)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := bolt.Restore(path, f); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "restored %s from %s\n", path, backup)
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"math/rand"
@@ -19,30 +20,58 @@ func main() {
 	generateCommand := flag.NewFlagSet("generate", flag.ContinueOnError)
 	srcDBPath := generateCommand.String("src-db", "", "source db path")
 	since := generateCommand.Int("start-txtid", 0, "replay from txid")
+	token := generateCommand.String("token", "seed", "owner token for generated products")
+
+	apiKeyCommand := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	keyDBPath := apiKeyCommand.String("src-db", "", "source db path")
+	keyUserID := apiKeyCommand.String("user", "", "user id the key belongs to")
+	keyAdmin := apiKeyCommand.Bool("admin", false, "grant admin access")
 
 	// First argument specifies a subcommand to run.
 	switch os.Args[1] {
 	case "generate":
 		generateCommand.Parse(os.Args[2:])
 		fmt.Fprintln(os.Stdout, srcDBPath, since)
-		err := generate(*srcDBPath)
+		err := generate(*srcDBPath, *token)
 		if err != nil {
 			panic(err)
 		}
+	case "apikey":
+		apiKeyCommand.Parse(os.Args[2:])
+		k := &fruit.APIKey{UserID: fruit.UserID(*keyUserID), Admin: *keyAdmin}
+		if err := createAPIKey(*keyDBPath, k); err != nil {
+			panic(err)
+		}
+		fmt.Fprintln(os.Stdout, k.Key)
+	}
+}
+
+// createAPIKey issues a new API key in the database at path.
+func createAPIKey(path string, k *fruit.APIKey) error {
+	c := bolt.NewClient()
+	c.Path = path
+
+	if err := c.Open(); err != nil {
+		return err
 	}
+	defer c.Close()
+
+	return c.APIKeyService().CreateAPIKey(k)
 }
 
-func generate(path string) error {
+func generate(path, token string) error {
 	//for {
 	// Generate temporary path.
 	_, err := newFile(path)
 
-	err = generateFile(path, 10)
+	err = generateFile(path, 10, token)
 
 	return err
 }
 
-func generateFile(path string, n int) error {
+func generateFile(path string, n int, token string) error {
+	ctx := context.Background()
+
 	// Initialize client.
 	c := bolt.NewClient()
 	c.Path = path
@@ -60,12 +89,12 @@ func generateFile(path string, n int) error {
 
 		// TODO: Break these into their own functions when all services are defined.
 		// Generate products.
-		if err := c.ProductService().CreateProduct(&fruit.Product{ID: fruit.ProductID(id), Color: colors[color]}); err != nil {
+		if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: fruit.ProductID(id), Token: token, Color: colors[color], Price: &fruit.Money{Amount: int64(r.Intn(1000) + 1), Currency: "USD"}}); err != nil {
 			return err
 		}
 
 		// Generate Users
-		if err := c.UserService().CreateUser(&fruit.User{ID: fruit.UserID(id), Name: colors[color], CardID: strconv.Itoa(rand.Int())}); err != nil {
+		if err := c.UserService().CreateUser(ctx, &fruit.User{ID: fruit.UserID(id), Name: colors[color], CardID: strconv.Itoa(rand.Int())}); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,19 @@
+package fruit
+
+import "context"
+
+type contextKey int
+
+const principalContextKey contextKey = iota
+
+// NewPrincipalContext returns a new context that carries the principal p.
+func NewPrincipalContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey, p)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any. Returns
+// nil for anonymous callers.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalContextKey).(*Principal)
+	return p
+}
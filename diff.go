@@ -0,0 +1,126 @@
+package fruit
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// FieldChange represents a change to one field. Field is named as in a
+// FieldMask, and Old and New hold its JSON values, which are null when the
+// field is unset.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// null is the JSON value of an unset field.
+var null = json.RawMessage("null")
+
+// DiffProducts returns the fields which differ between two versions of a
+// product, sorted by name. A nil product has no fields set. The ID,
+// version and modified time are ignored.
+func DiffProducts(prev, next *Product) ([]FieldChange, error) {
+	return diff(prev, next, "productID", "version", "modTime")
+}
+
+// DiffUsers returns the fields which differ between two versions of a
+// user, sorted by name. A nil user has no fields set. The ID, version and
+// modified time are ignored.
+func DiffUsers(prev, next *User) ([]FieldChange, error) {
+	return diff(prev, next, "userID", "version", "modTime")
+}
+
+// diff compares the JSON encodings of prev and next field by field,
+// skipping the top-level fields in ignore.
+func diff(prev, next interface{}, ignore ...string) ([]FieldChange, error) {
+	a, err := flatten(prev)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flatten(next)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range ignore {
+		delete(a, field)
+		delete(b, field)
+	}
+
+	fields := []string{}
+	for field := range a {
+		fields = append(fields, field)
+	}
+	for field := range b {
+		if _, ok := a[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []FieldChange{}
+	for _, field := range fields {
+		old, ok := a[field]
+		if !ok {
+			old = null
+		}
+		new, ok := b[field]
+		if !ok {
+			new = null
+		}
+
+		if !bytes.Equal(old, new) {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	return changes, nil
+}
+
+// flatten returns the JSON values of the fields of v by name. Fields of
+// nested objects are joined with a dot, and null fields are left out.
+func flatten(v interface{}) (map[string]json.RawMessage, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are kept as written so amounts aren't rounded.
+	var obj interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := flattenValue(fields, "", obj); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// flattenValue adds the fields of v, named under prefix, to fields.
+func flattenValue(fields map[string]json.RawMessage, prefix string, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for name, value := range v {
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			if err := flattenValue(fields, name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fields[prefix] = buf
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package fruit_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+func TestDiffProducts(t *testing.T) {
+	price := fruit.NewMoney(100, "USD")
+	prev := &fruit.Product{ID: "A", Name: "Apple", SKU: "APL", Price: &price, Version: 1}
+	next := &fruit.Product{ID: "A", Name: "Apple", SKU: "APL-2", Version: 2}
+
+	changes, err := fruit.DiffProducts(prev, next)
+	if err != nil {
+		t.Fatal(err)
+	} else if s := mustMarshal(t, changes); s != `[`+
+		`{"field":"price.amount","old":100,"new":null},`+
+		`{"field":"price.currency","old":"USD","new":null},`+
+		`{"field":"sku","old":"APL","new":"APL-2"}]` {
+		t.Fatalf("unexpected changes: %s", s)
+	}
+
+	// Every set field of a new product is a change.
+	if changes, err := fruit.DiffProducts(nil, next); err != nil {
+		t.Fatal(err)
+	} else if s := mustMarshal(t, changes); s != `[`+
+		`{"field":"color","old":null,"new":""},`+
+		`{"field":"description","old":null,"new":""},`+
+		`{"field":"name","old":null,"new":"Apple"},`+
+		`{"field":"sku","old":null,"new":"APL-2"},`+
+		`{"field":"type","old":null,"new":""}]` {
+		t.Fatalf("unexpected changes: %s", s)
+	}
+}
+
+func TestDiffUsers(t *testing.T) {
+	prev := &fruit.User{ID: "A", Name: "Alice", Address: &fruit.Address{City: "Denver"}}
+	next := &fruit.User{ID: "A", Name: "Alice", Address: &fruit.Address{City: "Boulder"}}
+
+	if changes, err := fruit.DiffUsers(prev, next); err != nil {
+		t.Fatal(err)
+	} else if s := mustMarshal(t, changes); s != `[{"field":"address.city","old":"Denver","new":"Boulder"}]` {
+		t.Fatalf("unexpected changes: %s", s)
+	}
+
+	// Identical users have no changes.
+	if changes, err := fruit.DiffUsers(prev, prev); err != nil {
+		t.Fatal(err)
+	} else if len(changes) != 0 {
+		t.Fatalf("unexpected changes: %s", mustMarshal(t, changes))
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
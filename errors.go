@@ -3,7 +3,25 @@ package fruit
 // General errors.
 const (
 	ErrUnauthorized = Error("unauthorized")
+	ErrForbidden    = Error("forbidden")
 	ErrInternal     = Error("internal error")
+
+	// ErrConflict is returned when a record has changed since the version
+	// the caller read.
+	ErrConflict = Error("record has been modified")
+
+	// ErrVersionRequired is returned when an update doesn't name the
+	// version it is based on.
+	ErrVersionRequired = Error("record version required")
+)
+
+// Query errors.
+const (
+	ErrInvalidLimit  = Error("limit must not be negative")
+	ErrInvalidCursor = Error("invalid cursor")
+	ErrInvalidSort   = Error("invalid sort field")
+
+	ErrSearchQueryRequired = Error("search query required")
 )
 
 // Product errors.
@@ -12,6 +30,38 @@ const (
 	ErrProductNotFound   = Error("product not found")
 	ErrProductExists     = Error("product already exists")
 	ErrProductIDRequired = Error("product id required")
+	ErrProductNotDeleted = Error("product is not deleted")
+)
+
+// Batch errors.
+const (
+	ErrInvalidBatchOp = Error("invalid batch operation")
+	ErrBatchAborted   = Error("batch aborted")
+)
+
+// Patch errors.
+const (
+	ErrInvalidField = Error("field cannot be patched")
+)
+
+// Revision errors.
+const (
+	ErrRevisionNotFound = Error("revision not found")
+)
+
+// Pricing errors.
+const (
+	ErrInvalidPrice         = Error("price must not be negative")
+	ErrInvalidCurrency      = Error("invalid currency code")
+	ErrCurrencyMismatch     = Error("currencies do not match")
+	ErrAmountOverflow       = Error("amount out of range")
+	ErrProductPriceRequired = Error("product price required")
+)
+
+// Inventory errors.
+const (
+	ErrInsufficientStock   = Error("insufficient stock")
+	ErrReservationExceeded = Error("quantity exceeds reserved stock")
 )
 
 // User errors.
@@ -22,6 +72,56 @@ const (
 	ErrUserRequired   = Error("user required")
 )
 
+// Category errors.
+const (
+	ErrCategoryRequired       = Error("category required")
+	ErrCategoryNotFound       = Error("category not found")
+	ErrCategoryExists         = Error("category already exists")
+	ErrCategoryIDRequired     = Error("category id required")
+	ErrCategorySlugExists     = Error("category slug already exists")
+	ErrCategoryParentNotFound = Error("parent category not found")
+	ErrCategoryCycle          = Error("category cannot be its own ancestor")
+	ErrCategoryNotEmpty       = Error("category has subcategories or products")
+)
+
+// API key errors.
+const (
+	ErrAPIKeyRequired = Error("api key required")
+	ErrAPIKeyNotFound = Error("api key not found")
+	ErrAPIKeyExists   = Error("api key already exists")
+)
+
+// Cart errors.
+const (
+	ErrCartItemNotFound = Error("cart item not found")
+	ErrInvalidQuantity  = Error("quantity must be greater than zero")
+	ErrCartEmpty        = Error("cart is empty")
+)
+
+// Order errors.
+const (
+	ErrOrderNotFound   = Error("order not found")
+	ErrOrderIDRequired = Error("order id required")
+)
+
+// Webhook errors.
+const (
+	ErrWebhookRequired   = Error("webhook required")
+	ErrWebhookNotFound   = Error("webhook not found")
+	ErrWebhookExists     = Error("webhook already exists")
+	ErrInvalidWebhookURL = Error("webhook url must be absolute http or https")
+	ErrInvalidEventType  = Error("invalid event type")
+	ErrDeliveryNotFound  = Error("delivery not found")
+)
+
+// Transaction errors.
+const (
+	ErrTransactionRequired   = Error("transaction required")
+	ErrTransactionNotFound   = Error("transaction not found")
+	ErrTransactionExists     = Error("transaction already exists")
+	ErrTransactionIDRequired = Error("transaction id required")
+)
+
 // Error represents a fruit error.
 type Error string
 
@@ -1,35 +1,216 @@
 package fruit
 
-import "time"
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+)
 
 type ProductID string
 
+// Product represents an item for sale. Version is incremented on every
+// update, and updates must set it: they are rejected with ErrVersionRequired
+// if it is zero, and with ErrConflict unless it matches the stored version.
+// Imports are the exception, and overwrite whatever the stored version.
+// Deleted products keep their record, with DeletedAt set, until they are
+// purged.
 type Product struct {
-	ID          ProductID `json:"productID" storm:"id"`
-	Token       string    `json:"-"`
-	Name        string    `json:"name, omitempty"`
-	SKU         string    `json:"sku"`
-	Type        string    `json:"type"`
-	Color       string    `json:"color"`
-	Description string    `json:"description, omitempty"`
-	ModTime     time.Time `json:"modTime"`
+	ID          ProductID  `json:"productID" storm:"id"`
+	Token       string     `json:"-"`
+	Name        string     `json:"name"`
+	SKU         string     `json:"sku" storm:"index"`
+	Type        string     `json:"type" storm:"index"`
+	Color       string     `json:"color" storm:"index"`
+	Description string     `json:"description"`
+	Price       *Money     `json:"price,omitempty"`
+	CategoryID  CategoryID `json:"categoryID,omitempty" storm:"index"`
+	Version     int        `json:"version,omitempty"`
+	ModTime     time.Time  `json:"modTime"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
 }
 
 // Client creates a connection to the services.
 // TODO: Decide if we really need to use client and not
 // just standalone services.
+//
+// Each service method takes a context carrying the caller's deadline and
+// principal. Once the context is done, methods return its error and
+// nothing is saved.
 type Client interface {
 	ProductService() ProductService
 	UserService() UserService
+	TransactionService() TransactionService
 }
 
 // ProductService represents a service for managing products
 type ProductService interface {
-	Product(id ProductID) (*Product, error)
-	Products() ([]*Product, error)
-	CreateProduct(p *Product) error
-	UpdateProduct(id ProductID, p *Product) error
-	DeleteProduct(id ProductID, token string) error
+	Product(ctx context.Context, id ProductID) (*Product, error)
+
+	// Products returns a page of products and the cursor for the next page,
+	// which is blank on the last page.
+	Products(ctx context.Context, opt QueryOptions) ([]*Product, string, error)
+
+	// Search returns a page of products matching a text query, ordered by
+	// relevance.
+	Search(ctx context.Context, query string, opt QueryOptions) ([]*Product, string, error)
+
+	CreateProduct(ctx context.Context, p *Product) error
+	UpdateProduct(ctx context.Context, id ProductID, p *Product) error
+
+	// DeleteProduct moves a product to the trash. Deleted products are
+	// hidden, unless listed with IncludeDeleted, until they are restored.
+	// Their IDs can't be reused until they are purged.
+	DeleteProduct(ctx context.Context, id ProductID, token string) error
+
+	// RestoreProduct moves a product out of the trash. It is an admin
+	// operation, so no owner token is needed.
+	RestoreProduct(ctx context.Context, id ProductID) error
+
+	// PurgeProducts permanently removes products deleted before t and
+	// returns how many were removed.
+	PurgeProducts(ctx context.Context, before time.Time) (int, error)
+
+	// PatchProduct changes only the fields of a product named in mask. The
+	// token and version of p are checked as by UpdateProduct, and the
+	// updated product is copied back to p.
+	PatchProduct(ctx context.Context, id ProductID, p *Product, mask FieldMask) error
+
+	// Batch applies a series of creates, updates and deletes in order and
+	// reports the outcome of each. If atomic is set nothing is saved
+	// unless every operation succeeds.
+	Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error)
+}
+
+// Batch operations.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// Statuses reported for batch operations.
+const (
+	BatchCreated      = "created"
+	BatchUpdated      = "updated"
+	BatchDeleted      = "deleted"
+	BatchConflict     = "conflict"
+	BatchNotFound     = "notFound"
+	BatchUnauthorized = "unauthorized"
+	BatchInvalid      = "invalid"
+	BatchAborted      = "aborted"
+)
+
+// BatchOp represents one operation in a product batch. Creates use the ID
+// of Product, while updates and deletes use ID. Token is the owner token.
+type BatchOp struct {
+	Op      string    `json:"op"`
+	ID      ProductID `json:"productID,omitempty"`
+	Product *Product  `json:"product,omitempty"`
+	Token   string    `json:"token,omitempty"`
+}
+
+// NewBatchResult returns the result of the i-th operation of a batch, which
+// failed if err is not nil.
+func NewBatchResult(i int, op BatchOp, err error) BatchResult {
+	r := BatchResult{Index: i, ID: op.ID}
+	if op.Op == BatchCreate && op.Product != nil {
+		r.ID = op.Product.ID
+	}
+
+	switch err {
+	case nil:
+		switch op.Op {
+		case BatchCreate:
+			r.Status = BatchCreated
+		case BatchUpdate:
+			r.Status = BatchUpdated
+		case BatchDelete:
+			r.Status = BatchDeleted
+		}
+		return r
+	case ErrProductExists, ErrConflict:
+		r.Status = BatchConflict
+	case ErrProductNotFound:
+		r.Status = BatchNotFound
+	case ErrCategoryNotFound:
+		// The op names a missing category; the product itself exists.
+		r.Status = BatchInvalid
+	case ErrUnauthorized:
+		r.Status = BatchUnauthorized
+	case ErrBatchAborted:
+		r.Status = BatchAborted
+	default:
+		r.Status = BatchInvalid
+	}
+	r.Err = err.Error()
+	return r
+}
+
+// BatchResult reports the outcome of one batch operation. Err is set if
+// the operation failed.
+type BatchResult struct {
+	Index  int       `json:"index"`
+	ID     ProductID `json:"productID,omitempty"`
+	Status string    `json:"status"`
+	Err    string    `json:"err,omitempty"`
+}
+
+// Stock represents the inventory level of a product. Reserved units are
+// held for placed orders and can't be sold again.
+type Stock struct {
+	ProductID ProductID `json:"productID" storm:"id"`
+	OnHand    int       `json:"onHand"`
+	Reserved  int       `json:"reserved"`
+	ModTime   time.Time `json:"modTime"`
+}
+
+// Available returns the number of units that can still be reserved.
+func (s *Stock) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// InventoryService represents a service for tracking product stock.
+// Products without a stock record are untracked and never run out.
+type InventoryService interface {
+	Stock(id ProductID) (*Stock, error)
+
+	// AdjustStock adds delta units on hand. A negative delta removes units.
+	AdjustStock(id ProductID, delta int) error
+
+	// ReserveStock holds units for an order.
+	ReserveStock(id ProductID, quantity int) error
+
+	// ReleaseStock returns reserved units to available stock.
+	ReleaseStock(id ProductID, quantity int) error
+
+	// CommitStock removes reserved units once they have shipped.
+	CommitStock(id ProductID, quantity int) error
+}
+
+type CategoryID string
+
+// Category represents a node in the product category tree. A category
+// without a ParentID is a top-level category.
+type Category struct {
+	ID       CategoryID `json:"categoryID" storm:"id"`
+	Name     string     `json:"name"`
+	Slug     string     `json:"slug" storm:"unique"`
+	ParentID CategoryID `json:"parentID,omitempty" storm:"index"`
+	ModTime  time.Time  `json:"modTime"`
+}
+
+// CategoryService represents a service for managing categories.
+type CategoryService interface {
+	Category(id CategoryID) (*Category, error)
+	Categories() ([]*Category, error)
+	CreateCategory(c *Category) error
+	UpdateCategory(id CategoryID, c *Category) error
+	DeleteCategory(id CategoryID) error
+
+	// CategoryProducts returns the products in a category and all of its
+	// subcategories.
+	CategoryProducts(id CategoryID) ([]*Product, error)
 }
 
 type Address struct {
@@ -43,36 +224,372 @@ type Address struct {
 
 type UserID string
 
+// User represents a customer. Like products, users carry a version which
+// guards updates against lost writes.
 type User struct {
 	ID      UserID    `json:"userID" storm:"id"`
 	Name    string    `json:"name"`
 	Address *Address  `json:"address"`
 	CardID  string    `json:"card"`
+	Version int       `json:"version,omitempty"`
 	ModTime time.Time `json:"modTime"`
 }
 
 type UserService interface {
-	User(id UserID) (*User, error)
-	Users() ([]*User, error)
-	CreateUser(u *User) error
-	DeleteUser(id UserID) error
-	UpdateUser(id UserID, u *User) error
+	User(ctx context.Context, id UserID) (*User, error)
+	Users(ctx context.Context, opt QueryOptions) ([]*User, string, error)
+	CreateUser(ctx context.Context, u *User) error
+	DeleteUser(ctx context.Context, id UserID) error
+	UpdateUser(ctx context.Context, id UserID, u *User) error
+
+	// PatchUser changes only the fields of a user named in mask and copies
+	// the updated user back to u.
+	PatchUser(ctx context.Context, id UserID, u *User, mask FieldMask) error
+}
+
+// Principal represents an authenticated caller.
+type Principal struct {
+	// Hash of the API key the caller authenticated with.
+	Key string `json:"-"`
+
+	UserID UserID `json:"userID"`
+	Admin  bool   `json:"admin"`
+}
+
+// APIKey represents a key issued to a user for authenticating requests.
+// Only a hash of Key is stored, so the plain key is known just to the
+// caller of CreateAPIKey and keys read back hold the hash.
+type APIKey struct {
+	Key     string    `json:"key" storm:"id"`
+	UserID  UserID    `json:"userID" storm:"index"`
+	Admin   bool      `json:"admin"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Principal returns the caller identified by the key.
+func (k *APIKey) Principal() *Principal {
+	return &Principal{Key: k.Key, UserID: k.UserID, Admin: k.Admin}
+}
+
+// APIKeyService represents a service for managing API keys.
+type APIKeyService interface {
+	APIKey(key string) (*APIKey, error)
+	CreateAPIKey(k *APIKey) error
+	DeleteAPIKey(key string) error
+}
+
+// CartItem represents a quantity of a product in a cart.
+type CartItem struct {
+	ProductID ProductID `json:"productID"`
+	Quantity  int       `json:"quantity"`
+}
+
+// Cart represents the products a user intends to purchase. Each user has at
+// most one cart.
+type Cart struct {
+	UserID  UserID     `json:"userID" storm:"id"`
+	Items   []CartItem `json:"items"`
+	ModTime time.Time  `json:"modTime"`
+}
+
+// CartService represents a service for managing shopping carts.
+type CartService interface {
+	Cart(id UserID) (*Cart, error)
+	AddCartItem(id UserID, productID ProductID, quantity int) error
+	UpdateCartItem(id UserID, productID ProductID, quantity int) error
+	RemoveCartItem(id UserID, productID ProductID) error
+	ClearCart(id UserID) error
+}
+
+type OrderID string
+
+// OrderItem represents a purchased product. Product details are copied at
+// checkout so later product changes don't alter past orders.
+type OrderItem struct {
+	ProductID ProductID `json:"productID"`
+	Name      string    `json:"name"`
+	SKU       string    `json:"sku"`
+	Price     Money     `json:"price"`
+	Quantity  int       `json:"quantity"`
+}
+
+// Order represents a completed checkout.
+type Order struct {
+	ID      OrderID     `json:"orderID" storm:"id"`
+	UserID  UserID      `json:"userID" storm:"index"`
+	Items   []OrderItem `json:"items"`
+	Total   Money       `json:"total"`
+	ModTime time.Time   `json:"modTime"`
+}
+
+// OrderService represents a service for placing and retrieving orders.
+type OrderService interface {
+	Order(id OrderID) (*Order, error)
+	Orders(id UserID) ([]*Order, error)
+
+	// Checkout converts a user's cart into an order and empties the cart.
+	Checkout(id UserID) (*Order, error)
 }
 
 type TransactionID string
 
 type Transaction struct {
 	ID      TransactionID `json:"transactionID" storm:"id" validate:"nonzero"`
-	UserID  UserID        `json:"userID"`
+	UserID  UserID        `json:"userID" storm:"index"`
 	Count   int           `json:"count"`
 	Active  bool          `json:"active"`
 	ModTime time.Time     `json:"modTime"`
 }
 
+// TransactionService represents a service for managing transactions.
 type TransactionService interface {
-	Transaction(id TransactionID) (*Transaction, error)
-	Transactions(id UserID) ([]*Transaction, error)
-	CreateTransaction(t *Transaction) error
-	UpdateTransaction(id TransactionID, t *Transaction) error
-	DeleteTransaction(id TransactionID) error
+	Transaction(ctx context.Context, id TransactionID) (*Transaction, error)
+	Transactions(ctx context.Context, id UserID) ([]*Transaction, error)
+	CreateTransaction(ctx context.Context, t *Transaction) error
+	UpdateTransaction(ctx context.Context, id TransactionID, t *Transaction) error
+	DeleteTransaction(ctx context.Context, id TransactionID) error
+}
+
+// Actions reported for imported records.
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+)
+
+// ImportResult reports the outcome of importing one record. Err is set if
+// the record was rejected.
+type ImportResult struct {
+	Row    int    `json:"row"`
+	ID     string `json:"id,omitempty"`
+	Action string `json:"action,omitempty"`
+	Err    string `json:"err,omitempty"`
+}
+
+// ImportService represents a service for loading records in bulk. Records
+// are created, or updated if they already exist, and each call is applied
+// in one transaction. A rejected record doesn't stop the others.
+type ImportService interface {
+	// ImportProducts imports products, updating existing products the way
+	// UpdateProduct does. If dryRun is set nothing is saved.
+	ImportProducts(a []*Product, dryRun bool) ([]ImportResult, error)
+
+	// ImportUsers imports users, replacing existing users the way
+	// UpdateUser does. If dryRun is set nothing is saved.
+	ImportUsers(a []*User, dryRun bool) ([]ImportResult, error)
+}
+
+// BackupService represents a service for taking snapshots of the store.
+type BackupService interface {
+	// Backup writes a consistent snapshot of the store to w.
+	Backup(w io.Writer) error
+}
+
+// Event types recorded in the change feed.
+const (
+	EventProductCreated  = "product.created"
+	EventProductUpdated  = "product.updated"
+	EventProductDeleted  = "product.deleted"
+	EventProductRestored = "product.restored"
+	EventUserCreated     = "user.created"
+	EventUserUpdated     = "user.updated"
+	EventUserDeleted     = "user.deleted"
+	EventOrderCreated    = "order.created"
+)
+
+// EventTypes lists every event type.
+var EventTypes = []string{
+	EventProductCreated,
+	EventProductUpdated,
+	EventProductDeleted,
+	EventProductRestored,
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventOrderCreated,
+}
+
+// Event represents a change to a product, user or order. Events are
+// numbered by a sequence which increases with every change. Product, User
+// and Order hold the record as saved and are blank for deletes.
+type Event struct {
+	Seq       uint64    `json:"seq" storm:"id,increment"`
+	Type      string    `json:"type"`
+	ProductID ProductID `json:"productID,omitempty"`
+	UserID    UserID    `json:"userID,omitempty"`
+	OrderID   OrderID   `json:"orderID,omitempty"`
+	Product   *Product  `json:"product,omitempty"`
+	User      *User     `json:"user,omitempty"`
+	Order     *Order    `json:"order,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Redacted returns a copy of e to send outside the store, without the
+// user's card and address or the product's owner token.
+func (e *Event) Redacted() *Event {
+	other := *e
+	if e.User != nil {
+		u := *e.User
+		u.CardID, u.Address = "", nil
+		other.User = &u
+	}
+	if e.Product != nil {
+		p := *e.Product
+		p.Token = ""
+		other.Product = &p
+	}
+	return &other
+}
+
+// EventService represents a service for following the change feed.
+type EventService interface {
+	// Subscribe returns a subscription to events after the sequence number
+	// since. Past events are replayed before new ones are delivered.
+	Subscribe(since uint64) (Subscription, error)
+}
+
+// Subscription delivers events in sequence order.
+type Subscription interface {
+	// Events returns the channel events are delivered on. It is closed
+	// when the subscription ends.
+	Events() <-chan *Event
+
+	// Close ends the subscription. Returns the error which ended it early,
+	// if any.
+	Close() error
+}
+
+type WebhookID string
+
+// Webhook represents a subscription to events which are POSTed to URL.
+// Events limits the event types delivered, and all are delivered if it is
+// empty. Secret signs each delivery and is never encoded with the webhook;
+// the HTTP API reveals it once, when the webhook is created.
+type Webhook struct {
+	ID      WebhookID `json:"webhookID" storm:"id"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events,omitempty"`
+	Secret  string    `json:"-"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Validate returns an error if the URL isn't an absolute HTTP or HTTPS URL
+// or an event type is unknown.
+func (w *Webhook) Validate() error {
+	if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	for _, typ := range w.Events {
+		known := false
+		for _, other := range EventTypes {
+			known = known || typ == other
+		}
+		if !known {
+			return ErrInvalidEventType
+		}
+	}
+	return nil
+}
+
+// Matches returns true if events of type typ are delivered to the webhook.
+func (w *Webhook) Matches(typ string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, other := range w.Events {
+		if other == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type DeliveryID uint64
+
+// Delivery represents an event queued for a webhook and the outcome of its
+// attempts. Pending deliveries are retried at NextAttempt until they
+// succeed or run out of attempts.
+type Delivery struct {
+	ID           DeliveryID `json:"deliveryID" storm:"id,increment"`
+	WebhookID    WebhookID  `json:"webhookID" storm:"index"`
+	Event        *Event     `json:"event"`
+	Status       string     `json:"status" storm:"index"`
+	Attempts     int        `json:"attempts"`
+	ResponseCode int        `json:"responseCode,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	NextAttempt  time.Time  `json:"nextAttempt"`
+	ModTime      time.Time  `json:"modTime"`
+}
+
+// WebhookService represents a service for managing webhooks. Every event
+// is queued as a delivery to each matching webhook.
+type WebhookService interface {
+	Webhook(id WebhookID) (*Webhook, error)
+	Webhooks() ([]*Webhook, error)
+	CreateWebhook(w *Webhook) error
+	UpdateWebhook(id WebhookID, w *Webhook) error
+	DeleteWebhook(id WebhookID) error
+
+	// Deliveries returns the delivery log of a webhook, oldest first.
+	Deliveries(id WebhookID) ([]*Delivery, error)
+
+	// ReplayDelivery queues a delivery to be sent again.
+	ReplayDelivery(id DeliveryID) error
+
+	// ReplayFailed queues every failed delivery of a webhook to be sent
+	// again and returns how many were queued.
+	ReplayFailed(id WebhookID) (int, error)
+}
+
+// DeliveryService represents the queue read by the webhook delivery worker.
+type DeliveryService interface {
+	// DueDeliveries returns up to n pending deliveries due by t, oldest
+	// first.
+	DueDeliveries(t time.Time, n int) ([]*Delivery, error)
+
+	// UpdateDelivery saves the outcome of an attempt.
+	UpdateDelivery(d *Delivery) error
+}
+
+type RevisionID uint64
+
+// Revision represents a saved change to a product or user. Revisions are
+// never changed once recorded. Type is the event type of the change, Actor
+// is the user who made it, if known, and Product or User holds the record
+// as saved. Changes lists the fields which differ from the previous
+// revision of the record.
+type Revision struct {
+	ID        RevisionID    `json:"revisionID" storm:"id,increment"`
+	Type      string        `json:"type"`
+	ProductID ProductID     `json:"productID,omitempty" storm:"index"`
+	UserID    UserID        `json:"userID,omitempty" storm:"index"`
+	Actor     UserID        `json:"actor,omitempty"`
+	Changes   []FieldChange `json:"changes"`
+	Product   *Product      `json:"product,omitempty"`
+	User      *User         `json:"user,omitempty"`
+	Time      time.Time     `json:"time"`
+}
+
+// RevisionService represents a service for reading the revision history of
+// products and users.
+type RevisionService interface {
+	// ProductRevisions returns the revisions of a product, oldest first.
+	// History is kept after a product is purged.
+	ProductRevisions(ctx context.Context, id ProductID) ([]*Revision, error)
+
+	// UserRevisions returns the revisions of a user, oldest first.
+	UserRevisions(ctx context.Context, id UserID) ([]*Revision, error)
+
+	// RevertProduct sets the fields of a product back to those saved in one
+	// of its revisions, recording a new revision. It is an admin operation,
+	// so no owner token is needed. Version must match the stored version.
+	// Returns the updated product.
+	RevertProduct(ctx context.Context, id ProductID, rev RevisionID, version int) (*Product, error)
 }
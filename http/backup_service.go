@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+// BackupHandler serves snapshots of the store to admins.
+type BackupHandler struct {
+	*httprouter.Router
+
+	BackupService fruit.BackupService
+
+	Logger *log.Logger
+}
+
+// NewBackupHandler returns a new instance of BackupHandler.
+func NewBackupHandler() *BackupHandler {
+	h := &BackupHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.GET("/api/admin/backup", h.handleGetBackup)
+	return h
+}
+
+// handleGetBackup handles requests to stream a backup of the store.
+func (h *BackupHandler) handleGetBackup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Only admins may read the whole store.
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	sw := newStreamWriter(w, "application/octet-stream")
+	sw.Header().Set("Content-Disposition", `attachment; filename="fruit.db"`)
+	if err := h.BackupService.Backup(sw); err != nil && !sw.started {
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	} else if err != nil {
+		h.Logger.Printf("backup error: %s", err)
+	}
+}
+
+// BackupService represents an HTTP implementation of fruit.BackupService.
+type BackupService struct {
+	URL *url.URL
+	Key *string
+}
+
+// Backup streams a backup of the store to w.
+func (s *BackupService) Backup(w io.Writer) error {
+	u := *s.URL
+	u.Path = "/api/admin/backup"
+
+	// Execute request.
+	resp, err := doRequest(context.Background(), http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors are returned as JSON.
+	if resp.StatusCode != http.StatusOK {
+		var respBody errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+			return err
+		}
+		return fruit.Error(respBody.Err)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
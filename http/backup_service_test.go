@@ -0,0 +1,112 @@
+package http_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// BackupHandler represents a test wrapper for http.BackupHandler
+type BackupHandler struct {
+	*http.BackupHandler
+
+	BackupService mock.BackupService
+	LogOutput     bytes.Buffer
+}
+
+func NewBackupHandler() *BackupHandler {
+	h := &BackupHandler{BackupHandler: http.NewBackupHandler()}
+	h.BackupHandler.BackupService = &h.BackupService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+func TestBackupService_Backup(t *testing.T) {
+	t.Run("OK", testBackupService_Backup)
+	t.Run("ErrUnauthorized", testBackupService_Backup_ErrUnauthorized)
+	t.Run("ErrForbidden", testBackupService_Backup_ErrForbidden)
+	t.Run("ErrInternal", testBackupService_Backup_ErrInternal)
+}
+
+// mockAPIKeys makes "ADMIN" an admin key and "USER" a regular key.
+func mockAPIKeys(s *Server) {
+	s.Handler.APIKeyService.APIKeyFn = func(key string) (*fruit.APIKey, error) {
+		switch key {
+		case "ADMIN":
+			return &fruit.APIKey{Key: key, UserID: "A", Admin: true}, nil
+		case "USER":
+			return &fruit.APIKey{Key: key, UserID: "U"}, nil
+		}
+		return nil, fruit.ErrAPIKeyNotFound
+	}
+}
+
+func testBackupService_Backup(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.BackupHandler.BackupService.BackupFn = func(w io.Writer) error {
+		_, err := w.Write([]byte("SNAPSHOT"))
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := c.BackupService().Backup(&buf); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "SNAPSHOT" {
+		t.Fatalf("unexpected backup: %q", buf.String())
+	}
+}
+
+func testBackupService_Backup_ErrUnauthorized(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	var buf bytes.Buffer
+	if err := c.BackupService().Backup(&buf); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.BackupHandler.BackupService.BackupInvoked {
+		t.Fatal("unexpected Backup() invocation")
+	}
+}
+
+func testBackupService_Backup_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	var buf bytes.Buffer
+	if err := c.BackupService().Backup(&buf); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.BackupHandler.BackupService.BackupInvoked {
+		t.Fatal("unexpected Backup() invocation")
+	}
+}
+
+func testBackupService_Backup_ErrInternal(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.BackupHandler.BackupService.BackupFn = func(w io.Writer) error {
+		return errors.New("marker")
+	}
+
+	var buf bytes.Buffer
+	if err := c.BackupService().Backup(&buf); err != fruit.ErrInternal {
+		t.Fatal(err)
+	} else if !bytes.Contains(s.Handler.BackupHandler.LogOutput.Bytes(), []byte("marker")) {
+		t.Fatal("expected error to be logged")
+	}
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+type CartHandler struct {
+	*httprouter.Router
+
+	CartService fruit.CartService
+
+	Logger *log.Logger
+}
+
+// NewCartHandler returns a new instance of CartHandler.
+func NewCartHandler() *CartHandler {
+	h := &CartHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.GET("/api/carts/:id", h.handleGetCart)
+	h.DELETE("/api/carts/:id", h.handleDeleteCart)
+
+	h.POST("/api/carts/:id/items", h.handlePostCartItem)
+	h.PUT("/api/carts/:id/items/:productID", h.handlePutCartItem)
+	h.DELETE("/api/carts/:id/items/:productID", h.handleDeleteCartItem)
+	return h
+}
+
+// handleGetCart handles requests to fetch a user's cart.
+func (h *CartHandler) handleGetCart(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+	h.writeCart(w, id)
+}
+
+// handleDeleteCart handles requests to clear a user's cart.
+func (h *CartHandler) handleDeleteCart(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+	h.writeResult(w, id, h.CartService.ClearCart(id))
+}
+
+// handlePostCartItem handles requests to add a product to a user's cart.
+func (h *CartHandler) handlePostCartItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+
+	// Decode request.
+	var req postCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	h.writeResult(w, id, h.CartService.AddCartItem(id, req.ProductID, req.Quantity))
+}
+
+type postCartItemRequest struct {
+	ProductID fruit.ProductID `json:"productID"`
+	Quantity  int             `json:"quantity"`
+}
+
+// handlePutCartItem handles requests to change the quantity of a cart item.
+func (h *CartHandler) handlePutCartItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+
+	// Decode request.
+	var req putCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	productID := fruit.ProductID(ps.ByName("productID"))
+	h.writeResult(w, id, h.CartService.UpdateCartItem(id, productID, req.Quantity))
+}
+
+type putCartItemRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// handleDeleteCartItem handles requests to remove a product from a cart.
+func (h *CartHandler) handleDeleteCartItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+
+	productID := fruit.ProductID(ps.ByName("productID"))
+	h.writeResult(w, id, h.CartService.RemoveCartItem(id, productID))
+}
+
+// writeResult writes the error from a cart mutation, or the updated cart.
+func (h *CartHandler) writeResult(w http.ResponseWriter, id fruit.UserID, err error) {
+	switch err {
+	case nil:
+		h.writeCart(w, id)
+	case fruit.ErrUserIDRequired, fruit.ErrProductIDRequired, fruit.ErrInvalidQuantity:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrUserNotFound, fruit.ErrProductNotFound, fruit.ErrCartItemNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+// writeCart writes the current cart for a user.
+func (h *CartHandler) writeCart(w http.ResponseWriter, id fruit.UserID) {
+	switch c, err := h.CartService.Cart(id); err {
+	case nil:
+		encodeJSON(w, &cartResponse{Cart: c}, h.Logger)
+	case fruit.ErrUserIDRequired:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type cartResponse struct {
+	Cart *fruit.Cart `json:"cart,omitempty"`
+	Err  string      `json:"err,omitempty"`
+}
+
+// CartService represents an HTTP implementation of fruit.CartService.
+type CartService struct {
+	URL *url.URL
+	Key *string
+}
+
+func (s *CartService) Cart(id fruit.UserID) (*fruit.Cart, error) {
+	return s.do(http.MethodGet, cartPath(id), nil)
+}
+
+func (s *CartService) AddCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	_, err := s.do(http.MethodPost, cartPath(id)+"/items", postCartItemRequest{ProductID: productID, Quantity: quantity})
+	return err
+}
+
+func (s *CartService) UpdateCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	_, err := s.do(http.MethodPut, cartItemPath(id, productID), putCartItemRequest{Quantity: quantity})
+	return err
+}
+
+func (s *CartService) RemoveCartItem(id fruit.UserID, productID fruit.ProductID) error {
+	_, err := s.do(http.MethodDelete, cartItemPath(id, productID), nil)
+	return err
+}
+
+func (s *CartService) ClearCart(id fruit.UserID) error {
+	_, err := s.do(http.MethodDelete, cartPath(id), nil)
+	return err
+}
+
+// do executes a cart request and returns the cart from the response.
+func (s *CartService) do(method, path string, body interface{}) (*fruit.Cart, error) {
+	u := *s.URL
+	u.Path = path
+
+	var reqBody []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+	}
+
+	// Execute request.
+	resp, err := doRequest(context.Background(), method, u, reqBody, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody cartResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Cart, nil
+}
+
+func cartPath(id fruit.UserID) string {
+	return "/api/carts/" + string(id)
+}
+
+func cartItemPath(id fruit.UserID, productID fruit.ProductID) string {
+	return cartPath(id) + "/items/" + string(productID)
+}
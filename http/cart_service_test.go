@@ -0,0 +1,292 @@
+package http_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// CartHandler represents a test wrapper for http.CartHandler
+type CartHandler struct {
+	*http.CartHandler
+
+	CartService mock.CartService
+	LogOutput   bytes.Buffer
+}
+
+func NewCartHandler() *CartHandler {
+	h := &CartHandler{CartHandler: http.NewCartHandler()}
+	h.CartHandler.CartService = &h.CartService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+func TestCartService_Cart(t *testing.T) {
+	t.Run("OK", testCartService_Cart)
+	t.Run("ErrInternal", testCartService_Cart_ErrInternal)
+	t.Run("ErrUnauthorized", testCartService_Cart_ErrUnauthorized)
+	t.Run("ErrForbidden", testCartService_Cart_ErrForbidden)
+	t.Run("Admin", testCartService_Cart_Admin)
+}
+
+func testCartService_Cart(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.CartFn = func(id fruit.UserID) (*fruit.Cart, error) {
+		if id != "U" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return &fruit.Cart{UserID: "U", Items: []fruit.CartItem{{ProductID: "P", Quantity: 2}}}, nil
+	}
+
+	// Retrieve cart.
+	if cart, err := c.CartService().Cart("U"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart, &fruit.Cart{UserID: "U", Items: []fruit.CartItem{{ProductID: "P", Quantity: 2}}}) {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+}
+
+func testCartService_Cart_ErrInternal(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.CartFn = func(id fruit.UserID) (*fruit.Cart, error) {
+		return nil, errors.New("marker")
+	}
+
+	// Retrieve cart.
+	if _, err := c.CartService().Cart("U"); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+func testCartService_Cart_ErrUnauthorized(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	if _, err := c.CartService().Cart("U"); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.CartHandler.CartService.CartInvoked {
+		t.Fatal("expected Cart() not to be invoked")
+	}
+}
+
+func testCartService_Cart_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.CartService().Cart("OTHER"); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.CartHandler.CartService.CartInvoked {
+		t.Fatal("expected Cart() not to be invoked")
+	}
+}
+
+func testCartService_Cart_Admin(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.CartFn = func(id fruit.UserID) (*fruit.Cart, error) {
+		return &fruit.Cart{UserID: id}, nil
+	}
+
+	// Admins may read any user's cart.
+	if cart, err := c.CartService().Cart("OTHER"); err != nil {
+		t.Fatal(err)
+	} else if cart.UserID != "OTHER" {
+		t.Fatalf("unexpected user id: %s", cart.UserID)
+	}
+}
+
+func TestCartService_AddCartItem(t *testing.T) {
+	t.Run("OK", testCartService_AddCartItem)
+	t.Run("ErrProductNotFound", testCartService_AddCartItem_ErrProductNotFound)
+	t.Run("ErrInvalidQuantity", testCartService_AddCartItem_ErrInvalidQuantity)
+	t.Run("ErrForbidden", testCartService_AddCartItem_ErrForbidden)
+}
+
+func testCartService_AddCartItem(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.AddCartItemFn = func(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+		if id != "U" || productID != "P" || quantity != 3 {
+			t.Fatalf("unexpected args: %s %s %d", id, productID, quantity)
+		}
+		return nil
+	}
+	s.Handler.CartHandler.CartService.CartFn = func(id fruit.UserID) (*fruit.Cart, error) {
+		return &fruit.Cart{UserID: id}, nil
+	}
+
+	// Add item.
+	if err := c.CartService().AddCartItem("U", "P", 3); err != nil {
+		t.Fatal(err)
+	} else if !s.Handler.CartHandler.CartService.AddCartItemInvoked {
+		t.Fatal("expected AddCartItem() to be invoked")
+	}
+}
+
+func testCartService_AddCartItem_ErrProductNotFound(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.AddCartItemFn = func(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+		return fruit.ErrProductNotFound
+	}
+
+	// Add item.
+	if err := c.CartService().AddCartItem("U", "XXX", 1); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testCartService_AddCartItem_ErrInvalidQuantity(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.AddCartItemFn = func(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+		return fruit.ErrInvalidQuantity
+	}
+
+	// Add item.
+	if err := c.CartService().AddCartItem("U", "P", 0); err != fruit.ErrInvalidQuantity {
+		t.Fatal(err)
+	}
+}
+
+func testCartService_AddCartItem_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if err := c.CartService().AddCartItem("OTHER", "P", 1); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.CartHandler.CartService.AddCartItemInvoked {
+		t.Fatal("expected AddCartItem() not to be invoked")
+	}
+}
+
+func TestCartService_UpdateCartItem(t *testing.T) {
+	t.Run("OK", testCartService_UpdateCartItem)
+	t.Run("ErrCartItemNotFound", testCartService_UpdateCartItem_ErrCartItemNotFound)
+}
+
+func testCartService_UpdateCartItem(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.UpdateCartItemFn = func(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+		if id != "U" || productID != "P" || quantity != 5 {
+			t.Fatalf("unexpected args: %s %s %d", id, productID, quantity)
+		}
+		return nil
+	}
+	s.Handler.CartHandler.CartService.CartFn = func(id fruit.UserID) (*fruit.Cart, error) {
+		return &fruit.Cart{UserID: id}, nil
+	}
+
+	// Update item.
+	if err := c.CartService().UpdateCartItem("U", "P", 5); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testCartService_UpdateCartItem_ErrCartItemNotFound(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.UpdateCartItemFn = func(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+		return fruit.ErrCartItemNotFound
+	}
+
+	// Update item.
+	if err := c.CartService().UpdateCartItem("U", "XXX", 1); err != fruit.ErrCartItemNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestCartService_RemoveCartItem(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.RemoveCartItemFn = func(id fruit.UserID, productID fruit.ProductID) error {
+		if id != "U" || productID != "P" {
+			t.Fatalf("unexpected args: %s %s", id, productID)
+		}
+		return nil
+	}
+	s.Handler.CartHandler.CartService.CartFn = func(id fruit.UserID) (*fruit.Cart, error) {
+		return &fruit.Cart{UserID: id}, nil
+	}
+
+	// Remove item.
+	if err := c.CartService().RemoveCartItem("U", "P"); err != nil {
+		t.Fatal(err)
+	} else if !s.Handler.CartHandler.CartService.RemoveCartItemInvoked {
+		t.Fatal("expected RemoveCartItem() to be invoked")
+	}
+}
+
+func TestCartService_ClearCart(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.CartHandler.CartService.ClearCartFn = func(id fruit.UserID) error {
+		if id != "U" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return nil
+	}
+	s.Handler.CartHandler.CartService.CartFn = func(id fruit.UserID) (*fruit.Cart, error) {
+		return &fruit.Cart{UserID: id}, nil
+	}
+
+	// Clear cart.
+	if err := c.CartService().ClearCart("U"); err != nil {
+		t.Fatal(err)
+	} else if !s.Handler.CartHandler.CartService.ClearCartInvoked {
+		t.Fatal("expected ClearCart() to be invoked")
+	}
+}
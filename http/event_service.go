@@ -0,0 +1,227 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+// EventHandler streams the change feed to admins as Server-Sent Events.
+type EventHandler struct {
+	*httprouter.Router
+
+	EventService fruit.EventService
+
+	Logger *log.Logger
+}
+
+// NewEventHandler returns a new instance of EventHandler.
+func NewEventHandler() *EventHandler {
+	h := &EventHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.GET("/api/events", h.handleGetEvents)
+	return h
+}
+
+// handleGetEvents handles requests to follow the change feed. Events after
+// the "since" parameter are streamed until the client disconnects.
+// Reconnecting clients resume from their Last-Event-ID header.
+func (h *EventHandler) handleGetEvents(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Only admins may read changes to every record.
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	since, err := parseSince(r)
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		Error(w, fmt.Errorf("streaming unsupported"), http.StatusInternalServerError, h.Logger)
+		return
+	}
+
+	sub, err := h.EventService.Subscribe(since)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				if err := sub.Close(); err != nil {
+					h.Logger.Printf("event stream error: %s", err)
+				}
+				return
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				h.Logger.Printf("event stream error: %s", err)
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// parseSince returns the sequence number to stream events after. The
+// Last-Event-ID header wins over "since" because a reconnecting EventSource
+// repeats the original URL.
+func parseSince(r *http.Request) (uint64, error) {
+	s := r.Header.Get("Last-Event-ID")
+	if s == "" {
+		s = r.URL.Query().Get("since")
+	}
+	if s == "" {
+		return 0, nil
+	}
+
+	since, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidSequence
+	}
+	return since, nil
+}
+
+// EventService represents an HTTP implementation of fruit.EventService.
+type EventService struct {
+	URL *url.URL
+	Key *string
+}
+
+// Subscribe opens a stream of events after the sequence number since.
+func (s *EventService) Subscribe(since uint64) (fruit.Subscription, error) {
+	u := *s.URL
+	u.Path = "/api/events"
+	u.RawQuery = url.Values{"since": {strconv.FormatUint(since, 10)}}.Encode()
+
+	// The request is cancelled when the subscription is closed.
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := newRequest(ctx, http.MethodGet, u, nil, "", s.Key)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// Execute request.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Errors are returned as JSON.
+	if resp.StatusCode != http.StatusOK {
+		defer cancel()
+		defer resp.Body.Close()
+
+		var respBody errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+			return nil, err
+		}
+		return nil, fruit.Error(respBody.Err)
+	}
+
+	sub := &subscription{
+		c:      make(chan *fruit.Event),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go sub.run(resp.Body)
+	return sub, nil
+}
+
+// subscription reads events from a Server-Sent Events stream.
+type subscription struct {
+	c      chan *fruit.Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// run delivers events until the stream ends or the subscription is closed.
+// Only data fields are read as each event's JSON holds its ID and type.
+func (s *subscription) run(body io.ReadCloser) {
+	defer close(s.done)
+	defer close(s.c)
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, maxEventSize)
+
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")...)
+			continue
+		} else if line != "" || data == nil {
+			continue
+		}
+
+		// A blank line dispatches the event.
+		var e fruit.Event
+		if err := json.Unmarshal(data, &e); err != nil {
+			s.err = err
+			return
+		}
+		data = nil
+
+		select {
+		case s.c <- &e:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+
+	// Reads fail once the subscription is closed, which isn't an error.
+	if err := scanner.Err(); err != nil && s.ctx.Err() == nil {
+		s.err = err
+	}
+}
+
+// maxEventSize is the largest event line the client accepts.
+const maxEventSize = 1 << 20
+
+// Events returns the channel events are delivered on.
+func (s *subscription) Events() <-chan *fruit.Event { return s.c }
+
+// Close ends the subscription and returns the error which ended it early,
+// if any.
+func (s *subscription) Close() error {
+	s.cancel()
+	<-s.done
+	return s.err
+}
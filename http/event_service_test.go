@@ -0,0 +1,194 @@
+package http_test
+
+import (
+	"bytes"
+	"log"
+	nethttp "net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// EventHandler represents a test wrapper for http.EventHandler
+type EventHandler struct {
+	*http.EventHandler
+
+	EventService mock.EventService
+	LogOutput    bytes.Buffer
+}
+
+func NewEventHandler() *EventHandler {
+	h := &EventHandler{EventHandler: http.NewEventHandler()}
+	h.EventHandler.EventService = &h.EventService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+// mockSubscription returns a subscription delivering events from ch.
+func mockSubscription(ch chan *fruit.Event) *mock.Subscription {
+	return &mock.Subscription{
+		EventsFn: func() <-chan *fruit.Event { return ch },
+		CloseFn:  func() error { return nil },
+	}
+}
+
+func TestEventService_Subscribe(t *testing.T) {
+	t.Run("OK", testEventService_Subscribe)
+	t.Run("Close", testEventService_Subscribe_Close)
+	t.Run("Reconnect", testEventService_Subscribe_Reconnect)
+	t.Run("ErrUnauthorized", testEventService_Subscribe_ErrUnauthorized)
+	t.Run("ErrForbidden", testEventService_Subscribe_ErrForbidden)
+	t.Run("ErrInvalidSequence", testEventService_Subscribe_ErrInvalidSequence)
+}
+
+func testEventService_Subscribe(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	events := []*fruit.Event{
+		{Seq: 3, Type: fruit.EventProductCreated, ProductID: "A", Product: &fruit.Product{ID: "A", Name: "Apple"}, Time: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Seq: 4, Type: fruit.EventUserDeleted, UserID: "U", Time: time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Mock service to send two events and end the stream.
+	s.Handler.EventHandler.EventService.SubscribeFn = func(since uint64) (fruit.Subscription, error) {
+		if since != 2 {
+			t.Fatalf("unexpected since: %d", since)
+		}
+		ch := make(chan *fruit.Event, len(events))
+		for _, e := range events {
+			ch <- e
+		}
+		close(ch)
+		return mockSubscription(ch), nil
+	}
+
+	sub, err := c.EventService().Subscribe(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var other []*fruit.Event
+	for e := range sub.Events() {
+		other = append(other, e)
+	}
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, events) {
+		t.Fatalf("unexpected events: %+v", other)
+	}
+}
+
+func testEventService_Subscribe_Close(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service to send one event and keep the stream open.
+	s.Handler.EventHandler.EventService.SubscribeFn = func(since uint64) (fruit.Subscription, error) {
+		ch := make(chan *fruit.Event, 1)
+		ch <- &fruit.Event{Seq: 1, Type: fruit.EventProductDeleted, ProductID: "A"}
+		return mockSubscription(ch), nil
+	}
+
+	sub, err := c.EventService().Subscribe(0)
+	if err != nil {
+		t.Fatal(err)
+	} else if e := <-sub.Events(); e.Seq != 1 {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	// Closing the client side ends the stream.
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := <-sub.Events(); ok {
+		t.Fatal("expected closed channel")
+	}
+}
+
+func testEventService_Subscribe_Reconnect(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	// Mock service to end the stream immediately.
+	s.Handler.EventHandler.EventService.SubscribeFn = func(since uint64) (fruit.Subscription, error) {
+		if since != 5 {
+			t.Fatalf("unexpected since: %d", since)
+		}
+		ch := make(chan *fruit.Event)
+		close(ch)
+		return mockSubscription(ch), nil
+	}
+
+	// A reconnecting EventSource repeats the original URL and adds the last
+	// event id it saw.
+	u := c.URL
+	u.Path, u.RawQuery = "/api/events", "since=1"
+	req, err := nethttp.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer ADMIN")
+	req.Header.Set("Last-Event-ID", "5")
+
+	if resp, err := nethttp.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != nethttp.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if !s.Handler.EventHandler.EventService.SubscribeInvoked {
+		t.Fatal("expected Subscribe() to be invoked")
+	}
+}
+
+func testEventService_Subscribe_ErrUnauthorized(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	if _, err := c.EventService().Subscribe(0); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.EventHandler.EventService.SubscribeInvoked {
+		t.Fatal("unexpected Subscribe() invocation")
+	}
+}
+
+func testEventService_Subscribe_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.EventService().Subscribe(0); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.EventHandler.EventService.SubscribeInvoked {
+		t.Fatal("unexpected Subscribe() invocation")
+	}
+}
+
+func testEventService_Subscribe_ErrInvalidSequence(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	// The client always sends a valid sequence, so build the request by hand.
+	u := c.URL
+	u.Path, u.RawQuery = "/api/events", "since=-1"
+	req, err := nethttp.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer ADMIN")
+
+	if resp, err := nethttp.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != nethttp.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+}
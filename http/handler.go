@@ -1,30 +1,398 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/bulk"
 )
 
-const ErrInvalidJSON = fruit.Error("invalid json")
+// HTTP errors.
+const (
+	ErrInvalidJSON   = fruit.Error("invalid json")
+	ErrBatchTooLarge = fruit.Error("batch too large")
+
+	ErrInvalidSequence = fruit.Error("invalid sequence number")
+
+	ErrInvalidETag = fruit.Error("invalid etag")
+
+	ErrUnsupportedMediaType = fruit.Error("unsupported media type")
+
+	ErrInvalidTime = fruit.Error("invalid time")
+)
 
 // Handler is a collection of all the service handlers.
 
 type Handler struct {
-	ProductHandler *ProductHandler
+	ProductHandler     *ProductHandler
+	UserHandler        *UserHandler
+	TransactionHandler *TransactionHandler
+	CartHandler        *CartHandler
+	OrderHandler       *OrderHandler
+	BackupHandler      *BackupHandler
+	EventHandler       *EventHandler
+	WebhookHandler     *WebhookHandler
+	RevisionHandler    *RevisionHandler
+
+	// Resolves bearer tokens to principals. Authentication is disabled
+	// when nil.
+	APIKeyService fruit.APIKeyService
+
+	Logger *log.Logger
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if strings.HasPrefix(r.URL.Path, "/api/products") {
+	// Identify the caller and attach them to the request context.
+	if h.APIKeyService != nil {
+		p, err := h.authenticate(r)
+		if err != nil {
+			Error(w, err, http.StatusUnauthorized, h.logger())
+			return
+		} else if p != nil {
+			r = r.WithContext(fruit.NewPrincipalContext(r.Context(), p))
+		}
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/api/products") && (strings.HasSuffix(r.URL.Path, "/history") || strings.HasSuffix(r.URL.Path, "/revert")) {
+		// A product's history is served by the revision handler.
+		h.RevisionHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/products") {
+		h.ProductHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/transactions") {
+		h.TransactionHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/users") && strings.HasSuffix(r.URL.Path, "/transactions") {
+		// A user's transactions are served by the transaction handler.
+		h.TransactionHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/checkout") || strings.HasPrefix(r.URL.Path, "/api/orders") {
+		h.OrderHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/users") && strings.HasSuffix(r.URL.Path, "/orders") {
+		// A user's orders are served by the order handler.
+		h.OrderHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/users") && strings.HasSuffix(r.URL.Path, "/history") {
+		// A user's history is served by the revision handler.
+		h.RevisionHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/carts") {
+		h.CartHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/users") {
+		h.UserHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/admin/backup") {
+		h.BackupHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/events") {
+		h.EventHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/admin/products") {
 		h.ProductHandler.ServeHTTP(w, r)
+	} else if strings.HasPrefix(r.URL.Path, "/api/admin/webhooks") || strings.HasPrefix(r.URL.Path, "/api/admin/deliveries") {
+		h.WebhookHandler.ServeHTTP(w, r)
 	} else {
 		http.NotFound(w, r)
 	}
 }
 
+// authenticate resolves the bearer token in the Authorization header to a
+// principal. Returns nil for anonymous requests.
+func (h *Handler) authenticate(r *http.Request) (*fruit.Principal, error) {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return nil, nil
+	}
+
+	const prefix = "Bearer "
+	if !strings.HasPrefix(auth, prefix) {
+		return nil, fruit.ErrUnauthorized
+	}
+
+	switch k, err := h.APIKeyService.APIKey(strings.TrimPrefix(auth, prefix)); err {
+	case nil:
+		return k.Principal(), nil
+	case fruit.ErrAPIKeyRequired, fruit.ErrAPIKeyNotFound:
+		return nil, fruit.ErrUnauthorized
+	default:
+		return nil, err
+	}
+}
+
+func (h *Handler) logger() *log.Logger {
+	if h.Logger == nil {
+		return log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return h.Logger
+}
+
+// requestToken returns token if set, otherwise the hash of the API key the
+// caller authenticated with. The plain key is never used as an owner token.
+func requestToken(r *http.Request, token string) string {
+	if token != "" {
+		return token
+	} else if p := fruit.PrincipalFromContext(r.Context()); p != nil {
+		return p.Key
+	}
+	return ""
+}
+
+// authorizeAdmin reports an error and returns false unless the caller is an
+// admin.
+func authorizeAdmin(w http.ResponseWriter, r *http.Request, logger *log.Logger) bool {
+	if p := fruit.PrincipalFromContext(r.Context()); p == nil {
+		Error(w, fruit.ErrUnauthorized, http.StatusUnauthorized, logger)
+		return false
+	} else if !p.Admin {
+		Error(w, fruit.ErrForbidden, http.StatusForbidden, logger)
+		return false
+	}
+	return true
+}
+
+// authorizeUser reports an error and returns false unless the caller is the
+// user id or an admin. Users, carts and orders are private to their user.
+func authorizeUser(w http.ResponseWriter, r *http.Request, id fruit.UserID, logger *log.Logger) bool {
+	if p := fruit.PrincipalFromContext(r.Context()); p == nil {
+		Error(w, fruit.ErrUnauthorized, http.StatusUnauthorized, logger)
+		return false
+	} else if !p.Admin && p.UserID != id {
+		Error(w, fruit.ErrForbidden, http.StatusForbidden, logger)
+		return false
+	}
+	return true
+}
+
+// formatETag returns the entity tag of a record at version.
+func formatETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseETag returns the version in an entity tag. Weak tags aren't
+// accepted as versions must match exactly.
+func parseETag(s string) (int, error) {
+	unquoted, err := strconv.Unquote(s)
+	if err != nil || !strings.HasPrefix(s, `"`) {
+		return 0, ErrInvalidETag
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, ErrInvalidETag
+	}
+	return version, nil
+}
+
+// parseIfMatch returns the version in the If-Match header. Returns zero if
+// the header is absent or "*".
+func parseIfMatch(r *http.Request) (int, error) {
+	s := r.Header.Get("If-Match")
+	if s == "" || s == "*" {
+		return 0, nil
+	}
+	return parseETag(s)
+}
+
+// requireIfMatch returns the version in the If-Match header, which updates
+// must send. Returns ErrVersionRequired if the header is absent or "*".
+func requireIfMatch(r *http.Request) (int, error) {
+	version, err := parseIfMatch(r)
+	if err != nil {
+		return 0, err
+	} else if version == 0 {
+		return 0, fruit.ErrVersionRequired
+	}
+	return version, nil
+}
+
+// MergePatchContentType is the media type of JSON Merge Patch documents.
+const MergePatchContentType = "application/merge-patch+json"
+
+// decodeMergePatch decodes a JSON Merge Patch (RFC 7396) document into v
+// and returns the fields it names. Fields removed with null are left at
+// their zero value in v. Objects are merged, so their members are named
+// individually.
+func decodeMergePatch(r *http.Request, v interface{}) (fruit.FieldMask, error) {
+	if typ, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); typ != MergePatchContentType && typ != "application/json" {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil || doc == nil {
+		return nil, ErrInvalidJSON
+	} else if err := json.Unmarshal(data, v); err != nil {
+		return nil, ErrInvalidJSON
+	}
+
+	mask := mergePatchFields("", doc)
+	sort.Strings(mask)
+	return mask, nil
+}
+
+// mergePatchFields returns the fields named by the members of doc.
+func mergePatchFields(prefix string, doc map[string]json.RawMessage) fruit.FieldMask {
+	var mask fruit.FieldMask
+	for name, value := range doc {
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(value, &nested); err == nil && nested != nil {
+			mask = append(mask, mergePatchFields(prefix+name+".", nested)...)
+			continue
+		}
+		mask = append(mask, prefix+name)
+	}
+	return mask
+}
+
+// encodeMergePatch returns a JSON Merge Patch document which sets the
+// fields of v named in mask. Fields v leaves out are removed with null.
+func encodeMergePatch(v interface{}, mask fruit.FieldMask) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are kept as written so large amounts don't lose precision.
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]interface{})
+	for _, field := range mask {
+		src, dst := doc, patch
+		names := strings.Split(field, ".")
+		for _, name := range names[:len(names)-1] {
+			src, _ = src[name].(map[string]interface{})
+
+			next, ok := dst[name].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				dst[name] = next
+			}
+			dst = next
+		}
+
+		name := names[len(names)-1]
+		dst[name] = src[name]
+	}
+	return json.Marshal(patch)
+}
+
+// parseQueryOptions reads list options from URL query parameters.
+func parseQueryOptions(v url.Values) (fruit.QueryOptions, error) {
+	opt := fruit.QueryOptions{
+		Cursor: v.Get("cursor"),
+		Sort:   v.Get("sort"),
+		Desc:   v.Get("desc") == "true",
+		Type:   v.Get("type"),
+		Color:  v.Get("color"),
+		SKU:    v.Get("sku"),
+
+		IncludeDeleted: v.Get("includeDeleted") == "true",
+	}
+
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return opt, fruit.ErrInvalidLimit
+		}
+		opt.Limit = n
+	}
+	return opt, nil
+}
+
+// encodeQueryOptions returns the URL query parameters for opt.
+func encodeQueryOptions(opt fruit.QueryOptions) url.Values {
+	v := url.Values{}
+	if opt.Limit != 0 {
+		v.Set("limit", strconv.Itoa(opt.Limit))
+	}
+	if opt.Desc {
+		v.Set("desc", "true")
+	}
+	if opt.IncludeDeleted {
+		v.Set("includeDeleted", "true")
+	}
+	for key, value := range map[string]string{
+		"cursor": opt.Cursor,
+		"sort":   opt.Sort,
+		"type":   opt.Type,
+		"color":  opt.Color,
+		"sku":    opt.SKU,
+	} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	return v
+}
+
+// parseBulkOptions reads import and export options from URL query
+// parameters. The format defaults to NDJSON.
+func parseBulkOptions(v url.Values) (bulk.Options, error) {
+	opt := bulk.Options{Format: bulk.NDJSON, DryRun: v.Get("dryRun") == "true"}
+
+	if s := v.Get("format"); s != "" {
+		format, err := bulk.ParseFormat(s)
+		if err != nil {
+			return opt, err
+		}
+		opt.Format = format
+	}
+
+	mapping, err := bulk.ParseMapping(v.Get("map"))
+	if err != nil {
+		return opt, err
+	}
+	opt.Mapping = mapping
+
+	if s := v.Get("chunkSize"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return opt, fruit.ErrInvalidLimit
+		}
+		opt.ChunkSize = n
+	}
+	return opt, nil
+}
+
+// encodeBulkOptions returns the URL query parameters for opt. The token is
+// left to the caller.
+func encodeBulkOptions(opt bulk.Options) url.Values {
+	v := url.Values{}
+	if opt.Format != "" {
+		v.Set("format", string(opt.Format))
+	}
+	if len(opt.Mapping) > 0 {
+		v.Set("map", bulk.FormatMapping(opt.Mapping))
+	}
+	if opt.DryRun {
+		v.Set("dryRun", "true")
+	}
+	if opt.ChunkSize != 0 {
+		v.Set("chunkSize", strconv.Itoa(opt.ChunkSize))
+	}
+	return v
+}
+
+// bulkContentType returns the media type of files in format.
+func bulkContentType(format bulk.Format) string {
+	if format == bulk.CSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
 func Error(w http.ResponseWriter, err error, code int, logger *log.Logger) {
 	// Log error.
 	logger.Printf("http error: %s (code=%d)", err, code)
@@ -51,8 +419,82 @@ func encodeJSON(w http.ResponseWriter, v interface{}, logger *log.Logger) {
 	}
 }
 
+// streamWriter sets the content type of a streamed response on the first
+// write, so errors before any data is written can still be reported.
+type streamWriter struct {
+	http.ResponseWriter
+	contentType string
+	started     bool
+}
+
+// newStreamWriter returns a streamWriter for w. Errors before the first
+// write can still be reported. Once streaming starts the status has been
+// sent, so later errors can only be logged.
+func newStreamWriter(w http.ResponseWriter, contentType string) *streamWriter {
+	return &streamWriter{ResponseWriter: w, contentType: contentType}
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.Header().Set("Content-Type", w.contentType)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
 // NotFound writes an API error message to the response.
 func NotFound(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNotFound)
 	w.Write([]byte(`{}`))
 }
+
+// doRequest executes an HTTP request with an optional JSON body. The API key
+// is sent as a bearer token when one is configured. The request is
+// cancelled when ctx is done.
+func doRequest(ctx context.Context, method string, u url.URL, body []byte, key *string) (*http.Response, error) {
+	contentType := ""
+	if body != nil {
+		contentType = "application/json"
+	}
+	return doStreamRequest(ctx, method, u, bytes.NewReader(body), contentType, key)
+}
+
+// doConditionalRequest executes a request which fails unless the record is
+// still at version. Zero skips the check.
+func doConditionalRequest(ctx context.Context, method string, u url.URL, body []byte, contentType string, version int, key *string) (*http.Response, error) {
+	req, err := newRequest(ctx, method, u, bytes.NewReader(body), contentType, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 {
+		req.Header.Set("If-Match", formatETag(version))
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// doStreamRequest executes an HTTP request which streams its body.
+func doStreamRequest(ctx context.Context, method string, u url.URL, body io.Reader, contentType string, key *string) (*http.Response, error) {
+	req, err := newRequest(ctx, method, u, body, contentType, key)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// newRequest returns an HTTP request carrying the API key, if any, which is
+// bound to ctx.
+func newRequest(ctx context.Context, method string, u url.URL, body io.Reader, contentType string, key *string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if key != nil && *key != "" {
+		req.Header.Set("Authorization", "Bearer "+*key)
+	}
+	return req, nil
+}
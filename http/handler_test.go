@@ -1,20 +1,130 @@
 package http_test
 
-import "github.com/notjrbauer/fruit/http"
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
 
 // Handler represents a test wrapper for http.Handler.
 type Handler struct {
 	*http.Handler
 
-	ProductHandler *ProductHandler
+	ProductHandler     *ProductHandler
+	UserHandler        *UserHandler
+	TransactionHandler *TransactionHandler
+	CartHandler        *CartHandler
+	OrderHandler       *OrderHandler
+	BackupHandler      *BackupHandler
+	EventHandler       *EventHandler
+	WebhookHandler     *WebhookHandler
+	RevisionHandler    *RevisionHandler
+
+	APIKeyService mock.APIKeyService
+	LogOutput     bytes.Buffer
 }
 
 // NewHandler returns a new instance of Handler.
 func NewHandler() *Handler {
 	h := &Handler{
-		Handler:        &http.Handler{},
-		ProductHandler: NewProductHandler(),
+		Handler:            &http.Handler{},
+		ProductHandler:     NewProductHandler(),
+		UserHandler:        NewUserHandler(),
+		TransactionHandler: NewTransactionHandler(),
+		CartHandler:        NewCartHandler(),
+		OrderHandler:       NewOrderHandler(),
+		BackupHandler:      NewBackupHandler(),
+		EventHandler:       NewEventHandler(),
+		WebhookHandler:     NewWebhookHandler(),
+		RevisionHandler:    NewRevisionHandler(),
 	}
 	h.Handler.ProductHandler = h.ProductHandler.ProductHandler
+	h.Handler.UserHandler = h.UserHandler.UserHandler
+	h.Handler.TransactionHandler = h.TransactionHandler.TransactionHandler
+	h.Handler.CartHandler = h.CartHandler.CartHandler
+	h.Handler.OrderHandler = h.OrderHandler.OrderHandler
+	h.Handler.BackupHandler = h.BackupHandler.BackupHandler
+	h.Handler.EventHandler = h.EventHandler.EventHandler
+	h.Handler.WebhookHandler = h.WebhookHandler.WebhookHandler
+	h.Handler.RevisionHandler = h.RevisionHandler.RevisionHandler
+	h.Handler.APIKeyService = &h.APIKeyService
+	h.Handler.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
 	return h
 }
+
+func TestHandler_Authorization(t *testing.T) {
+	t.Run("OK", testHandler_Authorization)
+	t.Run("Anonymous", testHandler_Authorization_Anonymous)
+	t.Run("ErrUnauthorized", testHandler_Authorization_ErrUnauthorized)
+}
+
+func testHandler_Authorization(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "KEY"
+
+	// Mock key lookup.
+	s.Handler.APIKeyService.APIKeyFn = func(key string) (*fruit.APIKey, error) {
+		if key != "KEY" {
+			t.Fatalf("unexpected key: %s", key)
+		}
+		return &fruit.APIKey{Key: key, UserID: "USERID"}, nil
+	}
+
+	// The caller's key is used as the owner token when none is given, and
+	// the caller is passed to the service with the request context.
+	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(ctx context.Context, id fruit.ProductID, token string) error {
+		if token != "KEY" {
+			t.Fatalf("unexpected token: %s", token)
+		} else if p := fruit.PrincipalFromContext(ctx); p == nil || p.UserID != "USERID" {
+			t.Fatalf("unexpected principal: %#v", p)
+		}
+		return nil
+	}
+
+	if err := c.ProductService().DeleteProduct(ctx, "XXX", ""); err != nil {
+		t.Fatal(err)
+	} else if !s.Handler.APIKeyService.APIKeyInvoked {
+		t.Fatal("expected APIKey() to be invoked")
+	}
+}
+
+func testHandler_Authorization_Anonymous(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	s.Handler.ProductHandler.ProductService.ProductFn = func(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
+		return &fruit.Product{ID: id}, nil
+	}
+
+	// Requests without a key skip authentication.
+	if _, err := c.ProductService().Product(ctx, "XXX"); err != nil {
+		t.Fatal(err)
+	} else if s.Handler.APIKeyService.APIKeyInvoked {
+		t.Fatal("unexpected APIKey() invocation")
+	}
+}
+
+func testHandler_Authorization_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "BAD KEY"
+
+	s.Handler.APIKeyService.APIKeyFn = func(key string) (*fruit.APIKey, error) {
+		return nil, fruit.ErrAPIKeyNotFound
+	}
+
+	if _, err := c.ProductService().Product(ctx, "XXX"); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ProductService.ProductInvoked {
+		t.Fatal("unexpected Product() invocation")
+	}
+}
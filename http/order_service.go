@@ -0,0 +1,199 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+type OrderHandler struct {
+	*httprouter.Router
+
+	OrderService fruit.OrderService
+
+	Logger *log.Logger
+}
+
+// NewOrderHandler returns a new instance of OrderHandler.
+func NewOrderHandler() *OrderHandler {
+	h := &OrderHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.POST("/api/checkout", h.handlePostCheckout)
+
+	h.GET("/api/orders/:id", h.handleGetOrder)
+	h.GET("/api/users/:id/orders", h.handleGetOrders)
+	return h
+}
+
+// handleGetOrder handles requests to fetch a single order.
+func (h *OrderHandler) handleGetOrder(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	switch o, err := h.OrderService.Order(fruit.OrderID(id)); err {
+	case nil:
+		if !authorizeUser(w, r, o.UserID, h.Logger) {
+			return
+		}
+		encodeJSON(w, &getOrderResponse{Order: o}, h.Logger)
+	case fruit.ErrOrderNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type getOrderResponse struct {
+	Order *fruit.Order `json:"order,omitempty"`
+	Err   string       `json:"err,omitempty"`
+}
+
+// handleGetOrders handles requests to fetch all orders for a user.
+func (h *OrderHandler) handleGetOrders(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+
+	o, err := h.OrderService.Orders(id)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	} else {
+		encodeJSON(w, &getOrdersResponse{Orders: o}, h.Logger)
+	}
+}
+
+type getOrdersResponse struct {
+	Orders []*fruit.Order `json:"orders,omitempty"`
+	Err    string         `json:"err,omitempty"`
+}
+
+// handlePostCheckout handles requests to check out a user's cart.
+func (h *OrderHandler) handlePostCheckout(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req postCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	// Callers check out their own cart unless they are an admin.
+	if req.UserID == "" {
+		if p := fruit.PrincipalFromContext(r.Context()); p != nil {
+			req.UserID = p.UserID
+		}
+	}
+	if !authorizeUser(w, r, req.UserID, h.Logger) {
+		return
+	}
+
+	// Place order.
+	switch o, err := h.OrderService.Checkout(req.UserID); err {
+	case nil:
+		encodeJSON(w, &postCheckoutResponse{Order: o}, h.Logger)
+	case fruit.ErrUserIDRequired, fruit.ErrCartEmpty:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrUserNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrProductNotFound, fruit.ErrInsufficientStock, fruit.ErrProductPriceRequired, fruit.ErrCurrencyMismatch, fruit.ErrAmountOverflow:
+		Error(w, err, http.StatusConflict, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type postCheckoutRequest struct {
+	UserID fruit.UserID `json:"userID"`
+}
+
+type postCheckoutResponse struct {
+	Order *fruit.Order `json:"order,omitempty"`
+	Err   string       `json:"err,omitempty"`
+}
+
+// OrderService represents an HTTP implementation of fruit.OrderService.
+type OrderService struct {
+	URL *url.URL
+	Key *string
+}
+
+func (s *OrderService) Order(id fruit.OrderID) (*fruit.Order, error) {
+	u := *s.URL
+	u.Path = "/api/orders/" + url.QueryEscape(string(id))
+
+	// Execute the request.
+	resp, err := doRequest(context.Background(), http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getOrderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Order, nil
+}
+
+func (s *OrderService) Orders(id fruit.UserID) ([]*fruit.Order, error) {
+	u := *s.URL
+	u.Path = "/api/users/" + url.QueryEscape(string(id)) + "/orders"
+
+	// Execute the request.
+	resp, err := doRequest(context.Background(), http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getOrdersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Orders, nil
+}
+
+func (s *OrderService) Checkout(id fruit.UserID) (*fruit.Order, error) {
+	// Validate arguments.
+	if id == "" {
+		return nil, fruit.ErrUserIDRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/checkout"
+
+	reqBody, err := json.Marshal(postCheckoutRequest{UserID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute the request.
+	resp, err := doRequest(context.Background(), http.MethodPost, u, reqBody, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody postCheckoutResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Order, nil
+}
@@ -0,0 +1,214 @@
+package http_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// OrderHandler represents a test wrapper for http.OrderHandler
+type OrderHandler struct {
+	*http.OrderHandler
+
+	OrderService mock.OrderService
+	LogOutput    bytes.Buffer
+}
+
+func NewOrderHandler() *OrderHandler {
+	h := &OrderHandler{OrderHandler: http.NewOrderHandler()}
+	h.OrderHandler.OrderService = &h.OrderService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+func TestOrderService_Order(t *testing.T) {
+	t.Run("OK", testOrderService_Order)
+	t.Run("ErrOrderNotFound", testOrderService_Order_ErrOrderNotFound)
+	t.Run("ErrForbidden", testOrderService_Order_ErrForbidden)
+}
+
+func testOrderService_Order(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.OrderHandler.OrderService.OrderFn = func(id fruit.OrderID) (*fruit.Order, error) {
+		if id != "O" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return &fruit.Order{ID: "O", UserID: "U", Items: []fruit.OrderItem{{ProductID: "P", Name: "Pear", Quantity: 1}}}, nil
+	}
+
+	// Retrieve order.
+	if o, err := c.OrderService().Order("O"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(o, &fruit.Order{ID: "O", UserID: "U", Items: []fruit.OrderItem{{ProductID: "P", Name: "Pear", Quantity: 1}}}) {
+		t.Fatalf("unexpected order: %+v", o)
+	}
+}
+
+func testOrderService_Order_ErrOrderNotFound(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.OrderHandler.OrderService.OrderFn = func(id fruit.OrderID) (*fruit.Order, error) {
+		return nil, fruit.ErrOrderNotFound
+	}
+
+	// Retrieve order.
+	if o, err := c.OrderService().Order("XXX"); err != fruit.ErrOrderNotFound {
+		t.Fatal(err)
+	} else if o != nil {
+		t.Fatalf("unexpected order: %+v", o)
+	}
+}
+
+func testOrderService_Order_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.OrderHandler.OrderService.OrderFn = func(id fruit.OrderID) (*fruit.Order, error) {
+		return &fruit.Order{ID: "O", UserID: "OTHER"}, nil
+	}
+
+	if _, err := c.OrderService().Order("O"); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderService_Orders(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.OrderHandler.OrderService.OrdersFn = func(id fruit.UserID) ([]*fruit.Order, error) {
+		if id != "U" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return []*fruit.Order{{ID: "O1", UserID: "U"}, {ID: "O2", UserID: "U"}}, nil
+	}
+
+	// Retrieve orders.
+	if o, err := c.OrderService().Orders("U"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(o, []*fruit.Order{{ID: "O1", UserID: "U"}, {ID: "O2", UserID: "U"}}) {
+		t.Fatalf("unexpected orders: %+v", o)
+	}
+}
+
+func TestOrderService_Orders_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.OrderService().Orders("OTHER"); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.OrderHandler.OrderService.OrdersInvoked {
+		t.Fatal("expected Orders() not to be invoked")
+	}
+}
+
+func TestOrderService_Checkout(t *testing.T) {
+	t.Run("OK", testOrderService_Checkout)
+	t.Run("ErrUserIDRequired", testOrderService_Checkout_ErrUserIDRequired)
+	t.Run("ErrCartEmpty", testOrderService_Checkout_ErrCartEmpty)
+	t.Run("ErrInternal", testOrderService_Checkout_ErrInternal)
+	t.Run("ErrForbidden", testOrderService_Checkout_ErrForbidden)
+}
+
+func testOrderService_Checkout(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.OrderHandler.OrderService.CheckoutFn = func(id fruit.UserID) (*fruit.Order, error) {
+		if id != "U" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return &fruit.Order{ID: "O", UserID: "U"}, nil
+	}
+
+	// Check out.
+	if o, err := c.OrderService().Checkout("U"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(o, &fruit.Order{ID: "O", UserID: "U"}) {
+		t.Fatalf("unexpected order: %+v", o)
+	}
+}
+
+func testOrderService_Checkout_ErrUserIDRequired(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.OrderService().Checkout(""); err != fruit.ErrUserIDRequired {
+		t.Fatal(err)
+	} else if s.Handler.OrderHandler.OrderService.CheckoutInvoked {
+		t.Fatal("expected Checkout() not to be invoked")
+	}
+}
+
+func testOrderService_Checkout_ErrCartEmpty(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.OrderHandler.OrderService.CheckoutFn = func(id fruit.UserID) (*fruit.Order, error) {
+		return nil, fruit.ErrCartEmpty
+	}
+
+	if _, err := c.OrderService().Checkout("U"); err != fruit.ErrCartEmpty {
+		t.Fatal(err)
+	}
+}
+
+func testOrderService_Checkout_ErrInternal(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.OrderHandler.OrderService.CheckoutFn = func(id fruit.UserID) (*fruit.Order, error) {
+		return nil, errors.New("marker")
+	}
+
+	if _, err := c.OrderService().Checkout("U"); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+func testOrderService_Checkout_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.OrderService().Checkout("OTHER"); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.OrderHandler.OrderService.CheckoutInvoked {
+		t.Fatal("expected Checkout() not to be invoked")
+	}
+}
@@ -1,8 +1,9 @@
 package http
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -11,12 +12,17 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/bulk"
 )
 
+// MaxBatchSize is the most operations accepted in one batch request.
+const MaxBatchSize = 1000
+
 type ProductHandler struct {
 	*httprouter.Router
 
 	ProductService fruit.ProductService
+	ImportService  fruit.ImportService
 
 	Logger *log.Logger
 }
@@ -32,8 +38,14 @@ func NewProductHandler() *ProductHandler {
 	h.POST("/api/products", h.handlePostProduct)
 	h.PUT("/api/products", h.handlePutProduct)
 	h.DELETE("/api/products", h.handleDeleteProduct)
+	h.POST("/api/products/import", h.handleImportProducts)
+	h.POST("/api/products/batch", h.handleBatchProducts)
 
 	h.GET("/api/products/:id", h.handleGetProduct)
+	h.PATCH("/api/products/:id", h.handlePatchProduct)
+
+	h.POST("/api/admin/products/:id/restore", h.handleRestoreProduct)
+	h.DELETE("/api/admin/products/trash", h.handlePurgeProducts)
 	return h
 }
 
@@ -41,12 +53,25 @@ func NewProductHandler() *ProductHandler {
 func (h *ProductHandler) handleGetProduct(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	id := ps.ByName("id")
 
-	p, err := h.ProductService.Product(fruit.ProductID(id))
-	if err != nil {
+	// The router can't register static paths beside /:id.
+	switch id {
+	case "search":
+		h.handleSearchProducts(w, r, ps)
+		return
+	case "export":
+		h.handleExportProducts(w, r, ps)
+		return
+	}
+
+	p, err := h.ProductService.Product(r.Context(), fruit.ProductID(id))
+	if err == fruit.ErrProductNotFound {
+		Error(w, err, http.StatusNotFound, h.Logger)
+	} else if err != nil {
 		Error(w, err, http.StatusInternalServerError, h.Logger)
 	} else if p == nil {
 		NotFound(w)
 	} else {
+		w.Header().Set("ETag", formatETag(p.Version))
 		encodeJSON(w, &getProductResponse{Product: p}, h.Logger)
 	}
 }
@@ -58,21 +83,57 @@ type getProductResponse struct {
 
 // handleGetProducts handles requests to fetch a series of products
 func (h *ProductHandler) handleGetProducts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-
-	p, err := h.ProductService.Products()
+	opt, err := parseQueryOptions(r.URL.Query())
 	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	if opt.IncludeDeleted && !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch p, next, err := h.ProductService.Products(r.Context(), opt); err {
+	case nil:
+		if len(p) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}` + "\n"))
+		} else {
+			encodeJSON(w, &getProductsResponse{Products: p, NextCursor: next}, h.Logger)
+		}
+	case fruit.ErrInvalidLimit, fruit.ErrInvalidCursor, fruit.ErrInvalidSort:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	default:
 		Error(w, err, http.StatusInternalServerError, h.Logger)
-	} else if len(p) == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte(`{}` + "\n"))
-	} else {
-		encodeJSON(w, &getProductsResponse{Products: p}, h.Logger)
 	}
 }
 
 type getProductsResponse struct {
-	Products []*fruit.Product `json:"products,omitempty"`
-	Err      string           `json:"err,omitempty"`
+	Products   []*fruit.Product `json:"products,omitempty"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+	Err        string           `json:"err,omitempty"`
+}
+
+// handleSearchProducts handles requests to search products by text.
+func (h *ProductHandler) handleSearchProducts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	opt, err := parseQueryOptions(r.URL.Query())
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	if opt.IncludeDeleted && !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch p, next, err := h.ProductService.Search(r.Context(), r.URL.Query().Get("q"), opt); err {
+	case nil:
+		encodeJSON(w, &getProductsResponse{Products: p, NextCursor: next}, h.Logger)
+	case fruit.ErrSearchQueryRequired, fruit.ErrInvalidLimit, fruit.ErrInvalidCursor:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
 }
 
 // handlePostProduct handles requests to create a new product.
@@ -85,17 +146,19 @@ func (h *ProductHandler) handlePostProduct(w http.ResponseWriter, r *http.Reques
 	}
 
 	p := req.Product
-	p.Token = req.Token
+	p.Token = requestToken(r, req.Token)
 	p.ModTime = time.Time{}
 
 	// Create product.
-	switch err := h.ProductService.CreateProduct(p); err {
+	switch err := h.ProductService.CreateProduct(r.Context(), p); err {
 	case nil:
 		encodeJSON(w, &postProductRequest{Product: p}, h.Logger)
-	case fruit.ErrProductRequired, fruit.ErrProductIDRequired:
+	case fruit.ErrProductRequired, fruit.ErrProductIDRequired, fruit.ErrInvalidPrice, fruit.ErrInvalidCurrency, fruit.ErrCategoryNotFound:
 		Error(w, err, http.StatusBadRequest, h.Logger)
 	case fruit.ErrProductExists:
 		Error(w, err, http.StatusConflict, h.Logger)
+	case fruit.ErrUnauthorized:
+		Error(w, err, http.StatusUnauthorized, h.Logger)
 	default:
 		Error(w, err, http.StatusInternalServerError, h.Logger)
 	}
@@ -122,17 +185,33 @@ func (h *ProductHandler) handlePutProduct(w http.ResponseWriter, r *http.Request
 
 	p := req.Product
 	p.ID = req.ID
+	p.Token = requestToken(r, req.Token)
 	p.ModTime = time.Time{}
 
-	// Create product.
-	// TODO: Add Token
-	switch err := h.ProductService.UpdateProduct(p.ID, p); err {
+	// The version to update must be sent in the If-Match header.
+	version, err := requireIfMatch(r)
+	if err == fruit.ErrVersionRequired {
+		Error(w, err, http.StatusPreconditionRequired, h.Logger)
+		return
+	} else if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+	p.Version = version
+
+	// Update product.
+	switch err := h.ProductService.UpdateProduct(r.Context(), p.ID, p); err {
 	case nil:
+		w.Header().Set("ETag", formatETag(p.Version))
 		encodeJSON(w, &putProductResponse{Product: p}, h.Logger)
-	case fruit.ErrProductRequired, fruit.ErrProductIDRequired:
+	case fruit.ErrConflict:
+		Error(w, err, http.StatusPreconditionFailed, h.Logger)
+	case fruit.ErrProductRequired, fruit.ErrProductIDRequired, fruit.ErrInvalidPrice, fruit.ErrInvalidCurrency, fruit.ErrCategoryNotFound:
 		Error(w, err, http.StatusBadRequest, h.Logger)
 	case fruit.ErrProductNotFound:
 		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrUnauthorized:
+		Error(w, err, http.StatusUnauthorized, h.Logger)
 	default:
 		Error(w, err, http.StatusInternalServerError, h.Logger)
 	}
@@ -141,6 +220,7 @@ func (h *ProductHandler) handlePutProduct(w http.ResponseWriter, r *http.Request
 type putProductRequest struct {
 	Product *fruit.Product  `json:"product,omitempty"`
 	ID      fruit.ProductID `json:"id,omitempty"`
+	Token   string          `json:"token,omitempty"`
 }
 
 type putProductResponse struct {
@@ -148,6 +228,56 @@ type putProductResponse struct {
 	Err     string         `json:"err,omitempty"`
 }
 
+// handlePatchProduct handles requests to change some fields of a product.
+// The body is a JSON Merge Patch of the product, and the token is passed as
+// a query parameter.
+func (h *ProductHandler) handlePatchProduct(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var p fruit.Product
+	mask, err := decodeMergePatch(r, &p)
+	if err == ErrUnsupportedMediaType {
+		Error(w, err, http.StatusUnsupportedMediaType, h.Logger)
+		return
+	} else if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	// The version to update must be sent in the If-Match header.
+	version, err := requireIfMatch(r)
+	if err == fruit.ErrVersionRequired {
+		Error(w, err, http.StatusPreconditionRequired, h.Logger)
+		return
+	} else if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+	p.Version = version
+	p.Token = requestToken(r, r.URL.Query().Get("token"))
+
+	// Patch product.
+	switch err := h.ProductService.PatchProduct(r.Context(), fruit.ProductID(ps.ByName("id")), &p, mask); err {
+	case nil:
+		w.Header().Set("ETag", formatETag(p.Version))
+		encodeJSON(w, &patchProductResponse{Product: &p}, h.Logger)
+	case fruit.ErrInvalidField, fruit.ErrInvalidPrice, fruit.ErrInvalidCurrency, fruit.ErrCategoryNotFound:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrProductNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrUnauthorized:
+		Error(w, err, http.StatusUnauthorized, h.Logger)
+	case fruit.ErrConflict:
+		Error(w, err, http.StatusPreconditionFailed, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type patchProductResponse struct {
+	Product *fruit.Product `json:"product,omitempty"`
+	Err     string         `json:"err,omitempty"`
+}
+
 // handleDeleteProduct handles requests to update a product.
 func (h *ProductHandler) handleDeleteProduct(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	// Decode request.
@@ -158,13 +288,15 @@ func (h *ProductHandler) handleDeleteProduct(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Delete product.
-	switch err := h.ProductService.DeleteProduct(req.ID, req.Token); err {
+	switch err := h.ProductService.DeleteProduct(r.Context(), req.ID, requestToken(r, req.Token)); err {
 	case nil:
 		encodeJSON(w, &deleteProductResponse{}, h.Logger)
 	case fruit.ErrProductNotFound:
 		Error(w, err, http.StatusNotFound, h.Logger)
 	case fruit.ErrProductRequired, fruit.ErrProductIDRequired:
 		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrUnauthorized:
+		Error(w, err, http.StatusUnauthorized, h.Logger)
 	default:
 		Error(w, err, http.StatusInternalServerError, h.Logger)
 	}
@@ -179,17 +311,157 @@ type deleteProductResponse struct {
 	Err string `json:"err,omitempty"`
 }
 
+// handleRestoreProduct handles requests to move a product out of the
+// trash.
+func (h *ProductHandler) handleRestoreProduct(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch err := h.ProductService.RestoreProduct(r.Context(), fruit.ProductID(ps.ByName("id"))); err {
+	case nil:
+		encodeJSON(w, &restoreProductResponse{}, h.Logger)
+	case fruit.ErrProductNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrProductNotDeleted:
+		Error(w, err, http.StatusConflict, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type restoreProductResponse struct {
+	Err string `json:"err,omitempty"`
+}
+
+// handlePurgeProducts handles requests to empty the trash. Products deleted
+// before the time in the "before" parameter are removed, or every deleted
+// product if it is blank.
+func (h *ProductHandler) handlePurgeProducts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	before := time.Now()
+	if s := r.URL.Query().Get("before"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			Error(w, ErrInvalidTime, http.StatusBadRequest, h.Logger)
+			return
+		}
+		before = t
+	}
+
+	n, err := h.ProductService.PurgeProducts(r.Context(), before)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+		return
+	}
+	encodeJSON(w, &purgeProductsResponse{Purged: n}, h.Logger)
+}
+
+type purgeProductsResponse struct {
+	Purged int    `json:"purged"`
+	Err    string `json:"err,omitempty"`
+}
+
+// handleBatchProducts handles requests to apply a batch of operations. The
+// caller's key is the token of any operation without one.
+func (h *ProductHandler) handleBatchProducts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req batchProductsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if len(req.Ops) > MaxBatchSize {
+		Error(w, ErrBatchTooLarge, http.StatusRequestEntityTooLarge, h.Logger)
+		return
+	}
+
+	for i := range req.Ops {
+		op := &req.Ops[i]
+		op.Token = requestToken(r, op.Token)
+		if op.Product != nil {
+			op.Product.ModTime = time.Time{}
+		}
+	}
+
+	results, err := h.ProductService.Batch(r.Context(), req.Ops, req.Atomic)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+		return
+	}
+	encodeJSON(w, &batchProductsResponse{Results: results}, h.Logger)
+}
+
+type batchProductsRequest struct {
+	Ops    []fruit.BatchOp `json:"ops"`
+	Atomic bool            `json:"atomic,omitempty"`
+}
+
+type batchProductsResponse struct {
+	Results []fruit.BatchResult `json:"results,omitempty"`
+	Err     string              `json:"err,omitempty"`
+}
+
+// handleImportProducts handles requests to import a file of products. The
+// caller's token owns the imported products.
+func (h *ProductHandler) handleImportProducts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	opt, err := parseBulkOptions(r.URL.Query())
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	opt.Token = requestToken(r, r.URL.Query().Get("token"))
+	if opt.Token == "" {
+		Error(w, fruit.ErrUnauthorized, http.StatusUnauthorized, h.Logger)
+		return
+	}
+
+	switch report, err := bulk.ImportProducts(r.Body, h.ImportService, opt); err {
+	case nil:
+		encodeJSON(w, &importProductsResponse{Report: report}, h.Logger)
+	case bulk.ErrInvalidFormat, bulk.ErrInvalidMapping, bulk.ErrInvalidRow:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type importProductsResponse struct {
+	Report *bulk.Report `json:"report,omitempty"`
+	Err    string       `json:"err,omitempty"`
+}
+
+// handleExportProducts handles requests to download every product.
+func (h *ProductHandler) handleExportProducts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	opt, err := parseBulkOptions(r.URL.Query())
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	sw := newStreamWriter(w, bulkContentType(opt.Format))
+	if err := bulk.ExportProducts(r.Context(), sw, h.ProductService, opt); err != nil && !sw.started {
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	} else if err != nil {
+		h.Logger.Printf("export error: %s", err)
+	}
+}
+
 // ProductService represents an HTTP implementation of fruit.ProductService.
 type ProductService struct {
 	URL *url.URL
+	Key *string
 }
 
-func (s *ProductService) Product(id fruit.ProductID) (*fruit.Product, error) {
+func (s *ProductService) Product(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
 	u := *s.URL
 	u.Path = "/api/products/" + url.QueryEscape(string(id))
 
 	// Execute the request.
-	resp, err := http.Get(u.String())
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -202,18 +474,26 @@ func (s *ProductService) Product(id fruit.ProductID) (*fruit.Product, error) {
 		return nil, err
 	} else if respBody.Err != "" {
 		return nil, fruit.Error(respBody.Err)
+	} else if respBody.Product == nil {
+		return nil, nil
+	}
+
+	// Read the version from the entity tag.
+	if version, err := parseETag(resp.Header.Get("ETag")); err == nil {
+		respBody.Product.Version = version
 	}
 	return respBody.Product, nil
 }
 
-func (s *ProductService) Products() ([]*fruit.Product, error) {
+func (s *ProductService) Products(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
 	u := *s.URL
 	u.Path = "/api/products"
+	u.RawQuery = encodeQueryOptions(opt).Encode()
 
 	// Execute the request
-	resp, err := http.Get(u.String())
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	defer resp.Body.Close()
@@ -221,14 +501,39 @@ func (s *ProductService) Products() ([]*fruit.Product, error) {
 	// Decode response into JSON.
 	var respBody getProductsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
-		return nil, err
+		return nil, "", err
 	} else if respBody.Err != "" {
-		return nil, fruit.Error(respBody.Err)
+		return nil, "", fruit.Error(respBody.Err)
+	}
+	return respBody.Products, respBody.NextCursor, nil
+}
+
+func (s *ProductService) Search(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	v := encodeQueryOptions(opt)
+	v.Set("q", query)
+
+	u := *s.URL
+	u.Path = "/api/products/search"
+	u.RawQuery = v.Encode()
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getProductsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, "", err
+	} else if respBody.Err != "" {
+		return nil, "", fruit.Error(respBody.Err)
 	}
-	return respBody.Products, nil
+	return respBody.Products, respBody.NextCursor, nil
 }
 
-func (s *ProductService) CreateProduct(p *fruit.Product) error {
+func (s *ProductService) CreateProduct(ctx context.Context, p *fruit.Product) error {
 	// Validate arguments.
 	if p == nil {
 		return fruit.ErrProductRequired
@@ -246,7 +551,7 @@ func (s *ProductService) CreateProduct(p *fruit.Product) error {
 	}
 
 	// Execute the request.
-	resp, err := http.Post(u.String(), "application/json", bytes.NewBuffer(reqBody))
+	resp, err := doRequest(ctx, http.MethodPost, u, reqBody, s.Key)
 	if err != nil {
 		return err
 	}
@@ -268,28 +573,27 @@ func (s *ProductService) CreateProduct(p *fruit.Product) error {
 	return err
 }
 
-func (s *ProductService) UpdateProduct(id fruit.ProductID, p *fruit.Product) error {
+func (s *ProductService) UpdateProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
 	// Validate arguments.
 	if id == "" {
 		return fruit.ErrProductIDRequired
+	} else if p == nil {
+		return fruit.ErrProductRequired
 	}
 
 	u := *s.URL
 	u.Path = "/api/products"
 
-	reqBody, err := json.Marshal(putProductRequest{Product: p, ID: id})
-	if err != nil {
-		return err
-	}
+	// Save token.
+	token := p.Token
 
-	// Create request.
-	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewBuffer(reqBody))
+	reqBody, err := json.Marshal(putProductRequest{Product: p, ID: id, Token: token})
 	if err != nil {
 		return err
 	}
 
-	// Execute request.
-	resp, err := http.DefaultClient.Do(req)
+	// Execute request, which fails if the product has changed since it was read.
+	resp, err := doConditionalRequest(ctx, http.MethodPut, u, reqBody, "application/json", p.Version, s.Key)
 	if err != nil {
 		return err
 	}
@@ -307,31 +611,70 @@ func (s *ProductService) UpdateProduct(id fruit.ProductID, p *fruit.Product) err
 	// TODO: Remove ability to generate ID
 	*p = *respBody.Product
 	p.ID = id
+	p.Token = token
 	return nil
 }
 
-func (s *ProductService) DeleteProduct(id fruit.ProductID, token string) error {
+// PatchProduct sends the fields of p named in mask as a JSON Merge Patch.
+func (s *ProductService) PatchProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
 	// Validate arguments.
 	if id == "" {
 		return fruit.ErrProductIDRequired
+	} else if p == nil {
+		return fruit.ErrProductRequired
 	}
 
 	u := *s.URL
-	u.Path = "/api/products"
+	u.Path = "/api/products/" + url.QueryEscape(string(id))
+	if p.Token != "" {
+		u.RawQuery = url.Values{"token": {p.Token}}.Encode()
+	}
 
-	reqBody, err := json.Marshal(deleteProductRequest{ID: id, Token: token})
+	// Save token.
+	token := p.Token
+
+	reqBody, err := encodeMergePatch(p, mask)
 	if err != nil {
 		return err
 	}
 
-	// Create request.
-	req, err := http.NewRequest(http.MethodDelete, u.String(), bytes.NewBuffer(reqBody))
+	// Execute request, which fails if the product has changed since it was read.
+	resp, err := doConditionalRequest(ctx, http.MethodPatch, u, reqBody, MergePatchContentType, p.Version, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody patchProductResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned product.
+	*p = *respBody.Product
+	p.Token = token
+	return nil
+}
+
+func (s *ProductService) DeleteProduct(ctx context.Context, id fruit.ProductID, token string) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrProductIDRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/products"
+
+	reqBody, err := json.Marshal(deleteProductRequest{ID: id, Token: token})
 	if err != nil {
 		return err
 	}
 
 	// Execute request.
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doRequest(ctx, http.MethodDelete, u, reqBody, s.Key)
 	if err != nil {
 		return err
 	}
@@ -347,3 +690,152 @@ func (s *ProductService) DeleteProduct(id fruit.ProductID, token string) error {
 
 	return nil
 }
+
+// RestoreProduct moves a product out of the trash. The client's API key
+// must belong to an admin.
+func (s *ProductService) RestoreProduct(ctx context.Context, id fruit.ProductID) error {
+	u := *s.URL
+	u.Path = "/api/admin/products/" + url.QueryEscape(string(id)) + "/restore"
+
+	// Execute request.
+	resp, err := doRequest(ctx, http.MethodPost, u, nil, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody restoreProductResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+	return nil
+}
+
+// PurgeProducts permanently removes products deleted before t. The
+// client's API key must belong to an admin.
+func (s *ProductService) PurgeProducts(ctx context.Context, before time.Time) (int, error) {
+	u := *s.URL
+	u.Path = "/api/admin/products/trash"
+	u.RawQuery = url.Values{"before": {before.UTC().Format(time.RFC3339Nano)}}.Encode()
+
+	// Execute request.
+	resp, err := doRequest(ctx, http.MethodDelete, u, nil, s.Key)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody purgeProductsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return 0, err
+	} else if respBody.Err != "" {
+		return 0, fruit.Error(respBody.Err)
+	}
+	return respBody.Purged, nil
+}
+
+// Batch sends a series of operations to the server. Batches larger than
+// MaxBatchSize are sent in several requests, so they can't be atomic.
+func (s *ProductService) Batch(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+	if atomic && len(ops) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	u := *s.URL
+	u.Path = "/api/products/batch"
+
+	results := make([]fruit.BatchResult, 0, len(ops))
+	for start := 0; start < len(ops); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		reqBody, err := json.Marshal(batchProductsRequest{Ops: ops[start:end], Atomic: atomic})
+		if err != nil {
+			return nil, err
+		}
+
+		// Execute request.
+		resp, err := doRequest(ctx, http.MethodPost, u, reqBody, s.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		// Decode response into JSON.
+		var respBody batchProductsResponse
+		err = json.NewDecoder(resp.Body).Decode(&respBody)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		} else if respBody.Err != "" {
+			return nil, fruit.Error(respBody.Err)
+		}
+
+		// Results are indexed from the start of each request.
+		for _, r := range respBody.Results {
+			r.Index += start
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// Import uploads a file of products. The products are owned by opt.Token,
+// or by the client's API key if it is blank.
+func (s *ProductService) Import(ctx context.Context, r io.Reader, opt bulk.Options) (*bulk.Report, error) {
+	v := encodeBulkOptions(opt)
+	if opt.Token != "" {
+		v.Set("token", opt.Token)
+	}
+
+	u := *s.URL
+	u.Path = "/api/products/import"
+	u.RawQuery = v.Encode()
+
+	// Execute the request.
+	resp, err := doStreamRequest(ctx, http.MethodPost, u, r, bulkContentType(opt.Format), s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody importProductsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Report, nil
+}
+
+// Export downloads every product to w.
+func (s *ProductService) Export(ctx context.Context, w io.Writer, opt bulk.Options) error {
+	u := *s.URL
+	u.Path = "/api/products/export"
+	u.RawQuery = encodeBulkOptions(opt).Encode()
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors are returned as JSON.
+	if resp.StatusCode != http.StatusOK {
+		var respBody errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+			return err
+		}
+		return fruit.Error(respBody.Err)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -2,12 +2,17 @@ package http_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"log"
+	nethttp "net/http"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/bulk"
 	"github.com/notjrbauer/fruit/http"
 	"github.com/notjrbauer/fruit/mock"
 )
@@ -17,12 +22,14 @@ type ProductHandler struct {
 	*http.ProductHandler
 
 	ProductService mock.ProductService
+	ImportService  mock.ImportService
 	LogOutput      bytes.Buffer
 }
 
 func NewProductHandler() *ProductHandler {
 	h := &ProductHandler{ProductHandler: http.NewProductHandler()}
 	h.ProductHandler.ProductService = &h.ProductService
+	h.ProductHandler.ImportService = &h.ImportService
 	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
 	return h
 }
@@ -31,19 +38,22 @@ func TestProductService_Product(t *testing.T) {
 	t.Run("OK", testProductService_Product)
 	t.Run("NotFound", testProductService_Product_NotFound)
 	t.Run("ErrInternal", testProductService_Product_ErrInternal)
+	t.Run("Cancel", testProductService_Product_Cancel)
+	t.Run("ETag", testProductService_Product_ETag)
 }
 
 func testProductService_Product(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock service.
-	s.Handler.ProductHandler.ProductService.ProductFn = func(id fruit.ProductID) (*fruit.Product, error) {
+	s.Handler.ProductHandler.ProductService.ProductFn = func(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
 		return &fruit.Product{ID: "A"}, nil
 	}
 
 	// Retrieve product.
-	p, err := c.ProductService().Product(fruit.ProductID("A"))
+	p, err := c.ProductService().Product(ctx, fruit.ProductID("A"))
 	if err != nil {
 		t.Fatal(err)
 	} else if !reflect.DeepEqual(p, &fruit.Product{ID: "A"}) {
@@ -52,16 +62,17 @@ func testProductService_Product(t *testing.T) {
 }
 
 func testProductService_Product_NotFound(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock service.
-	s.Handler.ProductHandler.ProductService.ProductFn = func(id fruit.ProductID) (*fruit.Product, error) {
+	s.Handler.ProductHandler.ProductService.ProductFn = func(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
 		return nil, nil
 	}
 
 	// Retrieve product.
-	if d, err := c.ProductService().Product(fruit.ProductID("NO SUCH PRODUCT")); err != nil {
+	if d, err := c.ProductService().Product(ctx, fruit.ProductID("NO SUCH PRODUCT")); err != nil {
 		t.Fatal(err)
 	} else if d != nil {
 		t.Fatal("unexpected nil product")
@@ -69,41 +80,104 @@ func testProductService_Product_NotFound(t *testing.T) {
 }
 
 func testProductService_Product_ErrInternal(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock service.
-	s.Handler.ProductHandler.ProductService.ProductFn = func(id fruit.ProductID) (*fruit.Product, error) {
+	s.Handler.ProductHandler.ProductService.ProductFn = func(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
 		return nil, errors.New("marker")
 	}
 
 	// Retrieve product.
-	if p, err := c.ProductService().Product(fruit.ProductID("XXX")); err != fruit.ErrInternal {
+	if p, err := c.ProductService().Product(ctx, fruit.ProductID("XXX")); err != fruit.ErrInternal {
 		t.Fatal(err)
 	} else if p != nil {
 		t.Fatal("unexpected nil product")
 	}
 }
 
+func testProductService_Product_Cancel(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service to block until the request is cancelled.
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	s.Handler.ProductHandler.ProductService.ProductFn = func(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	if _, err := c.ProductService().Product(ctx, "A"); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+
+	// The server stops work once the client goes away.
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected request context to be cancelled")
+	}
+}
+
+func testProductService_Product_ETag(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.ProductFn = func(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
+		return &fruit.Product{ID: "A", Version: 3}, nil
+	}
+
+	// The version is sent as the entity tag.
+	u := c.URL
+	u.Path = "/api/products/A"
+	if resp, err := nethttp.Get(u.String()); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.Header.Get("ETag") != `"3"` {
+		t.Fatalf("unexpected etag: %s", resp.Header.Get("ETag"))
+	}
+
+	// The client reads it back.
+	if p, err := c.ProductService().Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p.Version != 3 {
+		t.Fatalf("unexpected version: %d", p.Version)
+	}
+}
+
 func TestProductService_Products(t *testing.T) {
 	t.Run("OK", testProductService_Products)
 	t.Run("NotFound", testProductService_Products_NotFound)
+	t.Run("Options", testProductService_Products_Options)
+	t.Run("ErrInvalidSort", testProductService_Products_ErrInvalidSort)
 	t.Run("ErrInternal", testProductService_Products_ErrInternal)
+	t.Run("IncludeDeleted", testProductService_Products_IncludeDeleted)
 }
 
 func testProductService_Products(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock service.
-	s.Handler.ProductHandler.ProductService.ProductsFn = func() ([]*fruit.Product, error) {
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
 		var products []*fruit.Product
 		products = append(products, &fruit.Product{ID: "A"}, &fruit.Product{ID: "B"})
 
-		return products, nil
+		return products, "", nil
 	}
 
-	if p, err := c.ProductService().Products(); err != nil {
+	if p, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{}); err != nil {
 		t.Fatal(err)
 	} else if len(p) != 2 {
 		t.Fatalf("expected to return two products but returned: %+v", p)
@@ -111,54 +185,208 @@ func testProductService_Products(t *testing.T) {
 }
 
 func testProductService_Products_NotFound(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock service.
-	s.Handler.ProductHandler.ProductService.ProductsFn = func() ([]*fruit.Product, error) {
-		return nil, nil
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		return nil, "", nil
 	}
 
 	// Retrieve products.
-	if d, err := c.ProductService().Products(); err != nil {
+	if d, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{}); err != nil {
 		t.Fatal(err)
 	} else if d != nil {
 		t.Fatal("unexpected nil product")
 	}
 }
 
+func testProductService_Products_Options(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	opt := fruit.QueryOptions{Limit: 10, Cursor: "20", Sort: "sku", Desc: true, Type: "Apple", Color: "Red", SKU: "A-1"}
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, other fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		if !reflect.DeepEqual(other, opt) {
+			t.Fatalf("unexpected options: %+v", other)
+		}
+		return []*fruit.Product{{ID: "A"}}, "30", nil
+	}
+
+	if p, next, err := c.ProductService().Products(ctx, opt); err != nil {
+		t.Fatal(err)
+	} else if len(p) != 1 {
+		t.Fatalf("unexpected products: %+v", p)
+	} else if next != "30" {
+		t.Fatalf("unexpected next cursor: %s", next)
+	}
+}
+
+func testProductService_Products_ErrInvalidSort(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		return nil, "", fruit.ErrInvalidSort
+	}
+
+	if _, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{Sort: "XXX"}); err != fruit.ErrInvalidSort {
+		t.Fatal(err)
+	}
+}
+
 func testProductService_Products_ErrInternal(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock service.
-	s.Handler.ProductHandler.ProductService.ProductsFn = func() ([]*fruit.Product, error) {
-		return nil, errors.New("marker")
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		return nil, "", errors.New("marker")
 	}
 
 	// Retrieve product.
-	if p, err := c.ProductService().Products(); err != fruit.ErrInternal {
+	if p, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{}); err != fruit.ErrInternal {
 		t.Fatal(err)
 	} else if p != nil {
 		t.Fatal("unexpected nil product")
 	}
 }
 
+func testProductService_Products_IncludeDeleted(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		if !opt.IncludeDeleted {
+			t.Fatal("expected deleted products")
+		}
+		return []*fruit.Product{{ID: "A"}}, "", nil
+	}
+
+	// Only admins may list deleted products.
+	c.Key = "USER"
+	if _, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{IncludeDeleted: true}); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	}
+
+	c.Key = "ADMIN"
+	if p, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{IncludeDeleted: true}); err != nil {
+		t.Fatal(err)
+	} else if len(p) != 1 {
+		t.Fatalf("unexpected products: %+v", p)
+	}
+}
+
+func TestProductService_Search(t *testing.T) {
+	t.Run("OK", testProductService_Search)
+	t.Run("ErrSearchQueryRequired", testProductService_Search_ErrSearchQueryRequired)
+	t.Run("IncludeDeleted", testProductService_Search_IncludeDeleted)
+}
+
+func testProductService_Search(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.SearchFn = func(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		if query != "red apple" {
+			t.Fatalf("unexpected query: %s", query)
+		} else if !reflect.DeepEqual(opt, fruit.QueryOptions{Limit: 5}) {
+			t.Fatalf("unexpected options: %+v", opt)
+		}
+		return []*fruit.Product{{ID: "B"}, {ID: "A"}}, "5", nil
+	}
+
+	if p, next, err := c.ProductService().Search(ctx, "red apple", fruit.QueryOptions{Limit: 5}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(p, []*fruit.Product{{ID: "B"}, {ID: "A"}}) {
+		t.Fatalf("unexpected products: %+v", p)
+	} else if next != "5" {
+		t.Fatalf("unexpected next cursor: %s", next)
+	} else if s.Handler.ProductHandler.ProductService.ProductInvoked {
+		t.Fatal("expected Product() not to be invoked")
+	}
+}
+
+func testProductService_Search_ErrSearchQueryRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.SearchFn = func(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		return nil, "", fruit.ErrSearchQueryRequired
+	}
+
+	if _, _, err := c.ProductService().Search(ctx, "", fruit.QueryOptions{}); err != fruit.ErrSearchQueryRequired {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_Search_IncludeDeleted(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.SearchFn = func(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		if !opt.IncludeDeleted {
+			t.Fatal("expected deleted products")
+		}
+		return []*fruit.Product{{ID: "A"}}, "", nil
+	}
+
+	// Only admins may search deleted products.
+	opt := fruit.QueryOptions{IncludeDeleted: true}
+	if _, _, err := c.ProductService().Search(ctx, "apple", opt); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	}
+	c.Key = "USER"
+	if _, _, err := c.ProductService().Search(ctx, "apple", opt); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ProductService.SearchInvoked {
+		t.Fatal("expected Search() not to be invoked")
+	}
+
+	c.Key = "ADMIN"
+	if p, _, err := c.ProductService().Search(ctx, "apple", opt); err != nil {
+		t.Fatal(err)
+	} else if len(p) != 1 {
+		t.Fatalf("unexpected products: %+v", p)
+	}
+}
+
 func TestProductService_Create(t *testing.T) {
 	t.Run("OK", testProductService_CreateProduct)
 	t.Run("ErrProductRequired", testProductService_CreateProduct_ErrProductRequired)
 	t.Run("ErrProductExists", testProductService_CreateProduct_ErrProductExists)
 	t.Run("ErrProductIDRequired", testProductService_CreateProduct_ErrProductIDRequired)
+	t.Run("ErrUnauthorized", testProductService_CreateProduct_ErrUnauthorized)
+	t.Run("ErrInvalidPrice", testProductService_CreateProduct_ErrInvalidPrice)
+	t.Run("ErrCategoryNotFound", testProductService_CreateProduct_ErrCategoryNotFound)
 	t.Run("ErrInternal", testProductService_Products_ErrInternal)
 }
 
 func testProductService_CreateProduct(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock server.
-	s.Handler.ProductHandler.ProductService.CreateProductFn = func(p *fruit.Product) error {
-		if !reflect.DeepEqual(p, &fruit.Product{ID: "XXX", Token: "TOKEN"}) {
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
+		if !reflect.DeepEqual(p, &fruit.Product{ID: "XXX", Token: "TOKEN", Price: &fruit.Money{Amount: 1299, Currency: "USD"}}) {
 			t.Fatalf("unexpected product: %v", p)
 		}
 
@@ -168,64 +396,112 @@ func testProductService_CreateProduct(t *testing.T) {
 		return nil
 	}
 
-	p := &fruit.Product{ID: "XXX", Token: "TOKEN"}
+	p := &fruit.Product{ID: "XXX", Token: "TOKEN", Price: &fruit.Money{Amount: 1299, Currency: "USD"}}
 
 	// Create product.
-	err := c.ProductService().CreateProduct(p)
+	err := c.ProductService().CreateProduct(ctx, p)
 	if err != nil {
 		t.Fatal(err)
-	} else if !reflect.DeepEqual(p, &fruit.Product{ID: "XXX", Token: "TOKEN", ModTime: Now}) {
+	} else if !reflect.DeepEqual(p, &fruit.Product{ID: "XXX", Token: "TOKEN", Price: &fruit.Money{Amount: 1299, Currency: "USD"}, ModTime: Now}) {
 		t.Fatalf("unexpected product: %v", p)
 	}
 }
 
 func testProductService_CreateProduct_ErrProductRequired(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
-	s.Handler.ProductHandler.ProductService.CreateProductFn = func(p *fruit.Product) error {
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
 		return fruit.ErrProductRequired
 	}
 
-	if err := c.ProductService().CreateProduct(nil); err != fruit.ErrProductRequired {
+	if err := c.ProductService().CreateProduct(ctx, nil); err != fruit.ErrProductRequired {
 		t.Fatal(err)
 	}
 }
 
 func testProductService_CreateProduct_ErrProductExists(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
-	s.Handler.ProductHandler.ProductService.CreateProductFn = func(p *fruit.Product) error {
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
 		return fruit.ErrProductExists
 	}
 
-	if err := c.ProductService().CreateProduct(&fruit.Product{ID: "XXX"}); err != fruit.ErrProductExists {
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "XXX"}); err != fruit.ErrProductExists {
 		t.Fatal(err)
 	}
 }
 
 func testProductService_CreateProduct_ErrProductIDRequired(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
-	s.Handler.ProductHandler.ProductService.CreateProductFn = func(p *fruit.Product) error {
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
 		return fruit.ErrProductIDRequired
 	}
 
-	if err := c.ProductService().CreateProduct(&fruit.Product{}); err != fruit.ErrProductIDRequired {
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{}); err != fruit.ErrProductIDRequired {
 		t.Fatal(err)
 	}
 }
+func testProductService_CreateProduct_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
+		return fruit.ErrUnauthorized
+	}
+
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "XXX"}); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_CreateProduct_ErrInvalidPrice(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
+		return fruit.ErrInvalidPrice
+	}
+
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "XXX", Token: "TOKEN", Price: &fruit.Money{Amount: -1, Currency: "USD"}}); err != fruit.ErrInvalidPrice {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_CreateProduct_ErrCategoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
+		return fruit.ErrCategoryNotFound
+	}
+
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "XXX", Token: "TOKEN", CategoryID: "X"}); err != fruit.ErrCategoryNotFound {
+		t.Fatal(err)
+	}
+}
+
 func testProductService_CreateProduct_ErrInternal(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
-	s.Handler.ProductHandler.ProductService.CreateProductFn = func(p *fruit.Product) error {
+	s.Handler.ProductHandler.ProductService.CreateProductFn = func(ctx context.Context, p *fruit.Product) error {
 		return errors.New("marker")
 	}
 
-	if err := c.ProductService().CreateProduct(&fruit.Product{}); err != fruit.ErrInternal {
+	if err := c.ProductService().CreateProduct(ctx, &fruit.Product{}); err != fruit.ErrInternal {
 		t.Fatal(err)
 	}
 }
@@ -233,15 +509,21 @@ func testProductService_CreateProduct_ErrInternal(t *testing.T) {
 func TestProductService_UpdateProduct(t *testing.T) {
 	t.Run("OK", testProductService_UpdateProduct)
 	t.Run("NotFound", testProductService_UpdateProduct_ErrProductNotFound)
+	t.Run("ErrUnauthorized", testProductService_UpdateProduct_ErrUnauthorized)
+	t.Run("ErrConflict", testProductService_UpdateProduct_ErrConflict)
+	t.Run("ErrInvalidETag", testProductService_UpdateProduct_ErrInvalidETag)
+	t.Run("ErrVersionRequired", testProductService_UpdateProduct_ErrVersionRequired)
+	t.Run("ErrCategoryNotFound", testProductService_UpdateProduct_ErrCategoryNotFound)
 	t.Run("ErrInternal", testProductService_UpdateProduct_ErrInternal)
 }
 
 func testProductService_UpdateProduct(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock server.
-	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(id fruit.ProductID, p *fruit.Product) error {
+	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
 		// Update mod time.
 		p.ModTime = Now
 		p.ID = id
@@ -249,100 +531,656 @@ func testProductService_UpdateProduct(t *testing.T) {
 		return nil
 	}
 
-	p := &fruit.Product{Token: "TOKEN"}
+	p := &fruit.Product{Token: "TOKEN", Version: 1}
 
 	// Update product.
-	err := c.ProductService().UpdateProduct(fruit.ProductID("XXX"), p)
+	err := c.ProductService().UpdateProduct(ctx, fruit.ProductID("XXX"), p)
 	if err != nil {
 		t.Fatal(err)
 	} else if p.ID != "XXX" {
 		t.Fatalf("product failed to update: %v", p)
+	} else if p.Token != "TOKEN" {
+		t.Fatalf("unexpected token: %s", p.Token)
+	}
+}
+
+func testProductService_UpdateProduct_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock server.
+	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
+		if p.Token != "TOKEN" {
+			t.Fatalf("unexpected token: %s", p.Token)
+		}
+		return fruit.ErrUnauthorized
+	}
+
+	// Update product.
+	err := c.ProductService().UpdateProduct(ctx, "XXX", &fruit.Product{Token: "TOKEN", Version: 1})
+	if err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_UpdateProduct_ErrConflict(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock server.
+	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
+		if p.Version != 2 {
+			t.Fatalf("unexpected version: %d", p.Version)
+		}
+		return fruit.ErrConflict
+	}
+
+	// Update product.
+	err := c.ProductService().UpdateProduct(ctx, "XXX", &fruit.Product{Version: 2})
+	if err != fruit.ErrConflict {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_UpdateProduct_ErrInvalidETag(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// The client always sends a strong tag, so build the request by hand.
+	u := c.URL
+	u.Path = "/api/products"
+	req, err := nethttp.NewRequest("PUT", u.String(), strings.NewReader(`{"id":"XXX","product":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("If-Match", `W/"2"`)
+
+	if resp, err := nethttp.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != nethttp.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if s.Handler.ProductHandler.ProductService.UpdateProductInvoked {
+		t.Fatal("unexpected UpdateProduct() invocation")
+	}
+}
+
+func testProductService_UpdateProduct_ErrVersionRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Updates without an If-Match header are rejected.
+	u := c.URL
+	u.Path = "/api/products"
+	req, err := nethttp.NewRequest("PUT", u.String(), strings.NewReader(`{"id":"XXX","token":"TOKEN","product":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err := nethttp.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != nethttp.StatusPreconditionRequired {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+
+	// The client reports a product without a version.
+	if err := c.ProductService().UpdateProduct(ctx, "XXX", &fruit.Product{Token: "TOKEN"}); err != fruit.ErrVersionRequired {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ProductService.UpdateProductInvoked {
+		t.Fatal("unexpected UpdateProduct() invocation")
 	}
 }
 
 func testProductService_UpdateProduct_ErrProductNotFound(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock server.
-	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(id fruit.ProductID, p *fruit.Product) error {
+	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
 		return fruit.ErrProductNotFound
 	}
 
 	// Update product.
-	err := c.ProductService().UpdateProduct("XXX", &fruit.Product{ID: "XXX"})
+	err := c.ProductService().UpdateProduct(ctx, "XXX", &fruit.Product{ID: "XXX", Version: 1})
 	if err != fruit.ErrProductNotFound {
 		t.Fatal(err)
 	}
 }
 
+func testProductService_UpdateProduct_ErrCategoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock server.
+	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
+		return fruit.ErrCategoryNotFound
+	}
+
+	// Update product.
+	err := c.ProductService().UpdateProduct(ctx, "XXX", &fruit.Product{ID: "XXX", CategoryID: "X", Version: 1})
+	if err != fruit.ErrCategoryNotFound {
+		t.Fatal(err)
+	}
+}
+
 func testProductService_UpdateProduct_ErrInternal(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock server.
-	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(id fruit.ProductID, p *fruit.Product) error {
+	s.Handler.ProductHandler.ProductService.UpdateProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
 		return errors.New("marker")
 	}
 
 	// Update product.
-	err := c.ProductService().UpdateProduct("XXX", &fruit.Product{ID: "XXX"})
+	err := c.ProductService().UpdateProduct(ctx, "XXX", &fruit.Product{ID: "XXX", Version: 1})
 	if err != fruit.ErrInternal {
 		t.Fatal(err)
 	}
 }
 
+func TestProductService_PatchProduct(t *testing.T) {
+	t.Run("OK", testProductService_PatchProduct)
+	t.Run("ErrConflict", testProductService_PatchProduct_ErrConflict)
+	t.Run("ErrVersionRequired", testProductService_PatchProduct_ErrVersionRequired)
+	t.Run("ErrUnsupportedMediaType", testProductService_PatchProduct_ErrUnsupportedMediaType)
+}
+
+func testProductService_PatchProduct(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock server.
+	s.Handler.ProductHandler.ProductService.PatchProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
+		if id != "XXX" {
+			t.Fatalf("unexpected id: %s", id)
+		} else if p.Token != "TOKEN" {
+			t.Fatalf("unexpected token: %s", p.Token)
+		} else if !reflect.DeepEqual(mask, fruit.FieldMask{"color", "description", "price.amount"}) {
+			t.Fatalf("unexpected mask: %v", mask)
+		} else if p.Color != "Green" || p.Description != "" || p.Price.Amount != 50 {
+			t.Fatalf("unexpected product: %+v", p)
+		}
+
+		// Return the whole product.
+		p.ID, p.Name, p.Version = id, "Apple", 3
+		return nil
+	}
+
+	// Blank fields in the mask are sent as null so they're removed.
+	p := &fruit.Product{Token: "TOKEN", Color: "Green", Price: &fruit.Money{Amount: 50}, Version: 2}
+	if err := c.ProductService().PatchProduct(ctx, "XXX", p, fruit.FieldMask{"color", "description", "price.amount"}); err != nil {
+		t.Fatal(err)
+	} else if p.ID != "XXX" || p.Name != "Apple" || p.Version != 3 {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if p.Token != "TOKEN" {
+		t.Fatalf("unexpected token: %s", p.Token)
+	}
+}
+
+func testProductService_PatchProduct_ErrConflict(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock server.
+	s.Handler.ProductHandler.ProductService.PatchProductFn = func(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
+		if p.Version != 2 {
+			t.Fatalf("unexpected version: %d", p.Version)
+		}
+		return fruit.ErrConflict
+	}
+
+	err := c.ProductService().PatchProduct(ctx, "XXX", &fruit.Product{Name: "Apple", Version: 2}, fruit.FieldMask{"name"})
+	if err != fruit.ErrConflict {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_PatchProduct_ErrVersionRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	if err := c.ProductService().PatchProduct(ctx, "XXX", &fruit.Product{Token: "TOKEN", Name: "Apple"}, fruit.FieldMask{"name"}); err != fruit.ErrVersionRequired {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ProductService.PatchProductInvoked {
+		t.Fatal("unexpected PatchProduct() invocation")
+	}
+}
+
+func testProductService_PatchProduct_ErrUnsupportedMediaType(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// The client always sends a merge patch, so build the request by hand.
+	u := c.URL
+	u.Path = "/api/products/XXX"
+	req, err := nethttp.NewRequest("PATCH", u.String(), strings.NewReader(`{"name":"Apple"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	if resp, err := nethttp.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != nethttp.StatusUnsupportedMediaType {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if s.Handler.ProductHandler.ProductService.PatchProductInvoked {
+		t.Fatal("unexpected PatchProduct() invocation")
+	}
+}
+
 func TestProductService_DeleteProduct(t *testing.T) {
-	// TODO: Add Token unauthorization
 	t.Run("OK", testProductService_DeleteProduct)
 	t.Run("ErrProductNotFound", testProductService_DeleteProduct_ErrProductNotFound)
+	t.Run("ErrUnauthorized", testProductService_DeleteProduct_ErrUnauthorized)
 	t.Run("ErrInternal", testProductService_DeleteProduct_ErrInternal)
 }
 
 func testProductService_DeleteProduct(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock server.
-	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(id fruit.ProductID, token string) error {
+	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(ctx context.Context, id fruit.ProductID, token string) error {
 		return nil
 	}
 
 	// Delete product.
-	err := c.ProductService().DeleteProduct("XXX", "TOKEN")
+	err := c.ProductService().DeleteProduct(ctx, "XXX", "TOKEN")
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
 func testProductService_DeleteProduct_ErrProductNotFound(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock server.
-	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(id fruit.ProductID, token string) error {
+	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(ctx context.Context, id fruit.ProductID, token string) error {
 		return fruit.ErrProductNotFound
 	}
 
 	// Delete product.
-	err := c.ProductService().DeleteProduct("XXX", "TOKEN")
+	err := c.ProductService().DeleteProduct(ctx, "XXX", "TOKEN")
 	if err != fruit.ErrProductNotFound {
 		t.Fatal(err)
 	}
 }
 
+func testProductService_DeleteProduct_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock server.
+	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(ctx context.Context, id fruit.ProductID, token string) error {
+		if token != "WRONG" {
+			t.Fatalf("unexpected token: %s", token)
+		}
+		return fruit.ErrUnauthorized
+	}
+
+	// Delete product.
+	err := c.ProductService().DeleteProduct(ctx, "XXX", "WRONG")
+	if err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	}
+}
+
 func testProductService_DeleteProduct_ErrInternal(t *testing.T) {
+	ctx := context.Background()
 	s, c := MustOpenServerClient()
 	defer s.Close()
 
 	// Mock server.
-	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(id fruit.ProductID, token string) error {
+	s.Handler.ProductHandler.ProductService.DeleteProductFn = func(ctx context.Context, id fruit.ProductID, token string) error {
 		return errors.New("marker")
 	}
 
 	// Delete product.
-	err := c.ProductService().DeleteProduct("XXX", "TOKEN")
+	err := c.ProductService().DeleteProduct(ctx, "XXX", "TOKEN")
 	if err != fruit.ErrInternal {
 		t.Fatal(err)
 	}
 }
+
+func TestProductService_RestoreProduct(t *testing.T) {
+	t.Run("OK", testProductService_RestoreProduct)
+	t.Run("ErrProductNotDeleted", testProductService_RestoreProduct_ErrProductNotDeleted)
+	t.Run("ErrForbidden", testProductService_RestoreProduct_ErrForbidden)
+}
+
+func testProductService_RestoreProduct(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.RestoreProductFn = func(ctx context.Context, id fruit.ProductID) error {
+		if id != "XXX" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return nil
+	}
+
+	if err := c.ProductService().RestoreProduct(ctx, "XXX"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_RestoreProduct_ErrProductNotDeleted(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.RestoreProductFn = func(ctx context.Context, id fruit.ProductID) error {
+		return fruit.ErrProductNotDeleted
+	}
+
+	if err := c.ProductService().RestoreProduct(ctx, "XXX"); err != fruit.ErrProductNotDeleted {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_RestoreProduct_ErrForbidden(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if err := c.ProductService().RestoreProduct(ctx, "XXX"); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ProductService.RestoreProductInvoked {
+		t.Fatal("unexpected restore")
+	}
+}
+
+func TestProductService_PurgeProducts(t *testing.T) {
+	t.Run("OK", testProductService_PurgeProducts)
+	t.Run("ErrUnauthorized", testProductService_PurgeProducts_ErrUnauthorized)
+	t.Run("ErrInvalidTime", testProductService_PurgeProducts_ErrInvalidTime)
+}
+
+func testProductService_PurgeProducts(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	before := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s.Handler.ProductHandler.ProductService.PurgeProductsFn = func(ctx context.Context, t0 time.Time) (int, error) {
+		if !t0.Equal(before) {
+			t.Fatalf("unexpected time: %s", t0)
+		}
+		return 3, nil
+	}
+
+	if n, err := c.ProductService().PurgeProducts(ctx, before); err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Fatalf("unexpected purged: %d", n)
+	}
+}
+
+func testProductService_PurgeProducts_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	if _, err := c.ProductService().PurgeProducts(ctx, time.Now()); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ProductService.PurgeProductsInvoked {
+		t.Fatal("unexpected purge")
+	}
+}
+
+func testProductService_PurgeProducts_ErrInvalidTime(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	// The client always sends a valid time, so build the request by hand.
+	u := c.URL
+	u.Path = "/api/admin/products/trash"
+	u.RawQuery = "before=yesterday"
+	req, err := nethttp.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer ADMIN")
+
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != nethttp.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+}
+
+func TestProductService_Batch(t *testing.T) {
+	t.Run("OK", testProductService_Batch)
+	t.Run("Chunked", testProductService_Batch_Chunked)
+	t.Run("ErrBatchTooLarge", testProductService_Batch_ErrBatchTooLarge)
+	t.Run("ErrInternal", testProductService_Batch_ErrInternal)
+}
+
+func testProductService_Batch(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.BatchFn = func(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+		if !atomic {
+			t.Fatal("expected atomic batch")
+		} else if len(ops) != 2 || ops[0].Product.ID != "A" || ops[0].Token != "TOKEN" || ops[1].ID != "B" {
+			t.Fatalf("unexpected ops: %+v", ops)
+		}
+		return []fruit.BatchResult{
+			{Index: 0, ID: "A", Status: fruit.BatchCreated},
+			{Index: 1, ID: "B", Status: fruit.BatchNotFound, Err: "product not found"},
+		}, nil
+	}
+
+	results, err := c.ProductService().Batch(ctx, []fruit.BatchOp{
+		{Op: fruit.BatchCreate, Product: &fruit.Product{ID: "A", Name: "Apple"}, Token: "TOKEN"},
+		{Op: fruit.BatchDelete, ID: "B", Token: "TOKEN"},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(results, []fruit.BatchResult{
+		{Index: 0, ID: "A", Status: fruit.BatchCreated},
+		{Index: 1, ID: "B", Status: fruit.BatchNotFound, Err: "product not found"},
+	}) {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func testProductService_Batch_Chunked(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	var n int
+	s.Handler.ProductHandler.ProductService.BatchFn = func(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+		n++
+		results := make([]fruit.BatchResult, len(ops))
+		for i, op := range ops {
+			results[i] = fruit.NewBatchResult(i, op, nil)
+		}
+		return results, nil
+	}
+
+	// Large batches are sent in several requests.
+	ops := make([]fruit.BatchOp, http.MaxBatchSize+1)
+	for i := range ops {
+		ops[i] = fruit.BatchOp{Op: fruit.BatchDelete, ID: "XXX"}
+	}
+	ops[http.MaxBatchSize].ID = "YYY"
+
+	results, err := c.ProductService().Batch(ctx, ops, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("unexpected request count: %d", n)
+	} else if len(results) != len(ops) {
+		t.Fatalf("unexpected result count: %d", len(results))
+	} else if r := results[http.MaxBatchSize]; r.Index != http.MaxBatchSize || r.ID != "YYY" {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
+
+func testProductService_Batch_ErrBatchTooLarge(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Atomic batches can't be split across requests.
+	ops := make([]fruit.BatchOp, http.MaxBatchSize+1)
+	if _, err := c.ProductService().Batch(ctx, ops, true); err != http.ErrBatchTooLarge {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ProductService.BatchInvoked {
+		t.Fatal("unexpected Batch() invocation")
+	}
+}
+
+func testProductService_Batch_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.BatchFn = func(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+		return nil, errors.New("marker")
+	}
+
+	if _, err := c.ProductService().Batch(ctx, []fruit.BatchOp{{Op: fruit.BatchDelete, ID: "XXX"}}, false); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+func TestProductService_Import(t *testing.T) {
+	t.Run("OK", testProductService_Import)
+	t.Run("ErrUnauthorized", testProductService_Import_ErrUnauthorized)
+	t.Run("ErrInvalidFormat", testProductService_Import_ErrInvalidFormat)
+}
+
+func testProductService_Import(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ImportService.ImportProductsFn = func(a []*fruit.Product, dryRun bool) ([]fruit.ImportResult, error) {
+		if !dryRun {
+			t.Fatal("expected dry run")
+		} else if len(a) != 2 || a[0].ID != "A" || a[0].Name != "Apple" || a[1].Token != "TOKEN" {
+			t.Fatalf("unexpected products: %+v", a)
+		}
+		return []fruit.ImportResult{{ID: "A", Action: fruit.ImportCreated}, {ID: "B", Err: "product already exists"}}, nil
+	}
+
+	r := strings.NewReader("id,Item Name\nA,Apple\nB,Banana\n")
+	report, err := c.ProductService().(*http.ProductService).Import(ctx, r, bulk.Options{
+		Format:  bulk.CSV,
+		Mapping: map[string]string{"id": "productID", "Item Name": "name"},
+		DryRun:  true,
+		Token:   "TOKEN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(report, &bulk.Report{DryRun: true, Created: 1, Failed: 1, Errors: []fruit.ImportResult{
+		{Row: 3, ID: "B", Err: "product already exists"},
+	}}) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func testProductService_Import_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Imports need an owner token.
+	if _, err := c.ProductService().(*http.ProductService).Import(ctx, strings.NewReader(""), bulk.Options{}); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.ProductHandler.ImportService.ImportProductsInvoked {
+		t.Fatal("unexpected ImportProducts() invocation")
+	}
+}
+
+func testProductService_Import_ErrInvalidFormat(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	if _, err := c.ProductService().(*http.ProductService).Import(ctx, strings.NewReader(""), bulk.Options{Format: "xml", Token: "TOKEN"}); err != bulk.ErrInvalidFormat {
+		t.Fatal(err)
+	}
+}
+
+func TestProductService_Export(t *testing.T) {
+	t.Run("OK", testProductService_Export)
+	t.Run("ErrInternal", testProductService_Export_ErrInternal)
+}
+
+func testProductService_Export(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		if opt.Cursor == "" {
+			return []*fruit.Product{{ID: "A", Name: "Apple"}}, "1", nil
+		}
+		return []*fruit.Product{{ID: "B", Name: "Banana"}}, "", nil
+	}
+
+	var buf bytes.Buffer
+	if err := c.ProductService().(*http.ProductService).Export(ctx, &buf, bulk.Options{Format: bulk.CSV}); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "productID,name,sku,type,color,description,price,currency,categoryID\nA,Apple,,,,,,,\nB,Banana,,,,,,,\n" {
+		t.Fatalf("unexpected export: %q", buf.String())
+	}
+}
+
+func testProductService_Export_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.ProductHandler.ProductService.ProductsFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+		return nil, "", errors.New("marker")
+	}
+
+	var buf bytes.Buffer
+	if err := c.ProductService().(*http.ProductService).Export(ctx, &buf, bulk.Options{}); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+// RevisionHandler lets admins read the history of products and users and
+// revert products to earlier revisions. History names who made each change,
+// so only admins may read it.
+type RevisionHandler struct {
+	*httprouter.Router
+
+	RevisionService fruit.RevisionService
+
+	Logger *log.Logger
+}
+
+// NewRevisionHandler returns a new instance of RevisionHandler.
+func NewRevisionHandler() *RevisionHandler {
+	h := &RevisionHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.GET("/api/products/:id/history", h.handleGetProductHistory)
+	h.POST("/api/products/:id/revert", h.handlePostRevertProduct)
+	h.GET("/api/users/:id/history", h.handleGetUserHistory)
+	return h
+}
+
+// handleGetProductHistory handles requests to fetch the revisions of a
+// product.
+func (h *RevisionHandler) handleGetProductHistory(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch revisions, err := h.RevisionService.ProductRevisions(r.Context(), fruit.ProductID(ps.ByName("id"))); err {
+	case nil:
+		encodeJSON(w, &getRevisionsResponse{Revisions: revisions}, h.Logger)
+	case fruit.ErrProductNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+// handleGetUserHistory handles requests to fetch the revisions of a user.
+func (h *RevisionHandler) handleGetUserHistory(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch revisions, err := h.RevisionService.UserRevisions(r.Context(), fruit.UserID(ps.ByName("id"))); err {
+	case nil:
+		encodeJSON(w, &getRevisionsResponse{Revisions: revisions}, h.Logger)
+	case fruit.ErrUserNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type getRevisionsResponse struct {
+	Revisions []*fruit.Revision `json:"revisions,omitempty"`
+	Err       string            `json:"err,omitempty"`
+}
+
+// handlePostRevertProduct handles requests to revert a product to one of
+// its revisions. The version to revert is sent in the If-Match header.
+func (h *RevisionHandler) handlePostRevertProduct(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	// Decode request.
+	var req revertProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	version, err := requireIfMatch(r)
+	if err == fruit.ErrVersionRequired {
+		Error(w, err, http.StatusPreconditionRequired, h.Logger)
+		return
+	} else if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	// Revert product.
+	switch p, err := h.RevisionService.RevertProduct(r.Context(), fruit.ProductID(ps.ByName("id")), req.RevisionID, version); err {
+	case nil:
+		w.Header().Set("ETag", formatETag(p.Version))
+		encodeJSON(w, &revertProductResponse{Product: p}, h.Logger)
+	case fruit.ErrCategoryNotFound:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrProductNotFound, fruit.ErrRevisionNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrConflict:
+		Error(w, err, http.StatusPreconditionFailed, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type revertProductRequest struct {
+	RevisionID fruit.RevisionID `json:"revisionID"`
+}
+
+type revertProductResponse struct {
+	Product *fruit.Product `json:"product,omitempty"`
+	Err     string         `json:"err,omitempty"`
+}
+
+// RevisionService represents an HTTP implementation of
+// fruit.RevisionService.
+type RevisionService struct {
+	URL *url.URL
+	Key *string
+}
+
+// ProductRevisions returns the revisions of a product, oldest first. The
+// client's API key must belong to an admin.
+func (s *RevisionService) ProductRevisions(ctx context.Context, id fruit.ProductID) ([]*fruit.Revision, error) {
+	u := *s.URL
+	u.Path = "/api/products/" + url.PathEscape(string(id)) + "/history"
+	return s.revisions(ctx, u)
+}
+
+// UserRevisions returns the revisions of a user, oldest first. The
+// client's API key must belong to an admin.
+func (s *RevisionService) UserRevisions(ctx context.Context, id fruit.UserID) ([]*fruit.Revision, error) {
+	u := *s.URL
+	u.Path = "/api/users/" + url.PathEscape(string(id)) + "/history"
+	return s.revisions(ctx, u)
+}
+
+// revisions fetches the revisions at u.
+func (s *RevisionService) revisions(ctx context.Context, u url.URL) ([]*fruit.Revision, error) {
+	// Execute request.
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getRevisionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Revisions, nil
+}
+
+// RevertProduct sets the fields of a product back to those saved in
+// revision rev. The client's API key must belong to an admin.
+func (s *RevisionService) RevertProduct(ctx context.Context, id fruit.ProductID, rev fruit.RevisionID, version int) (*fruit.Product, error) {
+	u := *s.URL
+	u.Path = "/api/products/" + url.PathEscape(string(id)) + "/revert"
+
+	reqBody, err := json.Marshal(revertProductRequest{RevisionID: rev})
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute request, which fails if the product has changed since it was read.
+	resp, err := doConditionalRequest(ctx, http.MethodPost, u, reqBody, "application/json", version, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody revertProductResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Product, nil
+}
@@ -0,0 +1,242 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	nethttp "net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// RevisionHandler represents a test wrapper for http.RevisionHandler.
+type RevisionHandler struct {
+	*http.RevisionHandler
+
+	RevisionService mock.RevisionService
+	LogOutput       bytes.Buffer
+}
+
+func NewRevisionHandler() *RevisionHandler {
+	h := &RevisionHandler{RevisionHandler: http.NewRevisionHandler()}
+	h.RevisionHandler.RevisionService = &h.RevisionService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+func TestRevisionService_ProductRevisions(t *testing.T) {
+	t.Run("OK", testRevisionService_ProductRevisions)
+	t.Run("ErrProductNotFound", testRevisionService_ProductRevisions_ErrProductNotFound)
+	t.Run("ErrUnauthorized", testRevisionService_ProductRevisions_ErrUnauthorized)
+	t.Run("ErrForbidden", testRevisionService_ProductRevisions_ErrForbidden)
+}
+
+func testRevisionService_ProductRevisions(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	revisions := []*fruit.Revision{{
+		ID:        1,
+		Type:      fruit.EventProductUpdated,
+		ProductID: "A",
+		Actor:     "U",
+		Changes:   []fruit.FieldChange{{Field: "sku", Old: json.RawMessage(`"APL"`), New: json.RawMessage(`"APL-2"`)}},
+		Product:   &fruit.Product{ID: "A", SKU: "APL-2", ModTime: Now},
+		Time:      Now,
+	}}
+
+	// Mock service.
+	s.Handler.RevisionHandler.RevisionService.ProductRevisionsFn = func(ctx context.Context, id fruit.ProductID) ([]*fruit.Revision, error) {
+		if id != "A" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return revisions, nil
+	}
+
+	if a, err := c.RevisionService().ProductRevisions(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(a, revisions) {
+		t.Fatalf("unexpected revisions: %+v", a)
+	}
+}
+
+func testRevisionService_ProductRevisions_ErrProductNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.RevisionHandler.RevisionService.ProductRevisionsFn = func(ctx context.Context, id fruit.ProductID) ([]*fruit.Revision, error) {
+		return nil, fruit.ErrProductNotFound
+	}
+
+	if _, err := c.RevisionService().ProductRevisions(ctx, "A"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testRevisionService_ProductRevisions_ErrUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	if _, err := c.RevisionService().ProductRevisions(ctx, "A"); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.RevisionHandler.RevisionService.ProductRevisionsInvoked {
+		t.Fatal("unexpected call")
+	}
+}
+
+func testRevisionService_ProductRevisions_ErrForbidden(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.RevisionService().ProductRevisions(ctx, "A"); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	}
+}
+
+func TestRevisionService_UserRevisions(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.RevisionHandler.RevisionService.UserRevisionsFn = func(ctx context.Context, id fruit.UserID) ([]*fruit.Revision, error) {
+		if id != "U" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return []*fruit.Revision{{ID: 1, Type: fruit.EventUserDeleted, UserID: "U", Time: Now}}, nil
+	}
+
+	if a, err := c.RevisionService().UserRevisions(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].Type != fruit.EventUserDeleted {
+		t.Fatalf("unexpected revisions: %+v", a)
+	}
+}
+
+func TestRevisionService_RevertProduct(t *testing.T) {
+	t.Run("OK", testRevisionService_RevertProduct)
+	t.Run("ErrRevisionNotFound", testRevisionService_RevertProduct_ErrRevisionNotFound)
+	t.Run("ErrConflict", testRevisionService_RevertProduct_ErrConflict)
+	t.Run("ErrVersionRequired", testRevisionService_RevertProduct_ErrVersionRequired)
+	t.Run("ErrForbidden", testRevisionService_RevertProduct_ErrForbidden)
+}
+
+func testRevisionService_RevertProduct(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.RevisionHandler.RevisionService.RevertProductFn = func(ctx context.Context, id fruit.ProductID, rev fruit.RevisionID, version int) (*fruit.Product, error) {
+		if id != "A" || rev != 3 || version != 5 {
+			t.Fatalf("unexpected arguments: %s, %d, %d", id, rev, version)
+		}
+		return &fruit.Product{ID: "A", Name: "Apple", Version: 6}, nil
+	}
+
+	if p, err := c.RevisionService().RevertProduct(ctx, "A", 3, 5); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apple" || p.Version != 6 {
+		t.Fatalf("unexpected product: %+v", p)
+	}
+}
+
+func testRevisionService_RevertProduct_ErrRevisionNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.RevisionHandler.RevisionService.RevertProductFn = func(ctx context.Context, id fruit.ProductID, rev fruit.RevisionID, version int) (*fruit.Product, error) {
+		return nil, fruit.ErrRevisionNotFound
+	}
+
+	if _, err := c.RevisionService().RevertProduct(ctx, "A", 3, 1); err != fruit.ErrRevisionNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testRevisionService_RevertProduct_ErrConflict(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.RevisionHandler.RevisionService.RevertProductFn = func(ctx context.Context, id fruit.ProductID, rev fruit.RevisionID, version int) (*fruit.Product, error) {
+		return nil, fruit.ErrConflict
+	}
+
+	if _, err := c.RevisionService().RevertProduct(ctx, "A", 3, 1); err != fruit.ErrConflict {
+		t.Fatal(err)
+	}
+}
+
+func testRevisionService_RevertProduct_ErrVersionRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Reverts without an If-Match header are rejected.
+	u := c.URL
+	u.Path = "/api/products/A/revert"
+	req, err := nethttp.NewRequest("POST", u.String(), strings.NewReader(`{"revisionID":3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer ADMIN")
+
+	if resp, err := nethttp.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != nethttp.StatusPreconditionRequired {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+
+	// The client reports a revert without a version.
+	if _, err := c.RevisionService().RevertProduct(ctx, "A", 3, 0); err != fruit.ErrVersionRequired {
+		t.Fatal(err)
+	} else if s.Handler.RevisionHandler.RevisionService.RevertProductInvoked {
+		t.Fatal("unexpected revert")
+	}
+}
+
+func testRevisionService_RevertProduct_ErrForbidden(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.RevisionService().RevertProduct(ctx, "A", 3, 0); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.RevisionHandler.RevisionService.RevertProductInvoked {
+		t.Fatal("unexpected revert")
+	}
+}
@@ -62,17 +62,78 @@ func (s *Server) Port() int {
 
 // Client represents a client to connect to the HTTP server.
 type Client struct {
-	URL            url.URL
-	productService ProductService
+	URL url.URL
+
+	// API key sent with every request.
+	Key string
+
+	productService     ProductService
+	userService        UserService
+	transactionService TransactionService
+	cartService        CartService
+	orderService       OrderService
+	backupService      BackupService
+	eventService       EventService
+	webhookService     WebhookService
+	revisionService    RevisionService
 }
 
 // NewClient returns a new instance of Client.
 func NewClient() *Client {
 	c := &Client{}
 	c.productService.URL = &c.URL
+	c.productService.Key = &c.Key
+	c.userService.URL = &c.URL
+	c.userService.Key = &c.Key
+	c.transactionService.URL = &c.URL
+	c.transactionService.Key = &c.Key
+	c.cartService.URL = &c.URL
+	c.cartService.Key = &c.Key
+	c.orderService.URL = &c.URL
+	c.orderService.Key = &c.Key
+	c.backupService.URL = &c.URL
+	c.backupService.Key = &c.Key
+	c.eventService.URL = &c.URL
+	c.eventService.Key = &c.Key
+	c.webhookService.URL = &c.URL
+	c.webhookService.Key = &c.Key
+	c.revisionService.URL = &c.URL
+	c.revisionService.Key = &c.Key
 	return c
 }
 
 func (c *Client) ProductService() fruit.ProductService {
 	return &c.productService
 }
+
+func (c *Client) UserService() fruit.UserService {
+	return &c.userService
+}
+
+func (c *Client) TransactionService() fruit.TransactionService {
+	return &c.transactionService
+}
+
+func (c *Client) CartService() fruit.CartService {
+	return &c.cartService
+}
+
+func (c *Client) OrderService() fruit.OrderService {
+	return &c.orderService
+}
+
+func (c *Client) BackupService() fruit.BackupService {
+	return &c.backupService
+}
+
+func (c *Client) EventService() fruit.EventService {
+	return &c.eventService
+}
+
+func (c *Client) WebhookService() fruit.WebhookService {
+	return &c.webhookService
+}
+
+func (c *Client) RevisionService() fruit.RevisionService {
+	return &c.revisionService
+}
@@ -0,0 +1,331 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+type TransactionHandler struct {
+	*httprouter.Router
+
+	TransactionService fruit.TransactionService
+
+	Logger *log.Logger
+}
+
+// NewTransactionHandler returns a new instance of TransactionHandler.
+func NewTransactionHandler() *TransactionHandler {
+	h := &TransactionHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.POST("/api/transactions", h.handlePostTransaction)
+	h.PUT("/api/transactions", h.handlePutTransaction)
+	h.DELETE("/api/transactions", h.handleDeleteTransaction)
+
+	h.GET("/api/transactions/:id", h.handleGetTransaction)
+	h.GET("/api/users/:id/transactions", h.handleGetTransactions)
+	return h
+}
+
+// handleGetTransaction handles requests to fetch a single transaction.
+func (h *TransactionHandler) handleGetTransaction(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	switch t, err := h.TransactionService.Transaction(r.Context(), fruit.TransactionID(id)); err {
+	case nil:
+		encodeJSON(w, &getTransactionResponse{Transaction: t}, h.Logger)
+	case fruit.ErrTransactionNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type getTransactionResponse struct {
+	Transaction *fruit.Transaction `json:"transaction,omitempty"`
+	Err         string             `json:"err,omitempty"`
+}
+
+// handleGetTransactions handles requests to fetch all transactions for a user.
+func (h *TransactionHandler) handleGetTransactions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	t, err := h.TransactionService.Transactions(r.Context(), fruit.UserID(id))
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	} else {
+		encodeJSON(w, &getTransactionsResponse{Transactions: t}, h.Logger)
+	}
+}
+
+type getTransactionsResponse struct {
+	Transactions []*fruit.Transaction `json:"transactions,omitempty"`
+	Err          string               `json:"err,omitempty"`
+}
+
+// handlePostTransaction handles requests to create a new transaction.
+func (h *TransactionHandler) handlePostTransaction(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req postTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if req.Transaction == nil {
+		Error(w, fruit.ErrTransactionRequired, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	t := req.Transaction
+	t.ModTime = time.Time{}
+
+	// Create transaction.
+	switch err := h.TransactionService.CreateTransaction(r.Context(), t); err {
+	case nil:
+		encodeJSON(w, &postTransactionResponse{Transaction: t}, h.Logger)
+	case fruit.ErrTransactionRequired, fruit.ErrTransactionIDRequired:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrTransactionExists:
+		Error(w, err, http.StatusConflict, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type postTransactionRequest struct {
+	Transaction *fruit.Transaction `json:"transaction,omitempty"`
+}
+
+type postTransactionResponse struct {
+	Transaction *fruit.Transaction `json:"transaction,omitempty"`
+	Err         string             `json:"err,omitempty"`
+}
+
+// handlePutTransaction handles requests to update a transaction.
+func (h *TransactionHandler) handlePutTransaction(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req putTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if req.Transaction == nil {
+		Error(w, fruit.ErrTransactionRequired, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	t := req.Transaction
+	t.ID = req.ID
+	t.ModTime = time.Time{}
+
+	// Update transaction.
+	switch err := h.TransactionService.UpdateTransaction(r.Context(), t.ID, t); err {
+	case nil:
+		encodeJSON(w, &putTransactionResponse{Transaction: t}, h.Logger)
+	case fruit.ErrTransactionRequired, fruit.ErrTransactionIDRequired:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrTransactionNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type putTransactionRequest struct {
+	Transaction *fruit.Transaction  `json:"transaction,omitempty"`
+	ID          fruit.TransactionID `json:"id,omitempty"`
+}
+
+type putTransactionResponse struct {
+	Transaction *fruit.Transaction `json:"transaction,omitempty"`
+	Err         string             `json:"err,omitempty"`
+}
+
+// handleDeleteTransaction handles requests to delete a transaction.
+func (h *TransactionHandler) handleDeleteTransaction(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req deleteTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	// Delete transaction.
+	switch err := h.TransactionService.DeleteTransaction(r.Context(), req.ID); err {
+	case nil:
+		encodeJSON(w, &deleteTransactionResponse{}, h.Logger)
+	case fruit.ErrTransactionNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrTransactionIDRequired:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type deleteTransactionRequest struct {
+	ID fruit.TransactionID `json:"id,omitempty"`
+}
+
+type deleteTransactionResponse struct {
+	Err string `json:"err,omitempty"`
+}
+
+// TransactionService represents an HTTP implementation of fruit.TransactionService.
+type TransactionService struct {
+	URL *url.URL
+	Key *string
+}
+
+func (s *TransactionService) Transaction(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+	u := *s.URL
+	u.Path = "/api/transactions/" + url.QueryEscape(string(id))
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getTransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Transaction, nil
+}
+
+func (s *TransactionService) Transactions(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error) {
+	u := *s.URL
+	u.Path = "/api/users/" + url.QueryEscape(string(id)) + "/transactions"
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getTransactionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Transactions, nil
+}
+
+func (s *TransactionService) CreateTransaction(ctx context.Context, t *fruit.Transaction) error {
+	// Validate arguments.
+	if t == nil {
+		return fruit.ErrTransactionRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/transactions"
+
+	reqBody, err := json.Marshal(postTransactionRequest{Transaction: t})
+	if err != nil {
+		return err
+	}
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodPost, u, reqBody, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody postTransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned transaction.
+	*t = *respBody.Transaction
+	return nil
+}
+
+func (s *TransactionService) UpdateTransaction(ctx context.Context, id fruit.TransactionID, t *fruit.Transaction) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrTransactionIDRequired
+	} else if t == nil {
+		return fruit.ErrTransactionRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/transactions"
+
+	reqBody, err := json.Marshal(putTransactionRequest{Transaction: t, ID: id})
+	if err != nil {
+		return err
+	}
+
+	// Execute request.
+	resp, err := doRequest(ctx, http.MethodPut, u, reqBody, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody putTransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned transaction.
+	*t = *respBody.Transaction
+	t.ID = id
+	return nil
+}
+
+func (s *TransactionService) DeleteTransaction(ctx context.Context, id fruit.TransactionID) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/transactions"
+
+	reqBody, err := json.Marshal(deleteTransactionRequest{ID: id})
+	if err != nil {
+		return err
+	}
+
+	// Execute request.
+	resp, err := doRequest(ctx, http.MethodDelete, u, reqBody, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody deleteTransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	return nil
+}
@@ -0,0 +1,335 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// TransactionHandler represents a test wrapper for http.TransactionHandler
+type TransactionHandler struct {
+	*http.TransactionHandler
+
+	TransactionService mock.TransactionService
+	LogOutput          bytes.Buffer
+}
+
+func NewTransactionHandler() *TransactionHandler {
+	h := &TransactionHandler{TransactionHandler: http.NewTransactionHandler()}
+	h.TransactionHandler.TransactionService = &h.TransactionService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+func TestTransactionService_Transaction(t *testing.T) {
+	t.Run("OK", testTransactionService_Transaction)
+	t.Run("ErrTransactionNotFound", testTransactionService_Transaction_ErrTransactionNotFound)
+	t.Run("ErrInternal", testTransactionService_Transaction_ErrInternal)
+}
+
+func testTransactionService_Transaction(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.TransactionFn = func(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+		if id != "A" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return &fruit.Transaction{ID: "A", UserID: "U", Count: 2, Active: true}, nil
+	}
+
+	// Retrieve transaction.
+	tr, err := c.TransactionService().Transaction(ctx, "A")
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(tr, &fruit.Transaction{ID: "A", UserID: "U", Count: 2, Active: true}) {
+		t.Fatalf("unexpected transaction: %+v", tr)
+	}
+}
+
+func testTransactionService_Transaction_ErrTransactionNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.TransactionFn = func(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+		return nil, fruit.ErrTransactionNotFound
+	}
+
+	// Retrieve transaction.
+	if tr, err := c.TransactionService().Transaction(ctx, "XXX"); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	} else if tr != nil {
+		t.Fatalf("unexpected transaction: %+v", tr)
+	}
+}
+
+func testTransactionService_Transaction_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.TransactionFn = func(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+		return nil, errors.New("marker")
+	}
+
+	// Retrieve transaction.
+	if tr, err := c.TransactionService().Transaction(ctx, "XXX"); err != fruit.ErrInternal {
+		t.Fatal(err)
+	} else if tr != nil {
+		t.Fatalf("unexpected transaction: %+v", tr)
+	}
+}
+
+func TestTransactionService_Transactions(t *testing.T) {
+	t.Run("OK", testTransactionService_Transactions)
+	t.Run("ErrInternal", testTransactionService_Transactions_ErrInternal)
+}
+
+func testTransactionService_Transactions(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.TransactionsFn = func(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error) {
+		if id != "U" {
+			t.Fatalf("unexpected user id: %s", id)
+		}
+		return []*fruit.Transaction{{ID: "A", UserID: "U"}, {ID: "B", UserID: "U"}}, nil
+	}
+
+	// Retrieve transactions for the user.
+	if tr, err := c.TransactionService().Transactions(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if len(tr) != 2 {
+		t.Fatalf("expected to return two transactions but returned: %+v", tr)
+	}
+}
+
+func testTransactionService_Transactions_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.TransactionsFn = func(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error) {
+		return nil, errors.New("marker")
+	}
+
+	if tr, err := c.TransactionService().Transactions(ctx, "U"); err != fruit.ErrInternal {
+		t.Fatal(err)
+	} else if tr != nil {
+		t.Fatalf("unexpected transactions: %+v", tr)
+	}
+}
+
+func TestTransactionService_CreateTransaction(t *testing.T) {
+	t.Run("OK", testTransactionService_CreateTransaction)
+	t.Run("ErrTransactionRequired", testTransactionService_CreateTransaction_ErrTransactionRequired)
+	t.Run("ErrTransactionExists", testTransactionService_CreateTransaction_ErrTransactionExists)
+	t.Run("ErrInternal", testTransactionService_CreateTransaction_ErrInternal)
+}
+
+func testTransactionService_CreateTransaction(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.CreateTransactionFn = func(ctx context.Context, tr *fruit.Transaction) error {
+		if !reflect.DeepEqual(tr, &fruit.Transaction{ID: "XXX", UserID: "U", Count: 1}) {
+			t.Fatalf("unexpected transaction: %+v", tr)
+		}
+
+		// Update mod time.
+		tr.ModTime = Now
+
+		return nil
+	}
+
+	tr := &fruit.Transaction{ID: "XXX", UserID: "U", Count: 1}
+
+	// Create transaction.
+	if err := c.TransactionService().CreateTransaction(ctx, tr); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(tr, &fruit.Transaction{ID: "XXX", UserID: "U", Count: 1, ModTime: Now}) {
+		t.Fatalf("unexpected transaction: %+v", tr)
+	}
+}
+
+func testTransactionService_CreateTransaction_ErrTransactionRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	if err := c.TransactionService().CreateTransaction(ctx, nil); err != fruit.ErrTransactionRequired {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionService_CreateTransaction_ErrTransactionExists(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	s.Handler.TransactionHandler.TransactionService.CreateTransactionFn = func(ctx context.Context, tr *fruit.Transaction) error {
+		return fruit.ErrTransactionExists
+	}
+
+	if err := c.TransactionService().CreateTransaction(ctx, &fruit.Transaction{ID: "XXX"}); err != fruit.ErrTransactionExists {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionService_CreateTransaction_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	s.Handler.TransactionHandler.TransactionService.CreateTransactionFn = func(ctx context.Context, tr *fruit.Transaction) error {
+		return errors.New("marker")
+	}
+
+	if err := c.TransactionService().CreateTransaction(ctx, &fruit.Transaction{ID: "XXX"}); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionService_UpdateTransaction(t *testing.T) {
+	t.Run("OK", testTransactionService_UpdateTransaction)
+	t.Run("ErrTransactionNotFound", testTransactionService_UpdateTransaction_ErrTransactionNotFound)
+	t.Run("ErrInternal", testTransactionService_UpdateTransaction_ErrInternal)
+}
+
+func testTransactionService_UpdateTransaction(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.UpdateTransactionFn = func(ctx context.Context, id fruit.TransactionID, tr *fruit.Transaction) error {
+		if id != "XXX" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		tr.ModTime = Now
+		return nil
+	}
+
+	tr := &fruit.Transaction{Count: 3}
+
+	// Update transaction.
+	if err := c.TransactionService().UpdateTransaction(ctx, "XXX", tr); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(tr, &fruit.Transaction{ID: "XXX", Count: 3, ModTime: Now}) {
+		t.Fatalf("unexpected transaction: %+v", tr)
+	}
+}
+
+func testTransactionService_UpdateTransaction_ErrTransactionNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.UpdateTransactionFn = func(ctx context.Context, id fruit.TransactionID, tr *fruit.Transaction) error {
+		return fruit.ErrTransactionNotFound
+	}
+
+	if err := c.TransactionService().UpdateTransaction(ctx, "XXX", &fruit.Transaction{}); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionService_UpdateTransaction_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.UpdateTransactionFn = func(ctx context.Context, id fruit.TransactionID, tr *fruit.Transaction) error {
+		return errors.New("marker")
+	}
+
+	if err := c.TransactionService().UpdateTransaction(ctx, "XXX", &fruit.Transaction{}); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionService_DeleteTransaction(t *testing.T) {
+	t.Run("OK", testTransactionService_DeleteTransaction)
+	t.Run("ErrTransactionNotFound", testTransactionService_DeleteTransaction_ErrTransactionNotFound)
+	t.Run("ErrTransactionIDRequired", testTransactionService_DeleteTransaction_ErrTransactionIDRequired)
+	t.Run("ErrInternal", testTransactionService_DeleteTransaction_ErrInternal)
+}
+
+func testTransactionService_DeleteTransaction(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.DeleteTransactionFn = func(ctx context.Context, id fruit.TransactionID) error {
+		if id != "XXX" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return nil
+	}
+
+	if err := c.TransactionService().DeleteTransaction(ctx, "XXX"); err != nil {
+		t.Fatal(err)
+	} else if !s.Handler.TransactionHandler.TransactionService.DeleteTransactionInvoked {
+		t.Fatal("expected DeleteTransaction() to be invoked")
+	}
+}
+
+func testTransactionService_DeleteTransaction_ErrTransactionNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.DeleteTransactionFn = func(ctx context.Context, id fruit.TransactionID) error {
+		return fruit.ErrTransactionNotFound
+	}
+
+	if err := c.TransactionService().DeleteTransaction(ctx, "XXX"); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionService_DeleteTransaction_ErrTransactionIDRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	if err := c.TransactionService().DeleteTransaction(ctx, ""); err != fruit.ErrTransactionIDRequired {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionService_DeleteTransaction_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+
+	// Mock service.
+	s.Handler.TransactionHandler.TransactionService.DeleteTransactionFn = func(ctx context.Context, id fruit.TransactionID) error {
+		return errors.New("marker")
+	}
+
+	if err := c.TransactionService().DeleteTransaction(ctx, "XXX"); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
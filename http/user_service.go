@@ -0,0 +1,475 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+// UserHandler serves user records. Users hold payment and address details,
+// so callers may only reach their own record unless they are an admin.
+type UserHandler struct {
+	*httprouter.Router
+
+	UserService fruit.UserService
+
+	Logger *log.Logger
+}
+
+// NewUserHandler returns a new instance of UserHandler.
+func NewUserHandler() *UserHandler {
+	h := &UserHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.GET("/api/users", h.handleGetUsers)
+	h.POST("/api/users", h.handlePostUser)
+	h.PUT("/api/users", h.handlePutUser)
+	h.DELETE("/api/users", h.handleDeleteUser)
+
+	h.GET("/api/users/:id", h.handleGetUser)
+	h.PATCH("/api/users/:id", h.handlePatchUser)
+	return h
+}
+
+// handleGetUser handles requests to fetch a single user.
+func (h *UserHandler) handleGetUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+
+	u, err := h.UserService.User(r.Context(), id)
+	switch {
+	case err == fruit.ErrUserNotFound, err == nil && u == nil:
+		Error(w, fruit.ErrUserNotFound, http.StatusNotFound, h.Logger)
+	case err != nil:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	default:
+		w.Header().Set("ETag", formatETag(u.Version))
+		encodeJSON(w, &getUserResponse{User: u}, h.Logger)
+	}
+}
+
+type getUserResponse struct {
+	User *fruit.User `json:"user,omitempty"`
+	Err  string      `json:"err,omitempty"`
+}
+
+// handleGetUsers handles requests to fetch a page of users.
+func (h *UserHandler) handleGetUsers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	opt, err := parseQueryOptions(r.URL.Query())
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	switch u, next, err := h.UserService.Users(r.Context(), opt); err {
+	case nil:
+		encodeJSON(w, &getUsersResponse{Users: u, NextCursor: next}, h.Logger)
+	case fruit.ErrInvalidLimit, fruit.ErrInvalidCursor, fruit.ErrInvalidSort:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type getUsersResponse struct {
+	Users      []*fruit.User `json:"users,omitempty"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	Err        string        `json:"err,omitempty"`
+}
+
+// handlePostUser handles requests to create a new user.
+func (h *UserHandler) handlePostUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req postUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if req.User == nil {
+		Error(w, fruit.ErrUserRequired, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	u := req.User
+	u.ModTime = time.Time{}
+
+	// Callers create their own user unless they are an admin.
+	if u.ID == "" {
+		if p := fruit.PrincipalFromContext(r.Context()); p != nil {
+			u.ID = p.UserID
+		}
+	}
+	if !authorizeUser(w, r, u.ID, h.Logger) {
+		return
+	}
+
+	// Create user.
+	switch err := h.UserService.CreateUser(r.Context(), u); err {
+	case nil:
+		encodeJSON(w, &postUserResponse{User: u}, h.Logger)
+	case fruit.ErrUserRequired, fruit.ErrUserIDRequired:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrUserExists:
+		Error(w, err, http.StatusConflict, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type postUserRequest struct {
+	User *fruit.User `json:"user,omitempty"`
+}
+
+type postUserResponse struct {
+	User *fruit.User `json:"user,omitempty"`
+	Err  string      `json:"err,omitempty"`
+}
+
+// handlePutUser handles requests to update a user.
+func (h *UserHandler) handlePutUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req putUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if req.User == nil {
+		Error(w, fruit.ErrUserRequired, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	u := req.User
+	u.ID = req.ID
+	u.ModTime = time.Time{}
+	if !authorizeUser(w, r, u.ID, h.Logger) {
+		return
+	}
+
+	// The version to update must be sent in the If-Match header.
+	version, err := requireIfMatch(r)
+	if err == fruit.ErrVersionRequired {
+		Error(w, err, http.StatusPreconditionRequired, h.Logger)
+		return
+	} else if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+	u.Version = version
+
+	// Update user.
+	switch err := h.UserService.UpdateUser(r.Context(), u.ID, u); err {
+	case nil:
+		w.Header().Set("ETag", formatETag(u.Version))
+		encodeJSON(w, &putUserResponse{User: u}, h.Logger)
+	case fruit.ErrConflict:
+		Error(w, err, http.StatusPreconditionFailed, h.Logger)
+	case fruit.ErrUserRequired, fruit.ErrUserIDRequired:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrUserNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type putUserRequest struct {
+	User *fruit.User  `json:"user,omitempty"`
+	ID   fruit.UserID `json:"id,omitempty"`
+}
+
+type putUserResponse struct {
+	User *fruit.User `json:"user,omitempty"`
+	Err  string      `json:"err,omitempty"`
+}
+
+// handlePatchUser handles requests to change some fields of a user. The
+// body is a JSON Merge Patch of the user.
+func (h *UserHandler) handlePatchUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := fruit.UserID(ps.ByName("id"))
+	if !authorizeUser(w, r, id, h.Logger) {
+		return
+	}
+
+	// Decode request.
+	var u fruit.User
+	mask, err := decodeMergePatch(r, &u)
+	if err == ErrUnsupportedMediaType {
+		Error(w, err, http.StatusUnsupportedMediaType, h.Logger)
+		return
+	} else if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	// The version to update must be sent in the If-Match header.
+	version, err := requireIfMatch(r)
+	if err == fruit.ErrVersionRequired {
+		Error(w, err, http.StatusPreconditionRequired, h.Logger)
+		return
+	} else if err != nil {
+		Error(w, err, http.StatusBadRequest, h.Logger)
+		return
+	}
+	u.Version = version
+
+	// Patch user.
+	switch err := h.UserService.PatchUser(r.Context(), id, &u, mask); err {
+	case nil:
+		w.Header().Set("ETag", formatETag(u.Version))
+		encodeJSON(w, &patchUserResponse{User: &u}, h.Logger)
+	case fruit.ErrInvalidField:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrUserNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrConflict:
+		Error(w, err, http.StatusPreconditionFailed, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type patchUserResponse struct {
+	User *fruit.User `json:"user,omitempty"`
+	Err  string      `json:"err,omitempty"`
+}
+
+// handleDeleteUser handles requests to delete a user.
+func (h *UserHandler) handleDeleteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var req deleteUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if !authorizeUser(w, r, req.ID, h.Logger) {
+		return
+	}
+
+	// Delete user.
+	switch err := h.UserService.DeleteUser(r.Context(), req.ID); err {
+	case nil:
+		encodeJSON(w, &deleteUserResponse{}, h.Logger)
+	case fruit.ErrUserNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	case fruit.ErrUserIDRequired:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type deleteUserRequest struct {
+	ID fruit.UserID `json:"id,omitempty"`
+}
+
+type deleteUserResponse struct {
+	Err string `json:"err,omitempty"`
+}
+
+// UserService represents an HTTP implementation of fruit.UserService.
+type UserService struct {
+	URL *url.URL
+	Key *string
+}
+
+func (s *UserService) User(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+	u := *s.URL
+	u.Path = "/api/users/" + url.QueryEscape(string(id))
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	} else if respBody.User == nil {
+		return nil, nil
+	}
+
+	// Read the version from the entity tag.
+	if version, err := parseETag(resp.Header.Get("ETag")); err == nil {
+		respBody.User.Version = version
+	}
+	return respBody.User, nil
+}
+
+func (s *UserService) Users(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error) {
+	u := *s.URL
+	u.Path = "/api/users"
+	u.RawQuery = encodeQueryOptions(opt).Encode()
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodGet, u, nil, s.Key)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody getUsersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, "", err
+	} else if respBody.Err != "" {
+		return nil, "", fruit.Error(respBody.Err)
+	}
+	return respBody.Users, respBody.NextCursor, nil
+}
+
+func (s *UserService) CreateUser(ctx context.Context, user *fruit.User) error {
+	// Validate arguments.
+	if user == nil {
+		return fruit.ErrUserRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/users"
+
+	reqBody, err := json.Marshal(postUserRequest{User: user})
+	if err != nil {
+		return err
+	}
+
+	// Execute the request.
+	resp, err := doRequest(ctx, http.MethodPost, u, reqBody, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody postUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned user.
+	*user = *respBody.User
+	return nil
+}
+
+func (s *UserService) UpdateUser(ctx context.Context, id fruit.UserID, user *fruit.User) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrUserIDRequired
+	} else if user == nil {
+		return fruit.ErrUserRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/users"
+
+	reqBody, err := json.Marshal(putUserRequest{User: user, ID: id})
+	if err != nil {
+		return err
+	}
+
+	// Execute request, which fails if the user has changed since it was read.
+	resp, err := doConditionalRequest(ctx, http.MethodPut, u, reqBody, "application/json", user.Version, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody putUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned user.
+	*user = *respBody.User
+	user.ID = id
+	return nil
+}
+
+// PatchUser sends the fields of user named in mask as a JSON Merge Patch.
+func (s *UserService) PatchUser(ctx context.Context, id fruit.UserID, user *fruit.User, mask fruit.FieldMask) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrUserIDRequired
+	} else if user == nil {
+		return fruit.ErrUserRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/users/" + url.QueryEscape(string(id))
+
+	reqBody, err := encodeMergePatch(user, mask)
+	if err != nil {
+		return err
+	}
+
+	// Execute request, which fails if the user has changed since it was read.
+	resp, err := doConditionalRequest(ctx, http.MethodPatch, u, reqBody, MergePatchContentType, user.Version, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody patchUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned user.
+	*user = *respBody.User
+	return nil
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, id fruit.UserID) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrUserIDRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/users"
+
+	reqBody, err := json.Marshal(deleteUserRequest{ID: id})
+	if err != nil {
+		return err
+	}
+
+	// Execute request.
+	resp, err := doRequest(ctx, http.MethodDelete, u, reqBody, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	var respBody deleteUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	return nil
+}
@@ -0,0 +1,526 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// UserHandler represents a test wrapper for http.UserHandler
+type UserHandler struct {
+	*http.UserHandler
+
+	UserService mock.UserService
+	LogOutput   bytes.Buffer
+}
+
+func NewUserHandler() *UserHandler {
+	h := &UserHandler{UserHandler: http.NewUserHandler()}
+	h.UserHandler.UserService = &h.UserService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+func TestUserService_User(t *testing.T) {
+	t.Run("OK", testUserService_User)
+	t.Run("ErrUserNotFound", testUserService_User_ErrUserNotFound)
+	t.Run("ErrInternal", testUserService_User_ErrInternal)
+}
+
+func testUserService_User(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UserFn = func(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+		if id != "A" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return &fruit.User{ID: "A", Name: "NAME"}, nil
+	}
+
+	// Retrieve user.
+	u, err := c.UserService().User(ctx, "A")
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(u, &fruit.User{ID: "A", Name: "NAME"}) {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+func testUserService_User_ErrUserNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UserFn = func(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+		return nil, fruit.ErrUserNotFound
+	}
+
+	// Retrieve user.
+	if u, err := c.UserService().User(ctx, "NO SUCH USER"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	} else if u != nil {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+func testUserService_User_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UserFn = func(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+		return nil, errors.New("marker")
+	}
+
+	// Retrieve user.
+	if u, err := c.UserService().User(ctx, "XXX"); err != fruit.ErrInternal {
+		t.Fatal(err)
+	} else if u != nil {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+func TestUserService_Users(t *testing.T) {
+	t.Run("OK", testUserService_Users)
+	t.Run("ErrInternal", testUserService_Users_ErrInternal)
+}
+
+func testUserService_Users(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UsersFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error) {
+		if !reflect.DeepEqual(opt, fruit.QueryOptions{Limit: 2, Cursor: "4", Sort: "name", Desc: true}) {
+			t.Fatalf("unexpected options: %+v", opt)
+		}
+		return []*fruit.User{{ID: "A"}, {ID: "B"}}, "6", nil
+	}
+
+	if u, next, err := c.UserService().Users(ctx, fruit.QueryOptions{Limit: 2, Cursor: "4", Sort: "name", Desc: true}); err != nil {
+		t.Fatal(err)
+	} else if len(u) != 2 {
+		t.Fatalf("expected to return two users but returned: %+v", u)
+	} else if next != "6" {
+		t.Fatalf("unexpected next cursor: %s", next)
+	}
+}
+
+func testUserService_Users_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UsersFn = func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error) {
+		return nil, "", errors.New("marker")
+	}
+
+	if u, _, err := c.UserService().Users(ctx, fruit.QueryOptions{}); err != fruit.ErrInternal {
+		t.Fatal(err)
+	} else if u != nil {
+		t.Fatalf("unexpected users: %+v", u)
+	}
+}
+
+func TestUserService_CreateUser(t *testing.T) {
+	t.Run("OK", testUserService_CreateUser)
+	t.Run("ErrUserRequired", testUserService_CreateUser_ErrUserRequired)
+	t.Run("ErrUserExists", testUserService_CreateUser_ErrUserExists)
+	t.Run("ErrUserIDRequired", testUserService_CreateUser_ErrUserIDRequired)
+	t.Run("ErrInternal", testUserService_CreateUser_ErrInternal)
+}
+
+func testUserService_CreateUser(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.CreateUserFn = func(ctx context.Context, u *fruit.User) error {
+		if !reflect.DeepEqual(u, &fruit.User{ID: "XXX", Name: "NAME"}) {
+			t.Fatalf("unexpected user: %+v", u)
+		}
+
+		// Update mod time.
+		u.ModTime = Now
+
+		return nil
+	}
+
+	u := &fruit.User{ID: "XXX", Name: "NAME"}
+
+	// Create user.
+	if err := c.UserService().CreateUser(ctx, u); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(u, &fruit.User{ID: "XXX", Name: "NAME", ModTime: Now}) {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+func testUserService_CreateUser_ErrUserRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	if err := c.UserService().CreateUser(ctx, nil); err != fruit.ErrUserRequired {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_CreateUser_ErrUserExists(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.UserHandler.UserService.CreateUserFn = func(ctx context.Context, u *fruit.User) error {
+		return fruit.ErrUserExists
+	}
+
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "XXX"}); err != fruit.ErrUserExists {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_CreateUser_ErrUserIDRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.UserHandler.UserService.CreateUserFn = func(ctx context.Context, u *fruit.User) error {
+		return fruit.ErrUserIDRequired
+	}
+
+	if err := c.UserService().CreateUser(ctx, &fruit.User{}); err != fruit.ErrUserIDRequired {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_CreateUser_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.UserHandler.UserService.CreateUserFn = func(ctx context.Context, u *fruit.User) error {
+		return errors.New("marker")
+	}
+
+	if err := c.UserService().CreateUser(ctx, &fruit.User{}); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+func TestUserService_UpdateUser(t *testing.T) {
+	t.Run("OK", testUserService_UpdateUser)
+	t.Run("ErrUserNotFound", testUserService_UpdateUser_ErrUserNotFound)
+	t.Run("ErrConflict", testUserService_UpdateUser_ErrConflict)
+	t.Run("ErrVersionRequired", testUserService_UpdateUser_ErrVersionRequired)
+	t.Run("ErrInternal", testUserService_UpdateUser_ErrInternal)
+}
+
+func testUserService_UpdateUser(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UpdateUserFn = func(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+		if id != "XXX" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		u.ModTime = Now
+		return nil
+	}
+
+	u := &fruit.User{Name: "NAME", Version: 1}
+
+	// Update user.
+	if err := c.UserService().UpdateUser(ctx, "XXX", u); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(u, &fruit.User{ID: "XXX", Name: "NAME", Version: 1, ModTime: Now}) {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+func testUserService_UpdateUser_ErrUserNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UpdateUserFn = func(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+		return fruit.ErrUserNotFound
+	}
+
+	if err := c.UserService().UpdateUser(ctx, "XXX", &fruit.User{Version: 1}); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_UpdateUser_ErrConflict(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UpdateUserFn = func(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+		if u.Version != 2 {
+			t.Fatalf("unexpected version: %d", u.Version)
+		}
+		return fruit.ErrConflict
+	}
+
+	if err := c.UserService().UpdateUser(ctx, "XXX", &fruit.User{Version: 2}); err != fruit.ErrConflict {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_UpdateUser_ErrVersionRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	if err := c.UserService().UpdateUser(ctx, "XXX", &fruit.User{Name: "NAME"}); err != fruit.ErrVersionRequired {
+		t.Fatal(err)
+	} else if s.Handler.UserHandler.UserService.UpdateUserInvoked {
+		t.Fatal("unexpected UpdateUser() invocation")
+	}
+}
+
+func testUserService_UpdateUser_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UpdateUserFn = func(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+		return errors.New("marker")
+	}
+
+	if err := c.UserService().UpdateUser(ctx, "XXX", &fruit.User{Version: 1}); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+func TestUserService_PatchUser(t *testing.T) {
+	t.Run("OK", testUserService_PatchUser)
+	t.Run("ErrInvalidField", testUserService_PatchUser_ErrInvalidField)
+}
+
+func testUserService_PatchUser(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.PatchUserFn = func(ctx context.Context, id fruit.UserID, u *fruit.User, mask fruit.FieldMask) error {
+		if id != "XXX" {
+			t.Fatalf("unexpected id: %s", id)
+		} else if !reflect.DeepEqual(mask, fruit.FieldMask{"address.city"}) {
+			t.Fatalf("unexpected mask: %v", mask)
+		} else if u.Address.City != "Shelbyville" {
+			t.Fatalf("unexpected address: %+v", u.Address)
+		}
+
+		// Return the whole user.
+		u.ID, u.Name, u.Address.Line1 = id, "NAME", "1 Main St"
+		return nil
+	}
+
+	u := &fruit.User{Address: &fruit.Address{City: "Shelbyville"}, Version: 1}
+	if err := c.UserService().PatchUser(ctx, "XXX", u, fruit.FieldMask{"address.city"}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(u, &fruit.User{ID: "XXX", Name: "NAME", Address: &fruit.Address{Line1: "1 Main St", City: "Shelbyville"}, Version: 1}) {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+}
+
+func testUserService_PatchUser_ErrInvalidField(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.PatchUserFn = func(ctx context.Context, id fruit.UserID, u *fruit.User, mask fruit.FieldMask) error {
+		return fruit.ErrInvalidField
+	}
+
+	if err := c.UserService().PatchUser(ctx, "XXX", &fruit.User{Version: 1}, fruit.FieldMask{"userID"}); err != fruit.ErrInvalidField {
+		t.Fatal(err)
+	}
+}
+
+func TestUserService_DeleteUser(t *testing.T) {
+	t.Run("OK", testUserService_DeleteUser)
+	t.Run("ErrUserNotFound", testUserService_DeleteUser_ErrUserNotFound)
+	t.Run("ErrUserIDRequired", testUserService_DeleteUser_ErrUserIDRequired)
+	t.Run("ErrInternal", testUserService_DeleteUser_ErrInternal)
+}
+
+func testUserService_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.DeleteUserFn = func(ctx context.Context, id fruit.UserID) error {
+		if id != "XXX" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return nil
+	}
+
+	if err := c.UserService().DeleteUser(ctx, "XXX"); err != nil {
+		t.Fatal(err)
+	} else if !s.Handler.UserHandler.UserService.DeleteUserInvoked {
+		t.Fatal("expected DeleteUser() to be invoked")
+	}
+}
+
+func testUserService_DeleteUser_ErrUserNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.DeleteUserFn = func(ctx context.Context, id fruit.UserID) error {
+		return fruit.ErrUserNotFound
+	}
+
+	if err := c.UserService().DeleteUser(ctx, "XXX"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_DeleteUser_ErrUserIDRequired(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	if err := c.UserService().DeleteUser(ctx, ""); err != fruit.ErrUserIDRequired {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_DeleteUser_ErrInternal(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.DeleteUserFn = func(ctx context.Context, id fruit.UserID) error {
+		return errors.New("marker")
+	}
+
+	if err := c.UserService().DeleteUser(ctx, "XXX"); err != fruit.ErrInternal {
+		t.Fatal(err)
+	}
+}
+
+// Ensure callers may only reach their own user unless they are an admin.
+func TestUserService_Authorize(t *testing.T) {
+	ctx := context.Background()
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	// Mock service.
+	s.Handler.UserHandler.UserService.UserFn = func(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+		return &fruit.User{ID: id}, nil
+	}
+	s.Handler.UserHandler.UserService.CreateUserFn = func(ctx context.Context, u *fruit.User) error {
+		return nil
+	}
+
+	// Anonymous callers can't read users.
+	if _, err := c.UserService().User(ctx, "U"); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	}
+
+	// Users may read their own record, and create it without giving an id.
+	c.Key = "USER"
+	if u, err := c.UserService().User(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if u.ID != "U" {
+		t.Fatalf("unexpected user: %+v", u)
+	}
+	u := &fruit.User{Name: "NAME"}
+	if err := c.UserService().CreateUser(ctx, u); err != nil {
+		t.Fatal(err)
+	} else if u.ID != "U" {
+		t.Fatalf("unexpected user id: %s", u.ID)
+	}
+
+	// Other users and the user list are forbidden.
+	for _, err := range []error{
+		func() error { _, err := c.UserService().User(ctx, "OTHER"); return err }(),
+		func() error { _, _, err := c.UserService().Users(ctx, fruit.QueryOptions{}); return err }(),
+		c.UserService().CreateUser(ctx, &fruit.User{ID: "OTHER"}),
+		c.UserService().UpdateUser(ctx, "OTHER", &fruit.User{Version: 1}),
+		c.UserService().PatchUser(ctx, "OTHER", &fruit.User{Version: 1}, fruit.FieldMask{"name"}),
+		c.UserService().DeleteUser(ctx, "OTHER"),
+	} {
+		if err != fruit.ErrForbidden {
+			t.Errorf("unexpected error: got %v, want %v", err, fruit.ErrForbidden)
+		}
+	}
+	if s.Handler.UserHandler.UserService.UsersInvoked || s.Handler.UserHandler.UserService.UpdateUserInvoked ||
+		s.Handler.UserHandler.UserService.PatchUserInvoked || s.Handler.UserHandler.UserService.DeleteUserInvoked {
+		t.Fatal("expected user service not to be invoked")
+	}
+}
@@ -0,0 +1,409 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/notjrbauer/fruit"
+)
+
+// WebhookHandler lets admins manage webhooks and their deliveries. Webhook
+// payloads are only visible to admins.
+type WebhookHandler struct {
+	*httprouter.Router
+
+	WebhookService fruit.WebhookService
+
+	Logger *log.Logger
+}
+
+// NewWebhookHandler returns a new instance of WebhookHandler.
+func NewWebhookHandler() *WebhookHandler {
+	h := &WebhookHandler{
+		Router: httprouter.New(),
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	h.GET("/api/admin/webhooks", h.handleGetWebhooks)
+	h.POST("/api/admin/webhooks", h.handlePostWebhook)
+
+	h.GET("/api/admin/webhooks/:id", h.handleGetWebhook)
+	h.PUT("/api/admin/webhooks/:id", h.handlePutWebhook)
+	h.DELETE("/api/admin/webhooks/:id", h.handleDeleteWebhook)
+
+	h.GET("/api/admin/webhooks/:id/deliveries", h.handleGetDeliveries)
+	h.POST("/api/admin/webhooks/:id/replay", h.handlePostReplayFailed)
+	h.POST("/api/admin/deliveries/:id/replay", h.handlePostReplayDelivery)
+	return h
+}
+
+// handleGetWebhook handles requests to fetch a single webhook.
+func (h *WebhookHandler) handleGetWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch webhook, err := h.WebhookService.Webhook(fruit.WebhookID(ps.ByName("id"))); err {
+	case nil:
+		encodeJSON(w, &getWebhookResponse{Webhook: webhook}, h.Logger)
+	case fruit.ErrWebhookNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type getWebhookResponse struct {
+	Webhook *fruit.Webhook `json:"webhook,omitempty"`
+	Err     string         `json:"err,omitempty"`
+}
+
+// handleGetWebhooks handles requests to fetch every webhook.
+func (h *WebhookHandler) handleGetWebhooks(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch webhooks, err := h.WebhookService.Webhooks(); err {
+	case nil:
+		encodeJSON(w, &getWebhooksResponse{Webhooks: webhooks}, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type getWebhooksResponse struct {
+	Webhooks []*fruit.Webhook `json:"webhooks,omitempty"`
+	Err      string           `json:"err,omitempty"`
+}
+
+// handlePostWebhook handles requests to create a new webhook.
+func (h *WebhookHandler) handlePostWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	// Decode request.
+	var req postWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if req.Webhook == nil {
+		Error(w, fruit.ErrWebhookRequired, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	webhook := req.Webhook
+	webhook.Secret = req.Secret
+	webhook.ModTime = time.Time{}
+
+	// Create webhook. This is the only response which includes the secret.
+	switch err := h.WebhookService.CreateWebhook(webhook); err {
+	case nil:
+		encodeJSON(w, &postWebhookResponse{Webhook: webhook, Secret: webhook.Secret}, h.Logger)
+	case fruit.ErrWebhookRequired, fruit.ErrInvalidWebhookURL, fruit.ErrInvalidEventType:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrWebhookExists:
+		Error(w, err, http.StatusConflict, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type postWebhookRequest struct {
+	Webhook *fruit.Webhook `json:"webhook,omitempty"`
+	Secret  string         `json:"secret,omitempty"`
+}
+
+type postWebhookResponse struct {
+	Webhook *fruit.Webhook `json:"webhook,omitempty"`
+	Secret  string         `json:"secret,omitempty"`
+	Err     string         `json:"err,omitempty"`
+}
+
+// handlePutWebhook handles requests to update a webhook.
+func (h *WebhookHandler) handlePutWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	// Decode request.
+	var req putWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.Logger)
+		return
+	} else if req.Webhook == nil {
+		Error(w, fruit.ErrWebhookRequired, http.StatusBadRequest, h.Logger)
+		return
+	}
+
+	webhook := req.Webhook
+	webhook.ID = fruit.WebhookID(ps.ByName("id"))
+	webhook.Secret = req.Secret
+	webhook.ModTime = time.Time{}
+
+	// Update webhook.
+	switch err := h.WebhookService.UpdateWebhook(webhook.ID, webhook); err {
+	case nil:
+		encodeJSON(w, &putWebhookResponse{Webhook: webhook}, h.Logger)
+	case fruit.ErrWebhookRequired, fruit.ErrInvalidWebhookURL, fruit.ErrInvalidEventType:
+		Error(w, err, http.StatusBadRequest, h.Logger)
+	case fruit.ErrWebhookNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type putWebhookRequest struct {
+	Webhook *fruit.Webhook `json:"webhook,omitempty"`
+	Secret  string         `json:"secret,omitempty"`
+}
+
+type putWebhookResponse struct {
+	Webhook *fruit.Webhook `json:"webhook,omitempty"`
+	Err     string         `json:"err,omitempty"`
+}
+
+// handleDeleteWebhook handles requests to delete a webhook.
+func (h *WebhookHandler) handleDeleteWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch err := h.WebhookService.DeleteWebhook(fruit.WebhookID(ps.ByName("id"))); err {
+	case nil:
+		encodeJSON(w, &deleteWebhookResponse{}, h.Logger)
+	case fruit.ErrWebhookNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type deleteWebhookResponse struct {
+	Err string `json:"err,omitempty"`
+}
+
+// handleGetDeliveries handles requests to fetch the delivery log of a
+// webhook.
+func (h *WebhookHandler) handleGetDeliveries(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch deliveries, err := h.WebhookService.Deliveries(fruit.WebhookID(ps.ByName("id"))); err {
+	case nil:
+		encodeJSON(w, &getDeliveriesResponse{Deliveries: deliveries}, h.Logger)
+	case fruit.ErrWebhookNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type getDeliveriesResponse struct {
+	Deliveries []*fruit.Delivery `json:"deliveries,omitempty"`
+	Err        string            `json:"err,omitempty"`
+}
+
+// handlePostReplayFailed handles requests to resend every failed delivery
+// of a webhook.
+func (h *WebhookHandler) handlePostReplayFailed(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	switch n, err := h.WebhookService.ReplayFailed(fruit.WebhookID(ps.ByName("id"))); err {
+	case nil:
+		encodeJSON(w, &postReplayResponse{Replayed: n}, h.Logger)
+	case fruit.ErrWebhookNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+// handlePostReplayDelivery handles requests to resend a single delivery.
+func (h *WebhookHandler) handlePostReplayDelivery(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !authorizeAdmin(w, r, h.Logger) {
+		return
+	}
+
+	id, err := strconv.ParseUint(ps.ByName("id"), 10, 64)
+	if err != nil {
+		Error(w, fruit.ErrDeliveryNotFound, http.StatusNotFound, h.Logger)
+		return
+	}
+
+	switch err := h.WebhookService.ReplayDelivery(fruit.DeliveryID(id)); err {
+	case nil:
+		encodeJSON(w, &postReplayResponse{Replayed: 1}, h.Logger)
+	case fruit.ErrDeliveryNotFound:
+		Error(w, err, http.StatusNotFound, h.Logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.Logger)
+	}
+}
+
+type postReplayResponse struct {
+	Replayed int    `json:"replayed"`
+	Err      string `json:"err,omitempty"`
+}
+
+// WebhookService represents an HTTP implementation of fruit.WebhookService.
+type WebhookService struct {
+	URL *url.URL
+	Key *string
+}
+
+func (s *WebhookService) Webhook(id fruit.WebhookID) (*fruit.Webhook, error) {
+	u := *s.URL
+	u.Path = "/api/admin/webhooks/" + url.PathEscape(string(id))
+
+	var respBody getWebhookResponse
+	if err := s.do(http.MethodGet, u, nil, &respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Webhook, nil
+}
+
+func (s *WebhookService) Webhooks() ([]*fruit.Webhook, error) {
+	u := *s.URL
+	u.Path = "/api/admin/webhooks"
+
+	var respBody getWebhooksResponse
+	if err := s.do(http.MethodGet, u, nil, &respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Webhooks, nil
+}
+
+func (s *WebhookService) CreateWebhook(w *fruit.Webhook) error {
+	// Validate arguments.
+	if w == nil {
+		return fruit.ErrWebhookRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/admin/webhooks"
+
+	var respBody postWebhookResponse
+	if err := s.do(http.MethodPost, u, postWebhookRequest{Webhook: w, Secret: w.Secret}, &respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned webhook and its secret.
+	*w = *respBody.Webhook
+	w.Secret = respBody.Secret
+	return nil
+}
+
+func (s *WebhookService) UpdateWebhook(id fruit.WebhookID, w *fruit.Webhook) error {
+	// Validate arguments.
+	if w == nil {
+		return fruit.ErrWebhookRequired
+	}
+
+	u := *s.URL
+	u.Path = "/api/admin/webhooks/" + url.PathEscape(string(id))
+
+	var respBody putWebhookResponse
+	if err := s.do(http.MethodPut, u, putWebhookRequest{Webhook: w, Secret: w.Secret}, &respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+
+	// Copy returned webhook.
+	*w = *respBody.Webhook
+	return nil
+}
+
+func (s *WebhookService) DeleteWebhook(id fruit.WebhookID) error {
+	u := *s.URL
+	u.Path = "/api/admin/webhooks/" + url.PathEscape(string(id))
+
+	var respBody deleteWebhookResponse
+	if err := s.do(http.MethodDelete, u, nil, &respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+	return nil
+}
+
+func (s *WebhookService) Deliveries(id fruit.WebhookID) ([]*fruit.Delivery, error) {
+	u := *s.URL
+	u.Path = "/api/admin/webhooks/" + url.PathEscape(string(id)) + "/deliveries"
+
+	var respBody getDeliveriesResponse
+	if err := s.do(http.MethodGet, u, nil, &respBody); err != nil {
+		return nil, err
+	} else if respBody.Err != "" {
+		return nil, fruit.Error(respBody.Err)
+	}
+	return respBody.Deliveries, nil
+}
+
+func (s *WebhookService) ReplayDelivery(id fruit.DeliveryID) error {
+	u := *s.URL
+	u.Path = "/api/admin/deliveries/" + strconv.FormatUint(uint64(id), 10) + "/replay"
+
+	var respBody postReplayResponse
+	if err := s.do(http.MethodPost, u, nil, &respBody); err != nil {
+		return err
+	} else if respBody.Err != "" {
+		return fruit.Error(respBody.Err)
+	}
+	return nil
+}
+
+func (s *WebhookService) ReplayFailed(id fruit.WebhookID) (int, error) {
+	u := *s.URL
+	u.Path = "/api/admin/webhooks/" + url.PathEscape(string(id)) + "/replay"
+
+	var respBody postReplayResponse
+	if err := s.do(http.MethodPost, u, nil, &respBody); err != nil {
+		return 0, err
+	} else if respBody.Err != "" {
+		return 0, fruit.Error(respBody.Err)
+	}
+	return respBody.Replayed, nil
+}
+
+// do executes a request with an optional JSON body and decodes the JSON
+// response into respBody.
+func (s *WebhookService) do(method string, u url.URL, reqBody, respBody interface{}) error {
+	var body []byte
+	if reqBody != nil {
+		b, err := json.Marshal(reqBody)
+		if err != nil {
+			return err
+		}
+		body = b
+	}
+
+	// Execute request.
+	resp, err := doRequest(context.Background(), method, u, body, s.Key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Decode response into JSON.
+	return json.NewDecoder(resp.Body).Decode(respBody)
+}
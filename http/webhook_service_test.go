@@ -0,0 +1,258 @@
+package http_test
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/http"
+	"github.com/notjrbauer/fruit/mock"
+)
+
+// WebhookHandler represents a test wrapper for http.WebhookHandler.
+type WebhookHandler struct {
+	*http.WebhookHandler
+
+	WebhookService mock.WebhookService
+	LogOutput      bytes.Buffer
+}
+
+func NewWebhookHandler() *WebhookHandler {
+	h := &WebhookHandler{WebhookHandler: http.NewWebhookHandler()}
+	h.WebhookHandler.WebhookService = &h.WebhookService
+	h.Logger = log.New(VerboseWriter(&h.LogOutput), "", log.LstdFlags)
+	return h
+}
+
+func TestWebhookService_Webhook(t *testing.T) {
+	t.Run("OK", testWebhookService_Webhook)
+	t.Run("ErrWebhookNotFound", testWebhookService_Webhook_ErrWebhookNotFound)
+	t.Run("ErrUnauthorized", testWebhookService_Webhook_ErrUnauthorized)
+	t.Run("ErrForbidden", testWebhookService_Webhook_ErrForbidden)
+}
+
+func testWebhookService_Webhook(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	webhook := &fruit.Webhook{ID: "W", URL: "http://example.com/hook", Events: []string{fruit.EventOrderCreated}, Secret: "SECRET", ModTime: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s.Handler.WebhookHandler.WebhookService.WebhookFn = func(id fruit.WebhookID) (*fruit.Webhook, error) {
+		if id != "W" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return webhook, nil
+	}
+
+	// The secret is never returned after creation.
+	if other, err := c.WebhookService().Webhook("W"); err != nil {
+		t.Fatal(err)
+	} else if other.Secret != "" {
+		t.Fatalf("unexpected secret: %s", other.Secret)
+	} else if other.Secret = "SECRET"; !reflect.DeepEqual(other, webhook) {
+		t.Fatalf("unexpected webhook: %#v", other)
+	}
+}
+
+func testWebhookService_Webhook_ErrWebhookNotFound(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.WebhookFn = func(id fruit.WebhookID) (*fruit.Webhook, error) {
+		return nil, fruit.ErrWebhookNotFound
+	}
+
+	if _, err := c.WebhookService().Webhook("W"); err != fruit.ErrWebhookNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testWebhookService_Webhook_ErrUnauthorized(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	mockAPIKeys(s)
+
+	if _, err := c.WebhookService().Webhook("W"); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if s.Handler.WebhookHandler.WebhookService.WebhookInvoked {
+		t.Fatal("unexpected Webhook() invocation")
+	}
+}
+
+func testWebhookService_Webhook_ErrForbidden(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "USER"
+	mockAPIKeys(s)
+
+	if _, err := c.WebhookService().Webhook("W"); err != fruit.ErrForbidden {
+		t.Fatal(err)
+	} else if s.Handler.WebhookHandler.WebhookService.WebhookInvoked {
+		t.Fatal("unexpected Webhook() invocation")
+	}
+}
+
+func TestWebhookService_Webhooks(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.WebhooksFn = func() ([]*fruit.Webhook, error) {
+		return []*fruit.Webhook{{ID: "W", URL: "http://example.com/hook", Secret: "SECRET"}}, nil
+	}
+
+	if webhooks, err := c.WebhookService().Webhooks(); err != nil {
+		t.Fatal(err)
+	} else if len(webhooks) != 1 || webhooks[0].ID != "W" || webhooks[0].Secret != "" {
+		t.Fatalf("unexpected webhooks: %#v", webhooks)
+	}
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	t.Run("OK", testWebhookService_CreateWebhook)
+	t.Run("ErrInvalidWebhookURL", testWebhookService_CreateWebhook_ErrInvalidWebhookURL)
+}
+
+func testWebhookService_CreateWebhook(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.CreateWebhookFn = func(w *fruit.Webhook) error {
+		if w.URL != "http://example.com/hook" {
+			t.Fatalf("unexpected url: %s", w.URL)
+		}
+		w.ID, w.Secret = "W", "SECRET"
+		return nil
+	}
+
+	w := &fruit.Webhook{URL: "http://example.com/hook"}
+	if err := c.WebhookService().CreateWebhook(w); err != nil {
+		t.Fatal(err)
+	} else if w.ID != "W" || w.Secret != "SECRET" {
+		t.Fatalf("unexpected webhook: %#v", w)
+	}
+}
+
+func testWebhookService_CreateWebhook_ErrInvalidWebhookURL(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.CreateWebhookFn = func(w *fruit.Webhook) error {
+		return fruit.ErrInvalidWebhookURL
+	}
+
+	if err := c.WebhookService().CreateWebhook(&fruit.Webhook{URL: "ftp://x"}); err != fruit.ErrInvalidWebhookURL {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookService_UpdateWebhook(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.UpdateWebhookFn = func(id fruit.WebhookID, w *fruit.Webhook) error {
+		if id != "W" || w.URL != "http://example.com/other" || w.Secret != "ROTATED" {
+			t.Fatalf("unexpected update: %s %#v", id, w)
+		}
+		return nil
+	}
+
+	// A new secret can be set but isn't echoed back.
+	w := &fruit.Webhook{URL: "http://example.com/other", Secret: "ROTATED"}
+	if err := c.WebhookService().UpdateWebhook("W", w); err != nil {
+		t.Fatal(err)
+	} else if w.ID != "W" || w.Secret != "" {
+		t.Fatalf("unexpected webhook: %#v", w)
+	}
+}
+
+func TestWebhookService_DeleteWebhook(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.DeleteWebhookFn = func(id fruit.WebhookID) error {
+		return fruit.ErrWebhookNotFound
+	}
+
+	if err := c.WebhookService().DeleteWebhook("W"); err != fruit.ErrWebhookNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookService_Deliveries(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	deliveries := []*fruit.Delivery{
+		{ID: 1, WebhookID: "W", Event: &fruit.Event{Seq: 1, Type: fruit.EventUserCreated, UserID: "U", Time: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}, Status: fruit.DeliveryFailed, Attempts: 8, ResponseCode: 500, LastError: "unexpected status: 500", NextAttempt: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), ModTime: time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	s.Handler.WebhookHandler.WebhookService.DeliveriesFn = func(id fruit.WebhookID) ([]*fruit.Delivery, error) {
+		if id != "W" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return deliveries, nil
+	}
+
+	if other, err := c.WebhookService().Deliveries("W"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, deliveries) {
+		t.Fatalf("unexpected deliveries: %#v", other)
+	}
+}
+
+func TestWebhookService_ReplayDelivery(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.ReplayDeliveryFn = func(id fruit.DeliveryID) error {
+		if id != 100 {
+			return fruit.ErrDeliveryNotFound
+		}
+		return nil
+	}
+
+	if err := c.WebhookService().ReplayDelivery(100); err != nil {
+		t.Fatal(err)
+	} else if err := c.WebhookService().ReplayDelivery(200); err != fruit.ErrDeliveryNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookService_ReplayFailed(t *testing.T) {
+	s, c := MustOpenServerClient()
+	defer s.Close()
+	c.Key = "ADMIN"
+	mockAPIKeys(s)
+
+	s.Handler.WebhookHandler.WebhookService.ReplayFailedFn = func(id fruit.WebhookID) (int, error) {
+		if id != "W" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+		return 3, nil
+	}
+
+	if n, err := c.WebhookService().ReplayFailed("W"); err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Fatalf("unexpected count: %d", n)
+	}
+}
@@ -0,0 +1,82 @@
+package inmem
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+type APIKeyService struct {
+	client *Client
+}
+
+// APIKey returns an API key by its key string.
+func (s *APIKeyService) APIKey(key string) (*fruit.APIKey, error) {
+	if key == "" {
+		return nil, fruit.ErrAPIKeyRequired
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	k, ok := s.client.apiKeys[token.Hash(key)]
+	if !ok {
+		return nil, fruit.ErrAPIKeyNotFound
+	}
+	other := *k
+	return &other, nil
+}
+
+// CreateAPIKey creates a new API key. A random key is generated if one is
+// not provided.
+func (s *APIKeyService) CreateAPIKey(k *fruit.APIKey) error {
+	if k.Key == "" {
+		key, err := newKey()
+		if err != nil {
+			return err
+		}
+		k.Key = key
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Verify key doesn't already exist.
+	hash := token.Hash(k.Key)
+	if _, ok := s.client.apiKeys[hash]; ok {
+		return fruit.ErrAPIKeyExists
+	}
+
+	k.ModTime = s.client.Now().UTC()
+
+	// Keys are stored by their hash, and k keeps the plain key.
+	other := *k
+	other.Key = hash
+	s.client.apiKeys[hash] = &other
+	return nil
+}
+
+// DeleteAPIKey revokes an existing API key.
+func (s *APIKeyService) DeleteAPIKey(key string) error {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	hash := token.Hash(key)
+	if _, ok := s.client.apiKeys[hash]; !ok {
+		return fruit.ErrAPIKeyNotFound
+	}
+
+	delete(s.client.apiKeys, hash)
+	return nil
+}
+
+// newKey returns a random hex encoded key.
+func newKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,131 @@
+package inmem
+
+import (
+	"github.com/notjrbauer/fruit"
+)
+
+type CartService struct {
+	client *Client
+}
+
+// Cart returns the cart for a user. Users without a cart get an empty one.
+func (s *CartService) Cart(id fruit.UserID) (*fruit.Cart, error) {
+	if id == "" {
+		return nil, fruit.ErrUserIDRequired
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	return s.findCart(id), nil
+}
+
+// AddCartItem adds quantity of a product to a user's cart. The quantity is
+// added to any existing quantity of the same product.
+func (s *CartService) AddCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	return s.update(id, productID, func(c *fruit.Cart) error {
+		if quantity <= 0 {
+			return fruit.ErrInvalidQuantity
+		}
+
+		for i := range c.Items {
+			if c.Items[i].ProductID == productID {
+				c.Items[i].Quantity += quantity
+				return nil
+			}
+		}
+		c.Items = append(c.Items, fruit.CartItem{ProductID: productID, Quantity: quantity})
+		return nil
+	})
+}
+
+// UpdateCartItem sets the quantity of a product already in a user's cart.
+func (s *CartService) UpdateCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	return s.update(id, productID, func(c *fruit.Cart) error {
+		if quantity <= 0 {
+			return fruit.ErrInvalidQuantity
+		}
+
+		for i := range c.Items {
+			if c.Items[i].ProductID == productID {
+				c.Items[i].Quantity = quantity
+				return nil
+			}
+		}
+		return fruit.ErrCartItemNotFound
+	})
+}
+
+// RemoveCartItem removes a product from a user's cart.
+func (s *CartService) RemoveCartItem(id fruit.UserID, productID fruit.ProductID) error {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Removing doesn't require the product to still exist.
+	c := s.findCart(id)
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			c.ModTime = s.client.Now().UTC()
+
+			s.client.carts[id] = c
+			return nil
+		}
+	}
+	return fruit.ErrCartItemNotFound
+}
+
+// ClearCart removes all items from a user's cart.
+func (s *CartService) ClearCart(id fruit.UserID) error {
+	if id == "" {
+		return fruit.ErrUserIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	delete(s.client.carts, id)
+	return nil
+}
+
+// update applies fn to a user's cart after verifying that the user and the
+// product exist.
+func (s *CartService) update(id fruit.UserID, productID fruit.ProductID, fn func(c *fruit.Cart) error) error {
+	if id == "" {
+		return fruit.ErrUserIDRequired
+	} else if productID == "" {
+		return fruit.ErrProductIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Verify user and product exist.
+	if _, ok := s.client.users[id]; !ok {
+		return fruit.ErrUserNotFound
+	} else if _, ok := s.client.product(productID); !ok {
+		return fruit.ErrProductNotFound
+	}
+
+	c := s.findCart(id)
+	if err := fn(c); err != nil {
+		return err
+	}
+	c.ModTime = s.client.Now().UTC()
+
+	s.client.carts[id] = c
+	return nil
+}
+
+// findCart returns a copy of the cart for a user, or an empty cart if none
+// is stored.
+func (s *CartService) findCart(id fruit.UserID) *fruit.Cart {
+	c, ok := s.client.carts[id]
+	if !ok {
+		return &fruit.Cart{UserID: id, Items: []fruit.CartItem{}}
+	}
+
+	other := *c
+	other.Items = append([]fruit.CartItem{}, c.Items...)
+	return &other
+}
@@ -0,0 +1,213 @@
+package inmem
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/search"
+)
+
+type CategoryService struct {
+	client *Client
+}
+
+// Category returns a category by ID.
+func (s *CategoryService) Category(id fruit.CategoryID) (*fruit.Category, error) {
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	c, ok := s.client.categories[id]
+	if !ok {
+		return nil, fruit.ErrCategoryNotFound
+	}
+	other := *c
+	return &other, nil
+}
+
+// Categories returns a list of all categories.
+func (s *CategoryService) Categories() ([]*fruit.Category, error) {
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	categories := make([]*fruit.Category, 0, len(s.client.categories))
+	for _, c := range s.client.categories {
+		other := *c
+		categories = append(categories, &other)
+	}
+
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
+// CreateCategory creates a new category. The slug is derived from the name
+// when it is not provided.
+func (s *CategoryService) CreateCategory(c *fruit.Category) error {
+	// Validate arguments.
+	if c == nil {
+		return fruit.ErrCategoryRequired
+	} else if c.ID == "" {
+		return fruit.ErrCategoryIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Verify category doesn't already exist.
+	if _, ok := s.client.categories[c.ID]; ok {
+		return fruit.ErrCategoryExists
+	}
+
+	// Verify parent exists.
+	if c.ParentID != "" {
+		if _, ok := s.client.categories[c.ParentID]; !ok {
+			return fruit.ErrCategoryParentNotFound
+		}
+	}
+
+	if c.Slug == "" {
+		c.Slug = slugify(c.Name)
+	}
+	if s.slugExists(c.ID, c.Slug) {
+		return fruit.ErrCategorySlugExists
+	}
+	c.ModTime = s.client.Now().UTC()
+
+	other := *c
+	s.client.categories[c.ID] = &other
+	return nil
+}
+
+// UpdateCategory updates an existing category.
+func (s *CategoryService) UpdateCategory(id fruit.CategoryID, c *fruit.Category) error {
+	// Validate arguments.
+	if c == nil {
+		return fruit.ErrCategoryRequired
+	} else if id == "" {
+		return fruit.ErrCategoryIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Find record.
+	category, ok := s.client.categories[id]
+	if !ok {
+		return fruit.ErrCategoryNotFound
+	}
+
+	// Walk up from the new parent to make sure we don't create a cycle.
+	for parent := c.ParentID; parent != ""; {
+		if parent == id {
+			return fruit.ErrCategoryCycle
+		}
+
+		p, ok := s.client.categories[parent]
+		if !ok {
+			return fruit.ErrCategoryParentNotFound
+		}
+		parent = p.ParentID
+	}
+
+	// Apply changes.
+	d := *category
+	d.Name = c.Name
+	d.Slug = c.Slug
+	if d.Slug == "" {
+		d.Slug = slugify(c.Name)
+	}
+	d.ParentID = c.ParentID
+	d.ModTime = s.client.Now().UTC()
+
+	if s.slugExists(id, d.Slug) {
+		return fruit.ErrCategorySlugExists
+	}
+
+	s.client.categories[id] = &d
+	*c = d
+	return nil
+}
+
+// DeleteCategory removes an existing category. Categories which still have
+// subcategories or products cannot be removed.
+func (s *CategoryService) DeleteCategory(id fruit.CategoryID) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrCategoryIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	if _, ok := s.client.categories[id]; !ok {
+		return fruit.ErrCategoryNotFound
+	}
+
+	// Verify category is empty.
+	for _, c := range s.client.categories {
+		if c.ParentID == id {
+			return fruit.ErrCategoryNotEmpty
+		}
+	}
+	// Deleted products don't count. They lose their category if restored.
+	for _, p := range s.client.products {
+		if p.CategoryID == id && p.DeletedAt == nil {
+			return fruit.ErrCategoryNotEmpty
+		}
+	}
+
+	delete(s.client.categories, id)
+	return nil
+}
+
+// CategoryProducts returns the products in a category and its subcategories.
+func (s *CategoryService) CategoryProducts(id fruit.CategoryID) ([]*fruit.Product, error) {
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	if _, ok := s.client.categories[id]; !ok {
+		return nil, fruit.ErrCategoryNotFound
+	}
+
+	// Collect products breadth-first through the category tree.
+	products := []*fruit.Product{}
+	for queue := []fruit.CategoryID{id}; len(queue) > 0; queue = queue[1:] {
+		var found []*fruit.Product
+		for _, p := range s.client.products {
+			if p.CategoryID == queue[0] && p.DeletedAt == nil {
+				found = append(found, copyProduct(p))
+			}
+		}
+		sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+		products = append(products, found...)
+
+		var children []fruit.CategoryID
+		for _, c := range s.client.categories {
+			if c.ParentID == queue[0] {
+				children = append(children, c.ID)
+			}
+		}
+		sort.Slice(children, func(i, j int) bool { return children[i] < children[j] })
+		queue = append(queue, children...)
+	}
+
+	return products, nil
+}
+
+// slugExists returns true if another category already uses slug.
+func (s *CategoryService) slugExists(id fruit.CategoryID, slug string) bool {
+	if slug == "" {
+		return false
+	}
+	for _, c := range s.client.categories {
+		if c.ID != id && c.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// slugify returns a lowercase, hyphen separated version of name.
+func slugify(name string) string {
+	return strings.Join(search.Tokenize(name), "-")
+}
@@ -0,0 +1,121 @@
+package inmem
+
+import (
+	"sync"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/feed"
+)
+
+// Client represents an in-memory store for all fruit services. It is safe
+// for concurrent use and behaves like the bolt client, but its data is lost
+// once the client is discarded.
+type Client struct {
+	// Returns the current time.
+	Now func() time.Time
+
+	// Services
+	productService     ProductService
+	userService        UserService
+	transactionService TransactionService
+	categoryService    CategoryService
+	apiKeyService      APIKeyService
+	cartService        CartService
+	orderService       OrderService
+	inventoryService   InventoryService
+	importService      ImportService
+	eventService       EventService
+
+	// Wakes change feed subscribers after events are appended.
+	broker feed.Broker
+
+	// Guards all data below. Every operation holds it for its whole
+	// duration, which makes multi-record changes such as checkout atomic.
+	mu sync.RWMutex
+
+	products     map[fruit.ProductID]*fruit.Product
+	tokens       map[fruit.ProductID]string
+	stock        map[fruit.ProductID]*fruit.Stock
+	users        map[fruit.UserID]*fruit.User
+	transactions map[fruit.TransactionID]*fruit.Transaction
+	categories   map[fruit.CategoryID]*fruit.Category
+	apiKeys      map[string]*fruit.APIKey
+	carts        map[fruit.UserID]*fruit.Cart
+	orders       map[fruit.OrderID]*fruit.Order
+	events       []*fruit.Event
+}
+
+// NewClient returns a new, empty instance of Client.
+func NewClient() *Client {
+	c := &Client{
+		Now: time.Now,
+
+		products:     make(map[fruit.ProductID]*fruit.Product),
+		tokens:       make(map[fruit.ProductID]string),
+		stock:        make(map[fruit.ProductID]*fruit.Stock),
+		users:        make(map[fruit.UserID]*fruit.User),
+		transactions: make(map[fruit.TransactionID]*fruit.Transaction),
+		categories:   make(map[fruit.CategoryID]*fruit.Category),
+		apiKeys:      make(map[string]*fruit.APIKey),
+		carts:        make(map[fruit.UserID]*fruit.Cart),
+		orders:       make(map[fruit.OrderID]*fruit.Order),
+	}
+	c.productService.client = c
+	c.userService.client = c
+	c.transactionService.client = c
+	c.categoryService.client = c
+	c.apiKeyService.client = c
+	c.cartService.client = c
+	c.orderService.client = c
+	c.inventoryService.client = c
+	c.importService.client = c
+	c.eventService.client = c
+	return c
+}
+
+// Close ends every change feed subscription. The data is kept.
+func (c *Client) Close() error {
+	c.broker.Close()
+	return nil
+}
+
+func (c *Client) ProductService() fruit.ProductService {
+	return &c.productService
+}
+
+func (c *Client) UserService() fruit.UserService {
+	return &c.userService
+}
+
+func (c *Client) TransactionService() fruit.TransactionService {
+	return &c.transactionService
+}
+
+func (c *Client) CategoryService() fruit.CategoryService {
+	return &c.categoryService
+}
+
+func (c *Client) APIKeyService() fruit.APIKeyService {
+	return &c.apiKeyService
+}
+
+func (c *Client) CartService() fruit.CartService {
+	return &c.cartService
+}
+
+func (c *Client) OrderService() fruit.OrderService {
+	return &c.orderService
+}
+
+func (c *Client) InventoryService() fruit.InventoryService {
+	return &c.inventoryService
+}
+
+func (c *Client) ImportService() fruit.ImportService {
+	return &c.importService
+}
+
+func (c *Client) EventService() fruit.EventService {
+	return &c.eventService
+}
@@ -0,0 +1,16 @@
+package inmem_test
+
+import (
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/inmem"
+	"github.com/notjrbauer/fruit/internal/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func() (fruit.Client, func()) {
+		c := inmem.NewClient()
+		return c, func() { c.Close() }
+	})
+}
@@ -0,0 +1,62 @@
+package inmem
+
+import (
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/feed"
+)
+
+type EventService struct {
+	client *Client
+}
+
+// Subscribe returns a subscription to events after the sequence number
+// since. The subscription ends when the client is closed.
+func (s *EventService) Subscribe(since uint64) (fruit.Subscription, error) {
+	return feed.Subscribe(&s.client.broker, since, s.events), nil
+}
+
+// events returns up to n events after the sequence number since.
+func (s *EventService) events(since uint64, n int) ([]*fruit.Event, error) {
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	// Sequence numbers start at one and have no gaps.
+	if since >= uint64(len(s.client.events)) {
+		return nil, nil
+	}
+	a := s.client.events[since:]
+	if len(a) > n {
+		a = a[:n]
+	}
+
+	events := make([]*fruit.Event, len(a))
+	for i, e := range a {
+		events[i] = copyEvent(e)
+	}
+	return events, nil
+}
+
+// appendEvent records e in the change feed and wakes subscribers, which
+// wait for the lock before reading it. The caller must hold the write
+// lock.
+func (c *Client) appendEvent(e *fruit.Event) {
+	e.Seq = uint64(len(c.events)) + 1
+	e.Time = c.Now().UTC()
+	c.events = append(c.events, copyEvent(e))
+	c.broker.Notify()
+}
+
+// copyEvent returns a copy of e that shares no memory with it.
+func copyEvent(e *fruit.Event) *fruit.Event {
+	other := *e
+	if e.Product != nil {
+		other.Product = copyProduct(e.Product)
+	}
+	if e.User != nil {
+		other.User = copyUser(e.User)
+	}
+	if e.Order != nil {
+		other.Order = copyOrder(e.Order)
+	}
+	return &other
+}
@@ -0,0 +1,108 @@
+package inmem
+
+import (
+	"github.com/notjrbauer/fruit"
+)
+
+type ImportService struct {
+	client *Client
+}
+
+// ImportProducts creates or updates products while holding the lock, so
+// the import is applied atomically.
+func (s *ImportService) ImportProducts(a []*fruit.Product, dryRun bool) ([]fruit.ImportResult, error) {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Remember replaced records so a dry run can be undone.
+	type saved struct {
+		id      fruit.ProductID
+		product *fruit.Product
+		token   string
+	}
+	var undo []saved
+	n := len(s.client.events)
+
+	results := make([]fruit.ImportResult, len(a))
+	for i, p := range a {
+		prev := saved{id: p.ID, product: s.client.products[p.ID], token: s.client.tokens[p.ID]}
+
+		var err error
+		if prev.product == nil {
+			results[i].Action, err = fruit.ImportCreated, s.client.productService.createProduct(p)
+		} else {
+			// Imports overwrite the stored record whatever its version.
+			p.Version = prev.product.Version
+			results[i].Action, err = fruit.ImportUpdated, s.client.productService.updateProduct(p.ID, p)
+		}
+
+		results[i].ID = string(p.ID)
+		if err != nil {
+			results[i].Action, results[i].Err = "", err.Error()
+			continue
+		}
+		undo = append(undo, prev)
+	}
+
+	if dryRun {
+		s.client.events = s.client.events[:n]
+		for i := len(undo) - 1; i >= 0; i-- {
+			if u := undo[i]; u.product == nil {
+				delete(s.client.products, u.id)
+				delete(s.client.tokens, u.id)
+			} else {
+				s.client.products[u.id] = u.product
+				s.client.tokens[u.id] = u.token
+			}
+		}
+	}
+	return results, nil
+}
+
+// ImportUsers creates or replaces users while holding the lock, so the
+// import is applied atomically.
+func (s *ImportService) ImportUsers(a []*fruit.User, dryRun bool) ([]fruit.ImportResult, error) {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Remember replaced records so a dry run can be undone.
+	type saved struct {
+		id   fruit.UserID
+		user *fruit.User
+	}
+	var undo []saved
+	n := len(s.client.events)
+
+	results := make([]fruit.ImportResult, len(a))
+	for i, u := range a {
+		prev := saved{id: u.ID, user: s.client.users[u.ID]}
+
+		var err error
+		if prev.user == nil {
+			results[i].Action, err = fruit.ImportCreated, s.client.userService.createUser(u)
+		} else {
+			// Imports overwrite the stored record whatever its version.
+			u.Version = 0
+			results[i].Action, err = fruit.ImportUpdated, s.client.userService.updateUser(u.ID, u)
+		}
+
+		results[i].ID = string(u.ID)
+		if err != nil {
+			results[i].Action, results[i].Err = "", err.Error()
+			continue
+		}
+		undo = append(undo, prev)
+	}
+
+	if dryRun {
+		s.client.events = s.client.events[:n]
+		for i := len(undo) - 1; i >= 0; i-- {
+			if u := undo[i]; u.user == nil {
+				delete(s.client.users, u.id)
+			} else {
+				s.client.users[u.id] = u.user
+			}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,131 @@
+package inmem
+
+import (
+	"github.com/notjrbauer/fruit"
+)
+
+type InventoryService struct {
+	client *Client
+}
+
+// Stock returns the stock record for a product. Untracked products return an
+// empty record.
+func (s *InventoryService) Stock(id fruit.ProductID) (*fruit.Stock, error) {
+	if id == "" {
+		return nil, fruit.ErrProductIDRequired
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	if _, ok := s.client.product(id); !ok {
+		return nil, fruit.ErrProductNotFound
+	}
+
+	st, ok := s.client.stock[id]
+	if !ok {
+		return &fruit.Stock{ProductID: id}, nil
+	}
+	other := *st
+	return &other, nil
+}
+
+// AdjustStock changes the units on hand for a product, starting to track it
+// if needed. On-hand stock can't drop below what is already reserved.
+func (s *InventoryService) AdjustStock(id fruit.ProductID, delta int) error {
+	return s.update(id, func(st *fruit.Stock) error {
+		if st.OnHand+delta < st.Reserved {
+			return fruit.ErrInsufficientStock
+		}
+		st.OnHand += delta
+		return nil
+	})
+}
+
+// ReserveStock holds quantity units of a product. Untracked products are
+// always available.
+func (s *InventoryService) ReserveStock(id fruit.ProductID, quantity int) error {
+	if id == "" {
+		return fruit.ErrProductIDRequired
+	} else if quantity <= 0 {
+		return fruit.ErrInvalidQuantity
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	if _, ok := s.client.product(id); !ok {
+		return fruit.ErrProductNotFound
+	}
+
+	st, ok := s.client.stock[id]
+	if !ok {
+		return nil
+	} else if st.Available() < quantity {
+		return fruit.ErrInsufficientStock
+	}
+
+	other := *st
+	other.Reserved += quantity
+	other.ModTime = s.client.Now().UTC()
+	s.client.stock[id] = &other
+	return nil
+}
+
+// ReleaseStock returns quantity reserved units to available stock.
+func (s *InventoryService) ReleaseStock(id fruit.ProductID, quantity int) error {
+	if quantity <= 0 {
+		return fruit.ErrInvalidQuantity
+	}
+
+	return s.update(id, func(st *fruit.Stock) error {
+		if quantity > st.Reserved {
+			return fruit.ErrReservationExceeded
+		}
+		st.Reserved -= quantity
+		return nil
+	})
+}
+
+// CommitStock removes quantity reserved units from stock.
+func (s *InventoryService) CommitStock(id fruit.ProductID, quantity int) error {
+	if quantity <= 0 {
+		return fruit.ErrInvalidQuantity
+	}
+
+	return s.update(id, func(st *fruit.Stock) error {
+		if quantity > st.Reserved {
+			return fruit.ErrReservationExceeded
+		}
+		st.Reserved -= quantity
+		st.OnHand -= quantity
+		return nil
+	})
+}
+
+// update applies fn to the stock record of an existing product.
+func (s *InventoryService) update(id fruit.ProductID, fn func(st *fruit.Stock) error) error {
+	if id == "" {
+		return fruit.ErrProductIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	if _, ok := s.client.product(id); !ok {
+		return fruit.ErrProductNotFound
+	}
+
+	st := &fruit.Stock{ProductID: id}
+	if other, ok := s.client.stock[id]; ok {
+		*st = *other
+	}
+
+	if err := fn(st); err != nil {
+		return err
+	}
+	st.ModTime = s.client.Now().UTC()
+
+	s.client.stock[id] = st
+	return nil
+}
@@ -0,0 +1,133 @@
+package inmem
+
+import (
+	"sort"
+
+	"github.com/notjrbauer/fruit"
+)
+
+type OrderService struct {
+	client *Client
+}
+
+// Order returns an order by ID.
+func (s *OrderService) Order(id fruit.OrderID) (*fruit.Order, error) {
+	if id == "" {
+		return nil, fruit.ErrOrderIDRequired
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	o, ok := s.client.orders[id]
+	if !ok {
+		return nil, fruit.ErrOrderNotFound
+	}
+	return copyOrder(o), nil
+}
+
+// Orders returns all orders placed by a user.
+func (s *OrderService) Orders(id fruit.UserID) ([]*fruit.Order, error) {
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	orders := []*fruit.Order{}
+	for _, o := range s.client.orders {
+		if o.UserID == id {
+			orders = append(orders, copyOrder(o))
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders, nil
+}
+
+// Checkout creates an order from the user's cart, reserves stock for it and
+// clears the cart. Nothing is changed if any step fails.
+func (s *OrderService) Checkout(id fruit.UserID) (*fruit.Order, error) {
+	if id == "" {
+		return nil, fruit.ErrUserIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Verify user exists.
+	if _, ok := s.client.users[id]; !ok {
+		return nil, fruit.ErrUserNotFound
+	}
+
+	c, ok := s.client.carts[id]
+	if !ok || len(c.Items) == 0 {
+		return nil, fruit.ErrCartEmpty
+	}
+
+	orderID, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	o := &fruit.Order{
+		ID:      fruit.OrderID(orderID),
+		UserID:  id,
+		Items:   make([]fruit.OrderItem, 0, len(c.Items)),
+		ModTime: s.client.Now().UTC(),
+	}
+
+	// Snapshot each product as it is now and stage its stock reservation.
+	reserved := make(map[fruit.ProductID]*fruit.Stock)
+	for _, item := range c.Items {
+		p, ok := s.client.product(item.ProductID)
+		if !ok {
+			return nil, fruit.ErrProductNotFound
+		} else if p.Price == nil {
+			return nil, fruit.ErrProductPriceRequired
+		}
+
+		if st, ok := s.client.stock[p.ID]; ok {
+			if st.Available() < item.Quantity {
+				return nil, fruit.ErrInsufficientStock
+			}
+
+			other := *st
+			other.Reserved += item.Quantity
+			other.ModTime = o.ModTime
+			reserved[p.ID] = &other
+		}
+
+		o.Items = append(o.Items, fruit.OrderItem{
+			ProductID: p.ID,
+			Name:      p.Name,
+			SKU:       p.SKU,
+			Price:     *p.Price,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	// Total the order in the currency of its first item.
+	o.Total = fruit.Money{Currency: o.Items[0].Price.Currency}
+	for _, item := range o.Items {
+		subtotal, err := item.Price.Mul(item.Quantity)
+		if err != nil {
+			return nil, err
+		} else if o.Total, err = o.Total.Add(subtotal); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply all changes.
+	for productID, st := range reserved {
+		s.client.stock[productID] = st
+	}
+	s.client.orders[o.ID] = copyOrder(o)
+	delete(s.client.carts, id)
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventOrderCreated, UserID: id, OrderID: o.ID, Order: o})
+
+	return o, nil
+}
+
+// copyOrder returns a copy of o that shares no memory with it.
+func copyOrder(o *fruit.Order) *fruit.Order {
+	other := *o
+	other.Items = append([]fruit.OrderItem{}, o.Items...)
+	return &other
+}
@@ -0,0 +1,556 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/search"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+// Product orderings by sort field. Records are sorted by ID first, so ties
+// keep ID order.
+var productLess = map[string]func(a, b *fruit.Product) bool{
+	"":          func(a, b *fruit.Product) bool { return a.ID < b.ID },
+	"productID": func(a, b *fruit.Product) bool { return a.ID < b.ID },
+	"name":      func(a, b *fruit.Product) bool { return a.Name < b.Name },
+	"sku":       func(a, b *fruit.Product) bool { return a.SKU < b.SKU },
+	"type":      func(a, b *fruit.Product) bool { return a.Type < b.Type },
+	"color":     func(a, b *fruit.Product) bool { return a.Color < b.Color },
+	"modTime":   func(a, b *fruit.Product) bool { return a.ModTime.Before(b.ModTime) },
+}
+
+type ProductService struct {
+	client *Client
+}
+
+// Product returns a product by ID.
+func (s *ProductService) Product(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	p, ok := s.client.product(id)
+	if !ok {
+		return nil, fruit.ErrProductNotFound
+	}
+	return copyProduct(p), nil
+}
+
+// Products returns a page of products matching opt.
+func (s *ProductService) Products(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	offset, err := parseQuery(opt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	less, ok := productLess[opt.Sort]
+	if !ok {
+		return nil, "", fruit.ErrInvalidSort
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	products := []*fruit.Product{}
+	for _, p := range s.client.products {
+		if matchProduct(opt, p) {
+			products = append(products, p)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	sort.SliceStable(products, func(i, j int) bool { return less(products[i], products[j]) })
+	if opt.Desc {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+	}
+
+	start, end, next := page(len(products), offset, opt.Limit)
+	return copyProducts(products[start:end]), next, nil
+}
+
+// Search returns a page of products matching query, best match first. The
+// sort options are ignored.
+func (s *ProductService) Search(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	words := search.Tokenize(query)
+	if len(words) == 0 {
+		return nil, "", fruit.ErrSearchQueryRequired
+	}
+
+	offset, err := parseQuery(opt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	scores := make(map[fruit.ProductID]int)
+	products := []*fruit.Product{}
+	for _, p := range s.client.products {
+		if !matchProduct(opt, p) {
+			continue
+		}
+
+		// Every word must match one of the product's terms.
+		terms, total := search.Terms(p), 0
+		for _, word := range words {
+			best := 0
+			for term, weight := range terms {
+				if score := search.Score(word, term, weight); score > best {
+					best = score
+				}
+			}
+
+			if best == 0 {
+				total = 0
+				break
+			}
+			total += best
+		}
+
+		if total > 0 {
+			scores[p.ID] = total
+			products = append(products, p)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		a, b := products[i].ID, products[j].ID
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return a < b
+	})
+
+	start, end, next := page(len(products), offset, opt.Limit)
+	return copyProducts(products[start:end]), next, nil
+}
+
+// CreateProduct creates a new product. The product's token identifies its
+// owner and must be supplied on later updates and deletes.
+func (s *ProductService) CreateProduct(ctx context.Context, p *fruit.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	return s.createProduct(p)
+}
+
+// createProduct creates a product. The caller must hold the write lock.
+func (s *ProductService) createProduct(p *fruit.Product) error {
+	// Require id
+	if p.ID == "" {
+		return fruit.ErrProductIDRequired
+	}
+
+	// Require owner token.
+	if p.Token == "" {
+		return fruit.ErrUnauthorized
+	}
+
+	// Validate price.
+	if p.Price != nil {
+		if err := p.Price.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Verify category exists.
+	if p.CategoryID != "" {
+		if _, ok := s.client.categories[p.CategoryID]; !ok {
+			return fruit.ErrCategoryNotFound
+		}
+	}
+
+	// Verify product doesn't already exist.
+	if _, ok := s.client.products[p.ID]; ok {
+		return fruit.ErrProductExists
+	}
+
+	// Set initial version and modified time. New products are never in the
+	// trash.
+	p.Version = 1
+	p.ModTime = s.client.Now().UTC()
+	p.DeletedAt = nil
+
+	s.client.products[p.ID] = copyProduct(p)
+	s.client.tokens[p.ID] = token.Hash(p.Token)
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventProductCreated, ProductID: p.ID, Product: p})
+	return nil
+}
+
+// UpdateProduct updates an existing product. Blank fields are left
+// unchanged.
+func (s *ProductService) UpdateProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	return s.updateProduct(id, p)
+}
+
+// updateProduct updates a product and copies its new version and modified
+// time to p. The caller must hold the write lock.
+func (s *ProductService) updateProduct(id fruit.ProductID, p *fruit.Product) error {
+	// Require the version the changes are based on.
+	if p.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	// Validate price.
+	if p.Price != nil {
+		if err := p.Price.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Verify category exists.
+	if p.CategoryID != "" {
+		if _, ok := s.client.categories[p.CategoryID]; !ok {
+			return fruit.ErrCategoryNotFound
+		}
+	}
+
+	// Find record.
+	product, ok := s.client.product(id)
+	if !ok {
+		return fruit.ErrProductNotFound
+	}
+
+	// Only the owner may update the product.
+	if !s.authorized(id, p.Token) {
+		return fruit.ErrUnauthorized
+	}
+
+	// Reject changes based on an old version.
+	if p.Version != product.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes.
+	d := copyProduct(product)
+	if p.Name != "" {
+		d.Name = p.Name
+	}
+	if p.SKU != "" {
+		d.SKU = p.SKU
+	}
+	if p.Type != "" {
+		d.Type = p.Type
+	}
+	if p.Color != "" {
+		d.Color = p.Color
+	}
+	if p.Description != "" {
+		d.Description = p.Description
+	}
+	if p.Price != nil {
+		price := *p.Price
+		d.Price = &price
+	}
+	if p.CategoryID != "" {
+		d.CategoryID = p.CategoryID
+	}
+	d.Version++
+	d.ModTime = s.client.Now().UTC()
+
+	s.client.products[id] = d
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventProductUpdated, ProductID: id, Product: d})
+	p.Version, p.ModTime = d.Version, d.ModTime
+	return nil
+}
+
+// PatchProduct changes the fields of a product named in mask.
+func (s *ProductService) PatchProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Require the version the changes are based on.
+	if p.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Find record.
+	product, ok := s.client.product(id)
+	if !ok {
+		return fruit.ErrProductNotFound
+	}
+
+	// Only the owner may update the product.
+	if !s.authorized(id, p.Token) {
+		return fruit.ErrUnauthorized
+	}
+
+	// Reject changes based on an old version.
+	if p.Version != 0 && p.Version != product.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes and validate the result.
+	d := copyProduct(product)
+	if err := d.Patch(p, mask); err != nil {
+		return err
+	} else if d.Price != nil {
+		if err := d.Price.Validate(); err != nil {
+			return err
+		}
+	}
+	if d.CategoryID != "" {
+		if _, ok := s.client.categories[d.CategoryID]; !ok {
+			return fruit.ErrCategoryNotFound
+		}
+	}
+	d.Version++
+	d.ModTime = s.client.Now().UTC()
+
+	s.client.products[id] = d
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventProductUpdated, ProductID: id, Product: d})
+
+	owner := p.Token
+	*p = *copyProduct(d)
+	p.Token = owner
+	return nil
+}
+
+// DeleteProduct removes an existing product.
+func (s *ProductService) DeleteProduct(ctx context.Context, id fruit.ProductID, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	return s.deleteProduct(id, token)
+}
+
+// deleteProduct removes a product. The caller must hold the write lock.
+func (s *ProductService) deleteProduct(id fruit.ProductID, token string) error {
+	// Find record.
+	product, ok := s.client.product(id)
+	if !ok {
+		return fruit.ErrProductNotFound
+	}
+
+	// Only the owner may delete the product.
+	if !s.authorized(id, token) {
+		return fruit.ErrUnauthorized
+	}
+
+	// Keep the record, along with its token and stock, so it can be
+	// restored.
+	d := copyProduct(product)
+	now := s.client.Now().UTC()
+	d.DeletedAt = &now
+
+	s.client.products[id] = d
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventProductDeleted, ProductID: id})
+	return nil
+}
+
+// RestoreProduct moves a deleted product out of the trash.
+func (s *ProductService) RestoreProduct(ctx context.Context, id fruit.ProductID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Find record, including deleted records.
+	product, ok := s.client.products[id]
+	if !ok {
+		return fruit.ErrProductNotFound
+	} else if product.DeletedAt == nil {
+		return fruit.ErrProductNotDeleted
+	}
+
+	d := copyProduct(product)
+	d.DeletedAt = nil
+
+	// Its category may have been deleted while it was in the trash.
+	if _, ok := s.client.categories[d.CategoryID]; !ok {
+		d.CategoryID = ""
+	}
+
+	s.client.products[id] = d
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventProductRestored, ProductID: id, Product: d})
+	return nil
+}
+
+// PurgeProducts permanently removes products deleted before t.
+func (s *ProductService) PurgeProducts(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	n := 0
+	for id, p := range s.client.products {
+		if p.DeletedAt == nil || !p.DeletedAt.Before(before) {
+			continue
+		}
+
+		delete(s.client.products, id)
+		delete(s.client.tokens, id)
+		delete(s.client.stock, id)
+		n++
+	}
+	return n, nil
+}
+
+// Batch applies a series of operations while holding the lock. Failed
+// operations are skipped unless atomic is set, in which case the whole
+// batch is undone.
+func (s *ProductService) Batch(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Remember replaced records so a failed atomic batch can be undone.
+	type saved struct {
+		id      fruit.ProductID
+		product *fruit.Product
+		token   string
+		stock   *fruit.Stock
+	}
+	var undo []saved
+	n := len(s.client.events)
+
+	results := make([]fruit.BatchResult, len(ops))
+	failed := false
+	for i, op := range ops {
+		prev := saved{id: op.ID}
+		if op.Op == fruit.BatchCreate && op.Product != nil {
+			prev.id = op.Product.ID
+		}
+		prev.product, prev.token, prev.stock = s.client.products[prev.id], s.client.tokens[prev.id], s.client.stock[prev.id]
+
+		err := s.batchOp(op)
+		results[i] = fruit.NewBatchResult(i, op, err)
+		if err != nil {
+			failed = true
+			continue
+		}
+		undo = append(undo, prev)
+	}
+
+	if atomic && failed {
+		for i := len(undo) - 1; i >= 0; i-- {
+			u := undo[i]
+			if u.product == nil {
+				delete(s.client.products, u.id)
+				delete(s.client.tokens, u.id)
+			} else {
+				s.client.products[u.id] = u.product
+				s.client.tokens[u.id] = u.token
+			}
+			if u.stock != nil {
+				s.client.stock[u.id] = u.stock
+			}
+		}
+		s.client.events = s.client.events[:n]
+
+		// Report the successful operations as aborted.
+		for i := range results {
+			if results[i].Err == "" {
+				results[i] = fruit.NewBatchResult(i, ops[i], fruit.ErrBatchAborted)
+			}
+		}
+	}
+	return results, nil
+}
+
+// batchOp applies a single batch operation. The caller must hold the write
+// lock.
+func (s *ProductService) batchOp(op fruit.BatchOp) error {
+	switch op.Op {
+	case fruit.BatchCreate, fruit.BatchUpdate:
+		if op.Product == nil {
+			return fruit.ErrProductRequired
+		}
+		op.Product.Token = op.Token
+
+		if op.Op == fruit.BatchCreate {
+			return s.createProduct(op.Product)
+		} else if op.ID == "" {
+			return fruit.ErrProductIDRequired
+		}
+		return s.updateProduct(op.ID, op.Product)
+	case fruit.BatchDelete:
+		if op.ID == "" {
+			return fruit.ErrProductIDRequired
+		}
+		return s.deleteProduct(op.ID, op.Token)
+	default:
+		return fruit.ErrInvalidBatchOp
+	}
+}
+
+// authorized returns true if t matches the owner token of a product.
+func (s *ProductService) authorized(id fruit.ProductID, t string) bool {
+	return token.Verify(s.client.tokens[id], t)
+}
+
+// copyProducts returns copies of a that share no memory with it.
+func copyProducts(a []*fruit.Product) []*fruit.Product {
+	other := make([]*fruit.Product, len(a))
+	for i, p := range a {
+		other[i] = copyProduct(p)
+	}
+	return other
+}
+
+// copyProduct returns a copy of p that shares no memory with it. Tokens are
+// stored separately, as a hash, and are never copied.
+func copyProduct(p *fruit.Product) *fruit.Product {
+	other := *p
+	other.Token = ""
+	if p.Price != nil {
+		price := *p.Price
+		other.Price = &price
+	}
+	if p.DeletedAt != nil {
+		deletedAt := *p.DeletedAt
+		other.DeletedAt = &deletedAt
+	}
+	return &other
+}
+
+// product returns a product which hasn't been deleted. The caller must
+// hold the lock.
+func (c *Client) product(id fruit.ProductID) (*fruit.Product, bool) {
+	p, ok := c.products[id]
+	if !ok || p.DeletedAt != nil {
+		return nil, false
+	}
+	return p, true
+}
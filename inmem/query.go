@@ -0,0 +1,43 @@
+package inmem
+
+import (
+	"strconv"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// parseQuery validates the paging options and returns the offset to start
+// from. Cursors are the offset of the next record.
+func parseQuery(opt fruit.QueryOptions) (int, error) {
+	if opt.Limit < 0 {
+		return 0, fruit.ErrInvalidLimit
+	} else if opt.Cursor == "" {
+		return 0, nil
+	}
+
+	offset, err := strconv.Atoi(opt.Cursor)
+	if err != nil || offset < 0 {
+		return 0, fruit.ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// page returns the bounds of the page of n sorted records starting at offset,
+// and the cursor for the next page.
+func page(n, offset, limit int) (start, end int, next string) {
+	if offset > n {
+		offset = n
+	}
+	if limit == 0 || offset+limit >= n {
+		return offset, n, ""
+	}
+	return offset, offset + limit, strconv.Itoa(offset + limit)
+}
+
+// matchProduct returns true if p matches the product filters in opt.
+func matchProduct(opt fruit.QueryOptions, p *fruit.Product) bool {
+	return (opt.Type == "" || p.Type == opt.Type) &&
+		(opt.Color == "" || p.Color == opt.Color) &&
+		(opt.SKU == "" || p.SKU == opt.SKU) &&
+		(opt.IncludeDeleted || p.DeletedAt == nil)
+}
@@ -0,0 +1,135 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+
+	"github.com/notjrbauer/fruit"
+)
+
+type TransactionService struct {
+	client *Client
+}
+
+// Transaction returns a transaction by ID.
+func (s *TransactionService) Transaction(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	t, ok := s.client.transactions[id]
+	if !ok {
+		return nil, fruit.ErrTransactionNotFound
+	}
+	other := *t
+	return &other, nil
+}
+
+// Transactions returns all transactions belonging to a user.
+func (s *TransactionService) Transactions(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	transactions := []*fruit.Transaction{}
+	for _, t := range s.client.transactions {
+		if t.UserID == id {
+			other := *t
+			transactions = append(transactions, &other)
+		}
+	}
+
+	sort.Slice(transactions, func(i, j int) bool { return transactions[i].ID < transactions[j].ID })
+	return transactions, nil
+}
+
+// CreateTransaction creates a new transaction.
+func (s *TransactionService) CreateTransaction(ctx context.Context, t *fruit.Transaction) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Validate arguments.
+	if t == nil {
+		return fruit.ErrTransactionRequired
+	} else if t.ID == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Verify transaction doesn't already exist.
+	if _, ok := s.client.transactions[t.ID]; ok {
+		return fruit.ErrTransactionExists
+	}
+
+	// Update modified time.
+	t.ModTime = s.client.Now().UTC()
+
+	other := *t
+	s.client.transactions[t.ID] = &other
+	return nil
+}
+
+// UpdateTransaction updates an existing transaction.
+func (s *TransactionService) UpdateTransaction(ctx context.Context, id fruit.TransactionID, t *fruit.Transaction) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Validate arguments.
+	if t == nil {
+		return fruit.ErrTransactionRequired
+	} else if id == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Find record.
+	other, ok := s.client.transactions[id]
+	if !ok {
+		return fruit.ErrTransactionNotFound
+	}
+
+	// Apply changes.
+	d := *other
+	d.UserID = t.UserID
+	d.Count = t.Count
+	d.Active = t.Active
+	d.ModTime = s.client.Now().UTC()
+
+	s.client.transactions[id] = &d
+	*t = d
+	return nil
+}
+
+// DeleteTransaction removes an existing transaction.
+func (s *TransactionService) DeleteTransaction(ctx context.Context, id fruit.TransactionID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	if _, ok := s.client.transactions[id]; !ok {
+		return fruit.ErrTransactionNotFound
+	}
+
+	delete(s.client.transactions, id)
+	return nil
+}
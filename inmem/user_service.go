@@ -0,0 +1,209 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// User orderings by sort field.
+var userLess = map[string]func(a, b *fruit.User) bool{
+	"":        func(a, b *fruit.User) bool { return a.ID < b.ID },
+	"userID":  func(a, b *fruit.User) bool { return a.ID < b.ID },
+	"name":    func(a, b *fruit.User) bool { return a.Name < b.Name },
+	"modTime": func(a, b *fruit.User) bool { return a.ModTime.Before(b.ModTime) },
+}
+
+type UserService struct {
+	client *Client
+}
+
+// User returns a user by ID.
+func (s *UserService) User(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	u, ok := s.client.users[id]
+	if !ok {
+		return nil, fruit.ErrUserNotFound
+	}
+	return copyUser(u), nil
+}
+
+// Users returns a page of users.
+func (s *UserService) Users(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	offset, err := parseQuery(opt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	less, ok := userLess[opt.Sort]
+	if !ok {
+		return nil, "", fruit.ErrInvalidSort
+	}
+
+	s.client.mu.RLock()
+	defer s.client.mu.RUnlock()
+
+	users := make([]*fruit.User, 0, len(s.client.users))
+	for _, u := range s.client.users {
+		users = append(users, copyUser(u))
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	sort.SliceStable(users, func(i, j int) bool { return less(users[i], users[j]) })
+	if opt.Desc {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+
+	start, end, next := page(len(users), offset, opt.Limit)
+	return users[start:end], next, nil
+}
+
+// CreateUser creates a new user.
+func (s *UserService) CreateUser(ctx context.Context, u *fruit.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	return s.createUser(u)
+}
+
+// createUser creates a user. The caller must hold the write lock.
+func (s *UserService) createUser(u *fruit.User) error {
+	// Require id
+	if u.ID == "" {
+		return fruit.ErrUserIDRequired
+	}
+
+	// Verify user doesn't already exist.
+	if _, ok := s.client.users[u.ID]; ok {
+		return fruit.ErrUserExists
+	}
+
+	// Set initial version and modified time.
+	u.Version = 1
+	u.ModTime = s.client.Now().UTC()
+
+	s.client.users[u.ID] = copyUser(u)
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventUserCreated, UserID: u.ID, User: u})
+	return nil
+}
+
+// DeleteUser removes an existing user.
+func (s *UserService) DeleteUser(ctx context.Context, id fruit.UserID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	if _, ok := s.client.users[id]; !ok {
+		return fruit.ErrUserNotFound
+	}
+
+	delete(s.client.users, id)
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventUserDeleted, UserID: id})
+	return nil
+}
+
+// UpdateUser updates an existing user.
+func (s *UserService) UpdateUser(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Require the version the changes are based on.
+	if u.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	return s.updateUser(id, u)
+}
+
+// PatchUser changes the fields of a user named in mask.
+func (s *UserService) PatchUser(ctx context.Context, id fruit.UserID, u *fruit.User, mask fruit.FieldMask) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Require the version the changes are based on.
+	if u.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	// Find user.
+	user, ok := s.client.users[id]
+	if !ok {
+		return fruit.ErrUserNotFound
+	}
+
+	// Apply changes to the stored user, then update as usual.
+	user = copyUser(user)
+	if err := user.Patch(u, mask); err != nil {
+		return err
+	}
+	user.Version = u.Version
+	if err := s.updateUser(id, user); err != nil {
+		return err
+	}
+
+	*u = *user
+	return nil
+}
+
+// updateUser replaces a user. The caller must hold the write lock.
+func (s *UserService) updateUser(id fruit.UserID, u *fruit.User) error {
+	// Find user.
+	user, ok := s.client.users[id]
+	if !ok {
+		return fruit.ErrUserNotFound
+	}
+
+	// Reject changes based on an old version.
+	if u.Version != 0 && u.Version != user.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes
+	user = copyUser(user)
+	user.Name = u.Name
+	user.CardID = u.CardID
+	user.Address = u.Address
+	user.Version++
+	user.ModTime = s.client.Now().UTC()
+
+	s.client.users[id] = copyUser(user)
+	s.client.appendEvent(&fruit.Event{Type: fruit.EventUserUpdated, UserID: id, User: user})
+	*u = *user
+	return nil
+}
+
+// copyUser returns a copy of u that shares no memory with it.
+func copyUser(u *fruit.User) *fruit.User {
+	other := *u
+	if u.Address != nil {
+		addr := *u.Address
+		other.Address = &addr
+	}
+	return &other
+}
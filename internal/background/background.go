@@ -0,0 +1,61 @@
+// Package background runs the periodic work of the long-lived services,
+// such as the webhook dispatcher and the trash purger.
+package background
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loop calls a function on its own goroutine every interval until it is
+// stopped. The zero value is stopped.
+type Loop struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start calls fn once and then every interval until Stop is called. The
+// context passed to fn is cancelled by Stop.
+func (l *Loop) Start(interval time.Duration, fn func(ctx context.Context)) {
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	ctx := l.ctx
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			fn(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the running call of fn, if any, and waits for the loop to
+// exit.
+func (l *Loop) Stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.wg.Wait()
+}
+
+// Context returns the context passed to fn, or a background context if the
+// loop was never started. Work run outside the loop uses it so that Stop
+// cancels that too.
+func (l *Loop) Context() context.Context {
+	if l.ctx == nil {
+		return context.Background()
+	}
+	return l.ctx
+}
@@ -0,0 +1,46 @@
+package background_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit/internal/background"
+)
+
+// Ensure fn runs as soon as the loop starts and its context is cancelled
+// when the loop stops.
+func TestLoop(t *testing.T) {
+	var l background.Loop
+	if err := l.Context().Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	called := make(chan context.Context, 1)
+	l.Start(time.Hour, func(ctx context.Context) {
+		select {
+		case called <- ctx:
+		default:
+		}
+	})
+
+	var ctx context.Context
+	select {
+	case ctx = <-called:
+	case <-time.After(time.Second):
+		t.Fatal("expected fn to be called")
+	}
+
+	l.Stop()
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("unexpected error: %v", ctx.Err())
+	} else if l.Context().Err() != context.Canceled {
+		t.Fatalf("unexpected error: %v", l.Context().Err())
+	}
+}
+
+// Ensure a loop which was never started can be stopped.
+func TestLoop_Stop(t *testing.T) {
+	var l background.Loop
+	l.Stop()
+}
@@ -0,0 +1,1436 @@
+// Package conformance holds tests which every storage backend must pass so
+// that backends can be swapped without changing behavior.
+package conformance
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+// Client represents a storage backend implementing every service. Backends
+// that only implement fruit.Client skip the tests for the other services.
+type Client interface {
+	fruit.Client
+	CategoryService() fruit.CategoryService
+	APIKeyService() fruit.APIKeyService
+	CartService() fruit.CartService
+	OrderService() fruit.OrderService
+	InventoryService() fruit.InventoryService
+	ImportService() fruit.ImportService
+	EventService() fruit.EventService
+}
+
+// OpenFunc returns a new, empty client and a function which closes it.
+type OpenFunc func() (fruit.Client, func())
+
+// Run runs the conformance suite against clients returned by open.
+func Run(t *testing.T, open OpenFunc) {
+	for _, tt := range []struct {
+		name string
+		fn   func(*testing.T, fruit.Client)
+	}{
+		{"ProductService/CRUD", testProductService_CRUD},
+		{"ProductService/Errors", testProductService_Errors},
+		{"ProductService/Products", testProductService_Products},
+		{"ProductService/Search", testProductService_Search},
+		{"ProductService/Batch", testProductService_Batch},
+		{"ProductService/Batch/Atomic", testProductService_Batch_Atomic},
+		{"ProductService/Cancel", testProductService_Cancel},
+		{"ProductService/Conflict", testProductService_Conflict},
+		{"ProductService/Patch", testProductService_Patch},
+		{"ProductService/Trash", testProductService_Trash},
+		{"ProductService/Trash/Create", testProductService_Trash_Create},
+		{"UserService/CRUD", testUserService_CRUD},
+		{"UserService/Users", testUserService_Users},
+		{"UserService/Conflict", testUserService_Conflict},
+		{"UserService/Patch", testUserService_Patch},
+		{"TransactionService", testTransactionService},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, close := open()
+			defer close()
+			tt.fn(t, c)
+		})
+	}
+
+	for _, tt := range []struct {
+		name string
+		fn   func(*testing.T, Client)
+	}{
+		{"CategoryService", testCategoryService},
+		{"APIKeyService", testAPIKeyService},
+		{"CartService", testCartService},
+		{"OrderService/Checkout", testOrderService_Checkout},
+		{"OrderService/Errors", testOrderService_Errors},
+		{"InventoryService", testInventoryService},
+		{"InventoryService/Concurrent", testInventoryService_Concurrent},
+		{"InventoryService/Trash", testInventoryService_Trash},
+		{"ImportService/Products", testImportService_Products},
+		{"ImportService/Users", testImportService_Users},
+		{"EventService", testEventService},
+		{"EventService/Since", testEventService_Since},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, close := open()
+			defer close()
+
+			other, ok := c.(Client)
+			if !ok {
+				t.Skip("service not implemented")
+			}
+			tt.fn(t, other)
+		})
+	}
+}
+
+func usd(amount int64) *fruit.Money {
+	return &fruit.Money{Amount: amount, Currency: "USD"}
+}
+
+// mustCreateProducts creates products owned by "TOKEN".
+func mustCreateProducts(t *testing.T, c fruit.Client, products ...*fruit.Product) {
+	ctx := context.Background()
+	for _, p := range products {
+		p.Token = "TOKEN"
+		if err := c.ProductService().CreateProduct(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func productIDs(products []*fruit.Product) []fruit.ProductID {
+	ids := []fruit.ProductID{}
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func testProductService_CRUD(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+
+	p := &fruit.Product{ID: "A", Token: "TOKEN", Name: "Apple", SKU: "APL", Type: "Fruit", Color: "Red", Price: usd(100)}
+	if err := s.CreateProduct(ctx, p); err != nil {
+		t.Fatal(err)
+	} else if p.ModTime.IsZero() {
+		t.Fatal("expected mod time")
+	}
+
+	// The owner token is never returned.
+	want := *p
+	want.Token = ""
+	if other, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, &want) {
+		t.Fatalf("unexpected product: %+v", other)
+	}
+
+	// Returned products don't share memory with the store.
+	if other, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else {
+		other.Price.Amount = 1
+		if p, _ := s.Product(ctx, "A"); p.Price.Amount != 100 {
+			t.Fatal("expected stored product to be unchanged")
+		}
+	}
+
+	// Blank fields are left unchanged on update.
+	if err := s.UpdateProduct(ctx, "A", &fruit.Product{Token: "TOKEN", Name: "Green Apple", Price: usd(150), Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if other, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if other.Name != "Green Apple" || other.SKU != "APL" || *other.Price != *usd(150) {
+		t.Fatalf("unexpected product: %+v", other)
+	}
+
+	if err := s.DeleteProduct(ctx, "A", "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Product(ctx, "A"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_Errors(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+	mustCreateProducts(t, c, &fruit.Product{ID: "A"})
+
+	for _, tt := range []struct {
+		err  error
+		want error
+	}{
+		{s.CreateProduct(ctx, &fruit.Product{Token: "TOKEN"}), fruit.ErrProductIDRequired},
+		{s.CreateProduct(ctx, &fruit.Product{ID: "B"}), fruit.ErrUnauthorized},
+		{s.CreateProduct(ctx, &fruit.Product{ID: "A", Token: "TOKEN"}), fruit.ErrProductExists},
+		{s.CreateProduct(ctx, &fruit.Product{ID: "B", Token: "TOKEN", Price: usd(-1)}), fruit.ErrInvalidPrice},
+		{s.CreateProduct(ctx, &fruit.Product{ID: "B", Token: "TOKEN", Price: &fruit.Money{Amount: 1}}), fruit.ErrInvalidCurrency},
+		{s.UpdateProduct(ctx, "X", &fruit.Product{Token: "TOKEN", Version: 1}), fruit.ErrProductNotFound},
+		{s.UpdateProduct(ctx, "A", &fruit.Product{Token: "OTHER", Version: 1}), fruit.ErrUnauthorized},
+		{s.UpdateProduct(ctx, "A", &fruit.Product{Version: 1}), fruit.ErrUnauthorized},
+		{s.UpdateProduct(ctx, "A", &fruit.Product{Token: "TOKEN"}), fruit.ErrVersionRequired},
+		{s.PatchProduct(ctx, "A", &fruit.Product{Token: "TOKEN"}, fruit.FieldMask{"name"}), fruit.ErrVersionRequired},
+		{s.DeleteProduct(ctx, "X", "TOKEN"), fruit.ErrProductNotFound},
+		{s.DeleteProduct(ctx, "A", "OTHER"), fruit.ErrUnauthorized},
+	} {
+		if tt.err != tt.want {
+			t.Errorf("unexpected error: got %v, want %v", tt.err, tt.want)
+		}
+	}
+
+	if _, err := s.Product(ctx, "X"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_Products(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+	mustCreateProducts(t, c,
+		&fruit.Product{ID: "A", SKU: "3", Type: "Apple", Color: "Red"},
+		&fruit.Product{ID: "B", SKU: "1", Type: "Apple", Color: "Green"},
+		&fruit.Product{ID: "C", SKU: "2", Type: "Apple", Color: "Red"},
+		&fruit.Product{ID: "D", SKU: "4", Type: "Pear", Color: "Red"},
+	)
+
+	for _, tt := range []struct {
+		opt  fruit.QueryOptions
+		ids  []fruit.ProductID
+		next bool
+	}{
+		{fruit.QueryOptions{}, []fruit.ProductID{"A", "B", "C", "D"}, false},
+		{fruit.QueryOptions{Desc: true}, []fruit.ProductID{"D", "C", "B", "A"}, false},
+		{fruit.QueryOptions{Sort: "sku"}, []fruit.ProductID{"B", "C", "A", "D"}, false},
+		{fruit.QueryOptions{Sort: "sku", Desc: true, Limit: 2}, []fruit.ProductID{"D", "A"}, true},
+		{fruit.QueryOptions{Color: "Red", Type: "Apple"}, []fruit.ProductID{"A", "C"}, false},
+		{fruit.QueryOptions{SKU: "4"}, []fruit.ProductID{"D"}, false},
+	} {
+		products, next, err := s.Products(ctx, tt.opt)
+		if err != nil {
+			t.Fatal(err)
+		} else if ids := productIDs(products); !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("%+v: unexpected products: %v", tt.opt, ids)
+		} else if (next != "") != tt.next {
+			t.Errorf("%+v: unexpected next cursor: %q", tt.opt, next)
+		}
+	}
+
+	// Cursors resume where the previous page ended.
+	opt := fruit.QueryOptions{Sort: "sku", Desc: true, Limit: 2}
+	if products, next, err := s.Products(ctx, opt); err != nil {
+		t.Fatal(err)
+	} else if opt.Cursor = next; next == "" {
+		t.Fatal("expected next cursor")
+	} else if products, next, err = s.Products(ctx, opt); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(products); !reflect.DeepEqual(ids, []fruit.ProductID{"C", "B"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	} else if next != "" {
+		t.Fatalf("unexpected next cursor: %q", next)
+	}
+
+	// Tokens are never returned.
+	if products, _, err := s.Products(ctx, fruit.QueryOptions{Limit: 1}); err != nil {
+		t.Fatal(err)
+	} else if products[0].Token != "" {
+		t.Fatalf("unexpected token: %s", products[0].Token)
+	}
+
+	for _, tt := range []struct {
+		opt fruit.QueryOptions
+		err error
+	}{
+		{fruit.QueryOptions{Limit: -1}, fruit.ErrInvalidLimit},
+		{fruit.QueryOptions{Cursor: "X"}, fruit.ErrInvalidCursor},
+		{fruit.QueryOptions{Sort: "token"}, fruit.ErrInvalidSort},
+	} {
+		if _, _, err := s.Products(ctx, tt.opt); err != tt.err {
+			t.Errorf("%+v: unexpected error: %v", tt.opt, err)
+		}
+	}
+}
+
+func testProductService_Search(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+	mustCreateProducts(t, c,
+		&fruit.Product{ID: "1", Name: "Granny Smith Apple", SKU: "APL-GS", Color: "Green", Type: "Apple", Description: "Tart and crisp."},
+		&fruit.Product{ID: "2", Name: "Red Delicious", SKU: "APL-RD", Color: "Red", Type: "Apple", Description: "A sweet apple."},
+		&fruit.Product{ID: "3", Name: "Bartlett Pear", SKU: "PR-BT", Color: "Green", Type: "Pear", Description: "Juicy and sweet."},
+	)
+
+	for _, tt := range []struct {
+		query string
+		opt   fruit.QueryOptions
+		ids   []fruit.ProductID
+	}{
+		{"apple", fruit.QueryOptions{}, []fruit.ProductID{"1", "2"}},
+		{"SWE", fruit.QueryOptions{}, []fruit.ProductID{"2", "3"}},
+		{"green pear", fruit.QueryOptions{}, []fruit.ProductID{"3"}},
+		{"banana", fruit.QueryOptions{}, []fruit.ProductID{}},
+		{"sweet", fruit.QueryOptions{Type: "Pear"}, []fruit.ProductID{"3"}},
+		{"green", fruit.QueryOptions{Limit: 1}, []fruit.ProductID{"1"}},
+	} {
+		if products, _, err := s.Search(ctx, tt.query, tt.opt); err != nil {
+			t.Fatal(err)
+		} else if ids := productIDs(products); !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("%q: unexpected products: %v", tt.query, ids)
+		}
+	}
+
+	// Cursors resume where the previous page ended.
+	if _, next, err := s.Search(ctx, "green", fruit.QueryOptions{Limit: 1}); err != nil {
+		t.Fatal(err)
+	} else if products, next, err := s.Search(ctx, "green", fruit.QueryOptions{Limit: 1, Cursor: next}); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(products); !reflect.DeepEqual(ids, []fruit.ProductID{"3"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	} else if next != "" {
+		t.Fatalf("unexpected next cursor: %q", next)
+	}
+
+	// The index follows updates.
+	if err := s.UpdateProduct(ctx, "2", &fruit.Product{Token: "TOKEN", Name: "Fuji", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if products, _, err := s.Search(ctx, "delicious", fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 0 {
+		t.Fatalf("unexpected products: %v", productIDs(products))
+	}
+
+	if _, _, err := s.Search(ctx, "", fruit.QueryOptions{}); err != fruit.ErrSearchQueryRequired {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_Batch(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+	mustCreateProducts(t, c,
+		&fruit.Product{ID: "A", Name: "Apple"},
+		&fruit.Product{ID: "B", Name: "Banana"},
+	)
+
+	ops := []fruit.BatchOp{
+		{Op: fruit.BatchCreate, Product: &fruit.Product{ID: "C", Name: "Cherry"}, Token: "TOKEN"},
+		{Op: fruit.BatchCreate, Product: &fruit.Product{ID: "A"}, Token: "TOKEN"},
+		{Op: fruit.BatchUpdate, ID: "A", Product: &fruit.Product{Name: "Green Apple", Version: 1}, Token: "TOKEN"},
+		{Op: fruit.BatchUpdate, ID: "B", Product: &fruit.Product{Name: "Plantain", Version: 1}, Token: "OTHER"},
+		{Op: fruit.BatchUpdate, ID: "X", Product: &fruit.Product{Version: 1}, Token: "TOKEN"},
+		{Op: fruit.BatchCreate, Product: &fruit.Product{ID: "D", Price: usd(-1)}, Token: "TOKEN"},
+		{Op: fruit.BatchDelete, ID: "B", Token: "TOKEN"},
+		{Op: "upsert", ID: "E"},
+		{Op: fruit.BatchUpdate, ID: "A", Product: &fruit.Product{Name: "Red Apple"}, Token: "TOKEN"},
+		{Op: fruit.BatchUpdate, ID: "A", Product: &fruit.Product{Name: "Red Apple", Version: 1}, Token: "TOKEN"},
+	}
+	want := []fruit.BatchResult{
+		{Index: 0, ID: "C", Status: fruit.BatchCreated},
+		{Index: 1, ID: "A", Status: fruit.BatchConflict, Err: fruit.ErrProductExists.Error()},
+		{Index: 2, ID: "A", Status: fruit.BatchUpdated},
+		{Index: 3, ID: "B", Status: fruit.BatchUnauthorized, Err: fruit.ErrUnauthorized.Error()},
+		{Index: 4, ID: "X", Status: fruit.BatchNotFound, Err: fruit.ErrProductNotFound.Error()},
+		{Index: 5, ID: "D", Status: fruit.BatchInvalid, Err: fruit.ErrInvalidPrice.Error()},
+		{Index: 6, ID: "B", Status: fruit.BatchDeleted},
+		{Index: 7, ID: "E", Status: fruit.BatchInvalid, Err: fruit.ErrInvalidBatchOp.Error()},
+		{Index: 8, ID: "A", Status: fruit.BatchInvalid, Err: fruit.ErrVersionRequired.Error()},
+		{Index: 9, ID: "A", Status: fruit.BatchConflict, Err: fruit.ErrConflict.Error()},
+	}
+
+	if results, err := s.Batch(ctx, ops, false); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	// Successful operations are saved in order.
+	if products, _, err := s.Products(ctx, fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(products); !reflect.DeepEqual(ids, []fruit.ProductID{"A", "C"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	} else if products[0].Name != "Green Apple" || products[1].Name != "Cherry" {
+		t.Fatalf("unexpected products: %+v, %+v", products[0], products[1])
+	}
+
+	// Batched products are searchable.
+	if products, _, err := s.Search(ctx, "cherry", fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(products); !reflect.DeepEqual(ids, []fruit.ProductID{"C"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	}
+}
+
+func testProductService_Batch_Atomic(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+	mustCreateProducts(t, c, &fruit.Product{ID: "A", Name: "Apple"})
+
+	ops := []fruit.BatchOp{
+		{Op: fruit.BatchCreate, Product: &fruit.Product{ID: "B", Name: "Banana"}, Token: "TOKEN"},
+		{Op: fruit.BatchDelete, ID: "A", Token: "TOKEN"},
+		{Op: fruit.BatchCreate, Product: &fruit.Product{ID: "B"}, Token: "TOKEN"},
+	}
+	want := []fruit.BatchResult{
+		{Index: 0, ID: "B", Status: fruit.BatchAborted, Err: fruit.ErrBatchAborted.Error()},
+		{Index: 1, ID: "A", Status: fruit.BatchAborted, Err: fruit.ErrBatchAborted.Error()},
+		{Index: 2, ID: "B", Status: fruit.BatchConflict, Err: fruit.ErrProductExists.Error()},
+	}
+
+	// A single failure rolls back the whole batch.
+	if results, err := s.Batch(ctx, ops, true); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: %+v", results)
+	} else if p, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apple" {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if _, err := s.Product(ctx, "B"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	// A batch without failures is saved.
+	if results, err := s.Batch(ctx, ops[:2], true); err != nil {
+		t.Fatal(err)
+	} else if results[0].Status != fruit.BatchCreated || results[1].Status != fruit.BatchDeleted {
+		t.Fatalf("unexpected results: %+v", results)
+	} else if _, err := s.Product(ctx, "A"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	} else if _, err := s.Product(ctx, "B"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_Cancel(t *testing.T, c fruit.Client) {
+	s := c.ProductService()
+	mustCreateProducts(t, c, &fruit.Product{ID: "A", Name: "Apple"})
+
+	// Nothing is changed once the context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range []error{
+		s.CreateProduct(ctx, &fruit.Product{ID: "B", Token: "TOKEN"}),
+		s.UpdateProduct(ctx, "A", &fruit.Product{Token: "TOKEN", Name: "Apricot", Version: 1}),
+		s.DeleteProduct(ctx, "A", "TOKEN"),
+		c.UserService().CreateUser(ctx, &fruit.User{ID: "U"}),
+		c.TransactionService().CreateTransaction(ctx, &fruit.Transaction{ID: "T", UserID: "U"}),
+	} {
+		if err != context.Canceled {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if _, err := s.Batch(ctx, []fruit.BatchOp{{Op: fruit.BatchDelete, ID: "A", Token: "TOKEN"}}, false); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx = context.Background()
+	if products, _, err := s.Products(ctx, fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 1 || products[0].Name != "Apple" {
+		t.Fatalf("unexpected products: %v", productIDs(products))
+	} else if _, err := c.UserService().User(ctx, "U"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testProductService_Conflict(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+	mustCreateProducts(t, c, &fruit.Product{ID: "A", Name: "Apple"})
+
+	// Two writers read the same version.
+	p, err := s.Product(ctx, "A")
+	if err != nil {
+		t.Fatal(err)
+	} else if p.Version != 1 {
+		t.Fatalf("unexpected version: %d", p.Version)
+	}
+	p.Token = "TOKEN"
+	first, second := *p, *p
+
+	first.Name = "Apricot"
+	if err := s.UpdateProduct(ctx, "A", &first); err != nil {
+		t.Fatal(err)
+	} else if first.Version != 2 {
+		t.Fatalf("unexpected version: %d", first.Version)
+	}
+
+	// The second write is based on an old version and is rejected.
+	second.Name = "Avocado"
+	if err := s.UpdateProduct(ctx, "A", &second); err != fruit.ErrConflict {
+		t.Fatal(err)
+	} else if p, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apricot" || p.Version != 2 {
+		t.Fatalf("unexpected product: %+v", p)
+	}
+
+	// Stale batch updates are reported as conflicts.
+	if results, err := s.Batch(ctx, []fruit.BatchOp{{Op: fruit.BatchUpdate, ID: "A", Token: "TOKEN", Product: &fruit.Product{Name: "Acerola", Version: 1}}}, false); err != nil {
+		t.Fatal(err)
+	} else if results[0].Status != fruit.BatchConflict {
+		t.Fatalf("unexpected status: %s", results[0].Status)
+	}
+
+	// Updates must name the version they are based on.
+	if err := s.UpdateProduct(ctx, "A", &fruit.Product{Token: "TOKEN", Name: "Avocado"}); err != fruit.ErrVersionRequired {
+		t.Fatal(err)
+	} else if p, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apricot" || p.Version != 2 {
+		t.Fatalf("unexpected product: %+v", p)
+	}
+}
+
+func testProductService_Patch(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+
+	price := fruit.NewMoney(100, "USD")
+	mustCreateProducts(t, c, &fruit.Product{ID: "A", Name: "Apple", SKU: "APL", Color: "Red", Description: "Crisp", Price: &price})
+
+	// Only the named fields change, and blank fields are cleared.
+	p := &fruit.Product{Token: "TOKEN", Name: "Banana", Color: "Yellow", Price: &fruit.Money{Amount: 50}, Version: 1}
+	if err := s.PatchProduct(ctx, "A", p, fruit.FieldMask{"name", "description", "price.amount"}); err != nil {
+		t.Fatal(err)
+	} else if p.Version != 2 {
+		t.Fatalf("unexpected version: %d", p.Version)
+	}
+
+	if other, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if other.Name != "Banana" || other.SKU != "APL" || other.Color != "Red" || other.Description != "" {
+		t.Fatalf("unexpected product: %+v", other)
+	} else if *other.Price != fruit.NewMoney(50, "USD") {
+		t.Fatalf("unexpected price: %+v", other.Price)
+	} else if other.Version != p.Version || !other.ModTime.Equal(p.ModTime) {
+		t.Fatalf("unexpected product: %+v", other)
+	}
+
+	// The product is searchable by its new name.
+	if a, _, err := s.Search(ctx, "banana", fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 1 || a[0].ID != "A" {
+		t.Fatalf("unexpected products: %v", productIDs(a))
+	}
+
+	// Fields which can't be patched, bad tokens and stale versions are
+	// rejected without changes.
+	if err := s.PatchProduct(ctx, "A", &fruit.Product{Token: "TOKEN", ID: "B", Version: 2}, fruit.FieldMask{"productID"}); err != fruit.ErrInvalidField {
+		t.Fatal(err)
+	} else if err := s.PatchProduct(ctx, "A", &fruit.Product{Token: "BAD", Name: "Cherry", Version: 2}, fruit.FieldMask{"name"}); err != fruit.ErrUnauthorized {
+		t.Fatal(err)
+	} else if err := s.PatchProduct(ctx, "A", &fruit.Product{Token: "TOKEN", Name: "Cherry", Version: 1}, fruit.FieldMask{"name"}); err != fruit.ErrConflict {
+		t.Fatal(err)
+	} else if err := s.PatchProduct(ctx, "A", &fruit.Product{Token: "TOKEN", Price: &fruit.Money{Currency: "usd"}, Version: 2}, fruit.FieldMask{"price.currency"}); err != fruit.ErrInvalidCurrency {
+		t.Fatal(err)
+	} else if err := s.PatchProduct(ctx, "B", &fruit.Product{Token: "TOKEN", Version: 1}, fruit.FieldMask{"name"}); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	} else if other, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if other.Name != "Banana" || other.Version != 2 {
+		t.Fatalf("unexpected product: %+v", other)
+	}
+}
+
+func testProductService_Trash(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+
+	mustCreateProducts(t, c,
+		&fruit.Product{ID: "A", Name: "Apple"},
+		&fruit.Product{ID: "B", Name: "Apricot"},
+	)
+	if err := s.DeleteProduct(ctx, "A", "TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleted products are hidden unless asked for.
+	if _, err := s.Product(ctx, "A"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	} else if a, _, err := s.Products(ctx, fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(a); !reflect.DeepEqual(ids, []fruit.ProductID{"B"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	} else if a, _, err := s.Search(ctx, "apple", fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 0 {
+		t.Fatalf("unexpected products: %v", productIDs(a))
+	} else if a, _, err := s.Search(ctx, "apple", fruit.QueryOptions{IncludeDeleted: true}); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(a); !reflect.DeepEqual(ids, []fruit.ProductID{"A"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	}
+
+	a, _, err := s.Products(ctx, fruit.QueryOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(a); !reflect.DeepEqual(ids, []fruit.ProductID{"A", "B"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	} else if a[0].DeletedAt == nil || a[1].DeletedAt != nil {
+		t.Fatalf("unexpected deletion times: %v, %v", a[0].DeletedAt, a[1].DeletedAt)
+	}
+	deletedAt := *a[0].DeletedAt
+
+	// Deleted products can't be changed and their IDs can't be reused.
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "A", Token: "TOKEN"}); err != fruit.ErrProductExists {
+		t.Fatal(err)
+	} else if err := s.UpdateProduct(ctx, "A", &fruit.Product{Token: "TOKEN", Name: "Avocado", Version: 1}); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	} else if err := s.DeleteProduct(ctx, "A", "TOKEN"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	// Restoring brings the product back unchanged.
+	if err := s.RestoreProduct(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p, err := s.Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apple" || p.DeletedAt != nil {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if err := s.RestoreProduct(ctx, "A"); err != fruit.ErrProductNotDeleted {
+		t.Fatal(err)
+	} else if err := s.RestoreProduct(ctx, "C"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	// Only products deleted before the given time are purged.
+	if err := s.DeleteProduct(ctx, "A", "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if a, _, err := s.Products(ctx, fruit.QueryOptions{IncludeDeleted: true}); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 || a[0].DeletedAt == nil {
+		t.Fatalf("unexpected products: %v", productIDs(a))
+	} else {
+		deletedAt = *a[0].DeletedAt
+	}
+
+	if n, err := s.PurgeProducts(ctx, deletedAt); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatalf("unexpected purged: %d", n)
+	} else if n, err := s.PurgeProducts(ctx, deletedAt.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("unexpected purged: %d", n)
+	} else if err := s.RestoreProduct(ctx, "A"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	} else if a, _, err := s.Products(ctx, fruit.QueryOptions{IncludeDeleted: true}); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(a); !reflect.DeepEqual(ids, []fruit.ProductID{"B"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	}
+
+	// Purged IDs can be reused.
+	mustCreateProducts(t, c, &fruit.Product{ID: "A", Name: "Avocado"})
+}
+
+// Ensure a deletion time sent with a new product is ignored so it can't be
+// created straight into the trash and purged.
+func testProductService_Trash_Create(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.ProductService()
+	deletedAt := time.Date(1999, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "A", Token: "TOKEN", DeletedAt: &deletedAt}); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Batch(ctx, []fruit.BatchOp{
+		{Op: fruit.BatchCreate, Product: &fruit.Product{ID: "B", DeletedAt: &deletedAt}, Token: "TOKEN"},
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := s.PurgeProducts(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatalf("unexpected purged count: %d", n)
+	}
+	for _, id := range []fruit.ProductID{"A", "B"} {
+		if p, err := s.Product(ctx, id); err != nil {
+			t.Fatal(err)
+		} else if p.DeletedAt != nil {
+			t.Fatalf("unexpected deletion time: %v", p.DeletedAt)
+		}
+	}
+}
+
+func testUserService_CRUD(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.UserService()
+
+	u := &fruit.User{ID: "U", Name: "Alice", Address: &fruit.Address{City: "Denver"}}
+	if err := s.CreateUser(ctx, u); err != nil {
+		t.Fatal(err)
+	} else if other, err := s.User(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, u) {
+		t.Fatalf("unexpected user: %+v", other)
+	}
+
+	// Updates replace every field.
+	if err := s.UpdateUser(ctx, "U", &fruit.User{Name: "Bob", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if other, err := s.User(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if other.Name != "Bob" || other.Address != nil {
+		t.Fatalf("unexpected user: %+v", other)
+	}
+
+	if err := s.CreateUser(ctx, &fruit.User{}); err != fruit.ErrUserIDRequired {
+		t.Fatal(err)
+	} else if err := s.CreateUser(ctx, &fruit.User{ID: "U"}); err != fruit.ErrUserExists {
+		t.Fatal(err)
+	} else if err := s.UpdateUser(ctx, "X", &fruit.User{Version: 1}); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	} else if err := s.DeleteUser(ctx, "X"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteUser(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.User(ctx, "U"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_Users(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.UserService()
+	for _, u := range []*fruit.User{{ID: "A", Name: "Carol"}, {ID: "B", Name: "Alice"}, {ID: "C", Name: "Bob"}} {
+		if err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users, next, err := s.Users(ctx, fruit.QueryOptions{Sort: "name", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(users) != 2 || users[0].ID != "B" || users[1].ID != "C" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+
+	if users, next, err = s.Users(ctx, fruit.QueryOptions{Sort: "name", Limit: 2, Cursor: next}); err != nil {
+		t.Fatal(err)
+	} else if len(users) != 1 || users[0].ID != "A" || next != "" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+
+	if _, _, err := s.Users(ctx, fruit.QueryOptions{Sort: "cardID"}); err != fruit.ErrInvalidSort {
+		t.Fatal(err)
+	}
+}
+
+func testUserService_Conflict(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.UserService()
+
+	u := &fruit.User{ID: "A", Name: "Alice"}
+	if err := s.CreateUser(ctx, u); err != nil {
+		t.Fatal(err)
+	} else if u.Version != 1 {
+		t.Fatalf("unexpected version: %d", u.Version)
+	}
+
+	if err := s.UpdateUser(ctx, "A", &fruit.User{Name: "Alicia", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateUser(ctx, "A", &fruit.User{Name: "Ally", Version: 1}); err != fruit.ErrConflict {
+		t.Fatal(err)
+	} else if other, err := s.User(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if other.Name != "Alicia" || other.Version != 2 {
+		t.Fatalf("unexpected user: %+v", other)
+	}
+}
+
+func testUserService_Patch(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.UserService()
+
+	if err := s.CreateUser(ctx, &fruit.User{ID: "A", Name: "Alice", CardID: "CARD", Address: &fruit.Address{Line1: "1 Main St", City: "Springfield"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Address fields can be changed individually.
+	u := &fruit.User{Address: &fruit.Address{City: "Shelbyville"}, Version: 1}
+	if err := s.PatchUser(ctx, "A", u, fruit.FieldMask{"address.city"}); err != nil {
+		t.Fatal(err)
+	} else if u.Name != "Alice" || u.CardID != "CARD" || u.Version != 2 {
+		t.Fatalf("unexpected user: %+v", u)
+	} else if other, err := s.User(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other.Address, &fruit.Address{Line1: "1 Main St", City: "Shelbyville"}) {
+		t.Fatalf("unexpected address: %+v", other.Address)
+	}
+
+	// The whole address can be removed.
+	if err := s.PatchUser(ctx, "A", &fruit.User{Name: "Alicia", Version: 2}, fruit.FieldMask{"name", "address"}); err != nil {
+		t.Fatal(err)
+	} else if other, err := s.User(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if other.Name != "Alicia" || other.CardID != "CARD" || other.Address != nil {
+		t.Fatalf("unexpected user: %+v", other)
+	}
+
+	if err := s.PatchUser(ctx, "A", &fruit.User{Version: 3}, fruit.FieldMask{"address.planet"}); err != fruit.ErrInvalidField {
+		t.Fatal(err)
+	} else if err := s.PatchUser(ctx, "A", &fruit.User{Version: 1}, fruit.FieldMask{"name"}); err != fruit.ErrConflict {
+		t.Fatal(err)
+	} else if err := s.PatchUser(ctx, "B", &fruit.User{Version: 1}, fruit.FieldMask{"name"}); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testTransactionService(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	s := c.TransactionService()
+
+	for _, tr := range []*fruit.Transaction{
+		{ID: "T2", UserID: "U", Count: 1, Active: true},
+		{ID: "T1", UserID: "U", Count: 2},
+		{ID: "T3", UserID: "OTHER"},
+	} {
+		if err := s.CreateTransaction(ctx, tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if a, err := s.Transactions(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 || a[0].ID != "T1" || a[1].ID != "T2" {
+		t.Fatalf("unexpected transactions: %+v", a)
+	}
+
+	// Updates can reset fields.
+	tr := &fruit.Transaction{UserID: "U", Count: 5}
+	if err := s.UpdateTransaction(ctx, "T2", tr); err != nil {
+		t.Fatal(err)
+	} else if other, err := s.Transaction(ctx, "T2"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, tr) || other.Active {
+		t.Fatalf("unexpected transaction: %+v", other)
+	}
+
+	if err := s.CreateTransaction(ctx, &fruit.Transaction{ID: "T1"}); err != fruit.ErrTransactionExists {
+		t.Fatal(err)
+	} else if err := s.CreateTransaction(ctx, &fruit.Transaction{}); err != fruit.ErrTransactionIDRequired {
+		t.Fatal(err)
+	} else if err := s.UpdateTransaction(ctx, "X", &fruit.Transaction{}); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	} else if err := s.DeleteTransaction(ctx, "X"); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	} else if err := s.DeleteTransaction(ctx, "T1"); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Transaction(ctx, "T1"); err != fruit.ErrTransactionNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testCategoryService(t *testing.T, c Client) {
+	ctx := context.Background()
+	s := c.CategoryService()
+
+	for _, cat := range []*fruit.Category{
+		{ID: "FRUIT", Name: "Fresh Fruit"},
+		{ID: "APPLES", Name: "Apples", ParentID: "FRUIT"},
+		{ID: "PEARS", Name: "Pears", ParentID: "FRUIT"},
+	} {
+		if err := s.CreateCategory(cat); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if cat, err := s.Category("FRUIT"); err != nil {
+		t.Fatal(err)
+	} else if cat.Slug != "fresh-fruit" {
+		t.Fatalf("unexpected slug: %s", cat.Slug)
+	}
+
+	mustCreateProducts(t, c,
+		&fruit.Product{ID: "P1", CategoryID: "PEARS"},
+		&fruit.Product{ID: "P2", CategoryID: "APPLES"},
+		&fruit.Product{ID: "P3", CategoryID: "FRUIT"},
+	)
+
+	if products, err := s.CategoryProducts("FRUIT"); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(products); !reflect.DeepEqual(ids, []fruit.ProductID{"P3", "P2", "P1"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	}
+
+	for _, tt := range []struct {
+		err  error
+		want error
+	}{
+		{c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "X", Token: "TOKEN", CategoryID: "X"}), fruit.ErrCategoryNotFound},
+		{s.CreateCategory(&fruit.Category{ID: "FRUIT"}), fruit.ErrCategoryExists},
+		{s.CreateCategory(&fruit.Category{ID: "X", Name: "Apples"}), fruit.ErrCategorySlugExists},
+		{s.CreateCategory(&fruit.Category{ID: "X", ParentID: "Y"}), fruit.ErrCategoryParentNotFound},
+		{s.UpdateCategory("FRUIT", &fruit.Category{Name: "Fruit", ParentID: "APPLES"}), fruit.ErrCategoryCycle},
+		{s.UpdateCategory("APPLES", &fruit.Category{Name: "Pears"}), fruit.ErrCategorySlugExists},
+		{s.DeleteCategory("FRUIT"), fruit.ErrCategoryNotEmpty},
+		{s.DeleteCategory("PEARS"), fruit.ErrCategoryNotEmpty},
+		{s.DeleteCategory("X"), fruit.ErrCategoryNotFound},
+	} {
+		if tt.err != tt.want {
+			t.Errorf("unexpected error: got %v, want %v", tt.err, tt.want)
+		}
+	}
+
+	// Batches report a missing category as an invalid op.
+	if results, err := c.ProductService().Batch(ctx, []fruit.BatchOp{
+		{Op: fruit.BatchUpdate, ID: "P2", Token: "TOKEN", Product: &fruit.Product{CategoryID: "X", Version: 1}},
+	}, false); err != nil {
+		t.Fatal(err)
+	} else if results[0].Status != fruit.BatchInvalid || results[0].Err != fruit.ErrCategoryNotFound.Error() {
+		t.Fatalf("unexpected result: %+v", results[0])
+	}
+
+	if err := c.ProductService().DeleteProduct(ctx, "P1", "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if err := s.DeleteCategory("PEARS"); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, err := s.Categories(); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 || a[0].ID != "APPLES" || a[1].ID != "FRUIT" {
+		t.Fatalf("unexpected categories: %+v", a)
+	}
+}
+
+func testAPIKeyService(t *testing.T, c Client) {
+	s := c.APIKeyService()
+
+	k := &fruit.APIKey{UserID: "U", Admin: true}
+	if err := s.CreateAPIKey(k); err != nil {
+		t.Fatal(err)
+	} else if k.Key == "" {
+		t.Fatal("expected generated key")
+	}
+
+	// Only the hash of the key is kept.
+	if other, err := s.APIKey(k.Key); err != nil {
+		t.Fatal(err)
+	} else if other.Key != token.Hash(k.Key) {
+		t.Fatalf("unexpected stored key: %s", other.Key)
+	} else if other.Key = k.Key; !reflect.DeepEqual(other, k) {
+		t.Fatalf("unexpected key: %+v", other)
+	} else if _, err := s.APIKey(token.Hash(k.Key)); err != fruit.ErrAPIKeyNotFound {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateAPIKey(&fruit.APIKey{Key: k.Key}); err != fruit.ErrAPIKeyExists {
+		t.Fatal(err)
+	} else if _, err := s.APIKey(""); err != fruit.ErrAPIKeyRequired {
+		t.Fatal(err)
+	} else if err := s.DeleteAPIKey(k.Key); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.APIKey(k.Key); err != fruit.ErrAPIKeyNotFound {
+		t.Fatal(err)
+	} else if err := s.DeleteAPIKey(k.Key); err != fruit.ErrAPIKeyNotFound {
+		t.Fatal(err)
+	}
+}
+
+// mustCreateShop creates a user and two priced products.
+func mustCreateShop(t *testing.T, c fruit.Client) {
+	ctx := context.Background()
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "USER"}); err != nil {
+		t.Fatal(err)
+	}
+	mustCreateProducts(t, c,
+		&fruit.Product{ID: "APPLE", Name: "Apple", Price: usd(100)},
+		&fruit.Product{ID: "PEAR", Name: "Pear", Price: usd(250)},
+	)
+}
+
+func testCartService(t *testing.T, c Client) {
+	s := c.CartService()
+	mustCreateShop(t, c)
+
+	if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart, &fruit.Cart{UserID: "USER", Items: []fruit.CartItem{}}) {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+
+	if err := s.AddCartItem("USER", "APPLE", 1); err != nil {
+		t.Fatal(err)
+	} else if err := s.AddCartItem("USER", "PEAR", 1); err != nil {
+		t.Fatal(err)
+	} else if err := s.AddCartItem("USER", "APPLE", 2); err != nil {
+		t.Fatal(err)
+	} else if err := s.UpdateCartItem("USER", "PEAR", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart.Items, []fruit.CartItem{{ProductID: "APPLE", Quantity: 3}, {ProductID: "PEAR", Quantity: 5}}) {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	for _, tt := range []struct {
+		err  error
+		want error
+	}{
+		{s.AddCartItem("", "APPLE", 1), fruit.ErrUserIDRequired},
+		{s.AddCartItem("USER", "", 1), fruit.ErrProductIDRequired},
+		{s.AddCartItem("X", "APPLE", 1), fruit.ErrUserNotFound},
+		{s.AddCartItem("USER", "X", 1), fruit.ErrProductNotFound},
+		{s.AddCartItem("USER", "APPLE", 0), fruit.ErrInvalidQuantity},
+		{s.UpdateCartItem("USER", "APPLE", -1), fruit.ErrInvalidQuantity},
+		{s.RemoveCartItem("USER", "X"), fruit.ErrCartItemNotFound},
+		{s.ClearCart(""), fruit.ErrUserIDRequired},
+	} {
+		if tt.err != tt.want {
+			t.Errorf("unexpected error: got %v, want %v", tt.err, tt.want)
+		}
+	}
+
+	if err := s.RemoveCartItem("USER", "APPLE"); err != nil {
+		t.Fatal(err)
+	} else if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cart.Items, []fruit.CartItem{{ProductID: "PEAR", Quantity: 5}}) {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	if err := s.ClearCart("USER"); err != nil {
+		t.Fatal(err)
+	} else if cart, err := s.Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(cart.Items) != 0 {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+}
+
+func testOrderService_Checkout(t *testing.T, c Client) {
+	s := c.OrderService()
+	mustCreateShop(t, c)
+
+	if err := c.InventoryService().AdjustStock("APPLE", 5); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "APPLE", 2); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "PEAR", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := s.Checkout("USER")
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(o.Items, []fruit.OrderItem{
+		{ProductID: "APPLE", Name: "Apple", Price: *usd(100), Quantity: 2},
+		{ProductID: "PEAR", Name: "Pear", Price: *usd(250), Quantity: 1},
+	}) {
+		t.Fatalf("unexpected items: %+v", o.Items)
+	} else if o.Total != *usd(450) {
+		t.Fatalf("unexpected total: %+v", o.Total)
+	}
+
+	if other, err := s.Order(o.ID); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, o) {
+		t.Fatalf("unexpected order: %+v", other)
+	} else if orders, err := s.Orders("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(orders) != 1 || orders[0].ID != o.ID {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+
+	// Stock is reserved and the cart is emptied.
+	if st, err := c.InventoryService().Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if st.Reserved != 2 {
+		t.Fatalf("unexpected stock: %+v", st)
+	} else if cart, err := c.CartService().Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(cart.Items) != 0 {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	// The order is recorded in the change feed after the shop's setup.
+	sub, err := c.EventService().Subscribe(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	e := mustReceive(t, sub)
+	for e.Type != fruit.EventOrderCreated {
+		e = mustReceive(t, sub)
+	}
+	if e.OrderID != o.ID || e.UserID != "USER" || !reflect.DeepEqual(e.Order, o) {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
+
+func testOrderService_Errors(t *testing.T, c Client) {
+	ctx := context.Background()
+	s := c.OrderService()
+	mustCreateShop(t, c)
+
+	if _, err := s.Checkout(""); err != fruit.ErrUserIDRequired {
+		t.Fatal(err)
+	} else if _, err := s.Checkout("X"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	} else if _, err := s.Checkout("USER"); err != fruit.ErrCartEmpty {
+		t.Fatal(err)
+	} else if _, err := s.Order("X"); err != fruit.ErrOrderNotFound {
+		t.Fatal(err)
+	}
+
+	// A failed checkout changes nothing.
+	if err := c.InventoryService().AdjustStock("APPLE", 5); err != nil {
+		t.Fatal(err)
+	} else if err := c.InventoryService().AdjustStock("PEAR", 1); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "APPLE", 2); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().AddCartItem("USER", "PEAR", 2); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Checkout("USER"); err != fruit.ErrInsufficientStock {
+		t.Fatal(err)
+	}
+
+	if st, err := c.InventoryService().Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if st.Reserved != 0 {
+		t.Fatalf("unexpected stock: %+v", st)
+	} else if cart, err := c.CartService().Cart("USER"); err != nil {
+		t.Fatal(err)
+	} else if len(cart.Items) != 2 {
+		t.Fatalf("unexpected items: %+v", cart.Items)
+	}
+
+	// Currencies can't be mixed.
+	if err := c.ProductService().UpdateProduct(ctx, "PEAR", &fruit.Product{Token: "TOKEN", Price: &fruit.Money{Amount: 1, Currency: "EUR"}, Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().UpdateCartItem("USER", "PEAR", 1); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Checkout("USER"); err != fruit.ErrCurrencyMismatch {
+		t.Fatal(err)
+	}
+
+	// Totals which can't be represented are rejected rather than wrapped.
+	if err := c.ProductService().UpdateProduct(ctx, "PEAR", &fruit.Product{Token: "TOKEN", Price: usd(math.MaxInt64 / 2), Version: 2}); err != nil {
+		t.Fatal(err)
+	} else if err := c.InventoryService().AdjustStock("PEAR", 2); err != nil {
+		t.Fatal(err)
+	} else if err := c.CartService().UpdateCartItem("USER", "PEAR", 3); err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Checkout("USER"); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	}
+}
+
+func testInventoryService(t *testing.T, c Client) {
+	s := c.InventoryService()
+	mustCreateShop(t, c)
+
+	if st, err := s.Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(st, &fruit.Stock{ProductID: "APPLE"}) {
+		t.Fatalf("unexpected stock: %+v", st)
+	}
+
+	for _, tt := range []struct {
+		err  error
+		want error
+	}{
+		{s.AdjustStock("APPLE", 10), nil},
+		{s.ReserveStock("APPLE", 6), nil},
+		{s.ReserveStock("APPLE", 5), fruit.ErrInsufficientStock},
+		{s.AdjustStock("APPLE", -5), fruit.ErrInsufficientStock},
+		{s.ReleaseStock("APPLE", 2), nil},
+		{s.CommitStock("APPLE", 3), nil},
+		{s.CommitStock("APPLE", 2), fruit.ErrReservationExceeded},
+		{s.ReleaseStock("APPLE", 0), fruit.ErrInvalidQuantity},
+		{s.ReserveStock("PEAR", 100), nil},
+		{s.ReserveStock("X", 1), fruit.ErrProductNotFound},
+		{s.AdjustStock("", 1), fruit.ErrProductIDRequired},
+	} {
+		if tt.err != tt.want {
+			t.Errorf("unexpected error: got %v, want %v", tt.err, tt.want)
+		}
+	}
+
+	if st, err := s.Stock("APPLE"); err != nil {
+		t.Fatal(err)
+	} else if st.OnHand != 7 || st.Reserved != 1 {
+		t.Fatalf("unexpected stock: %+v", st)
+	} else if _, err := s.Stock("X"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+}
+
+func testInventoryService_Trash(t *testing.T, c Client) {
+	ctx := context.Background()
+	s := c.InventoryService()
+	mustCreateShop(t, c)
+
+	if err := s.AdjustStock("APPLE", 5); err != nil {
+		t.Fatal(err)
+	} else if err := c.ProductService().DeleteProduct(ctx, "APPLE", "TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Trashed products have no stock to read or change.
+	if _, err := s.Stock("APPLE"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+	for _, err := range []error{
+		s.AdjustStock("APPLE", 1),
+		s.ReserveStock("APPLE", 1),
+		s.ReleaseStock("APPLE", 1),
+		s.CommitStock("APPLE", 1),
+	} {
+		if err != fruit.ErrProductNotFound {
+			t.Errorf("unexpected error: got %v, want %v", err, fruit.ErrProductNotFound)
+		}
+	}
+}
+
+func testInventoryService_Concurrent(t *testing.T, c Client) {
+	s := c.InventoryService()
+	mustCreateShop(t, c)
+
+	if err := s.AdjustStock("APPLE", 20); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.ReserveStock("APPLE", 1); err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			} else if err != fruit.ErrInsufficientStock {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 20 {
+		t.Fatalf("unexpected reservations: %d", reserved)
+	}
+}
+
+func testImportService_Products(t *testing.T, c Client) {
+	ctx := context.Background()
+	s := c.ImportService()
+	mustCreateProducts(t, c, &fruit.Product{ID: "A", Name: "Apple", SKU: "APL"})
+
+	a := []*fruit.Product{
+		{ID: "A", Token: "TOKEN", Name: "Green Apple"},
+		{ID: "B", Token: "TOKEN", Name: "Banana", Price: usd(10)},
+		{ID: "C", Token: "TOKEN", Price: usd(-1)},
+		{ID: "A", Token: "OTHER"},
+		{Token: "TOKEN"},
+		{ID: "B", Token: "TOKEN", Color: "Yellow"},
+	}
+	want := []fruit.ImportResult{
+		{ID: "A", Action: fruit.ImportUpdated},
+		{ID: "B", Action: fruit.ImportCreated},
+		{ID: "C", Err: fruit.ErrInvalidPrice.Error()},
+		{ID: "A", Err: fruit.ErrUnauthorized.Error()},
+		{Err: fruit.ErrProductIDRequired.Error()},
+		{ID: "B", Action: fruit.ImportUpdated},
+	}
+
+	// A dry run reports results without saving anything.
+	if results, err := s.ImportProducts(a, true); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: %+v", results)
+	} else if p, err := c.ProductService().Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Apple" {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if _, err := c.ProductService().Product(ctx, "B"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	if results, err := s.ImportProducts(a, false); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	if p, err := c.ProductService().Product(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Green Apple" || p.SKU != "APL" {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if p, err := c.ProductService().Product(ctx, "B"); err != nil {
+		t.Fatal(err)
+	} else if p.Name != "Banana" || p.Color != "Yellow" || *p.Price != *usd(10) {
+		t.Fatalf("unexpected product: %+v", p)
+	} else if _, err := c.ProductService().Product(ctx, "C"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	}
+
+	// Imported products are searchable.
+	if products, _, err := c.ProductService().Search(ctx, "banana", fruit.QueryOptions{}); err != nil {
+		t.Fatal(err)
+	} else if ids := productIDs(products); !reflect.DeepEqual(ids, []fruit.ProductID{"B"}) {
+		t.Fatalf("unexpected products: %v", ids)
+	}
+}
+
+func testImportService_Users(t *testing.T, c Client) {
+	ctx := context.Background()
+	s := c.ImportService()
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "A", Name: "Alice", CardID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := []*fruit.User{
+		{ID: "A", Name: "Alicia"},
+		{ID: "B", Name: "Bob"},
+		{},
+	}
+	want := []fruit.ImportResult{
+		{ID: "A", Action: fruit.ImportUpdated},
+		{ID: "B", Action: fruit.ImportCreated},
+		{Err: fruit.ErrUserIDRequired.Error()},
+	}
+
+	if results, err := s.ImportUsers(a, true); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: %+v", results)
+	} else if _, err := c.UserService().User(ctx, "B"); err != fruit.ErrUserNotFound {
+		t.Fatal(err)
+	}
+
+	if results, err := s.ImportUsers(a, false); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	// Existing users are replaced.
+	if u, err := c.UserService().User(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if u.Name != "Alicia" || u.CardID != "" {
+		t.Fatalf("unexpected user: %+v", u)
+	} else if _, err := c.UserService().User(ctx, "B"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mustReceive returns the next event delivered to sub.
+func mustReceive(t *testing.T, sub fruit.Subscription) *fruit.Event {
+	t.Helper()
+	select {
+	case e, ok := <-sub.Events():
+		if !ok {
+			t.Fatalf("subscription closed: %v", sub.Close())
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return nil
+	}
+}
+
+func testEventService(t *testing.T, c Client) {
+	ctx := context.Background()
+	sub, err := c.EventService().Subscribe(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// Changes are delivered as they happen.
+	mustCreateProducts(t, c, &fruit.Product{ID: "A", Name: "Apple"})
+	if e := mustReceive(t, sub); e.Seq != 1 || e.Type != fruit.EventProductCreated || e.ProductID != "A" || e.Product.Name != "Apple" {
+		t.Fatalf("unexpected event: %+v", e)
+	} else if e.Product.Token != "" {
+		t.Fatal("expected token to be hidden")
+	} else if e.Time.IsZero() {
+		t.Fatal("expected event time")
+	}
+
+	if err := c.ProductService().UpdateProduct(ctx, "A", &fruit.Product{Token: "TOKEN", Color: "Red", Version: 1}); err != nil {
+		t.Fatal(err)
+	} else if e := mustReceive(t, sub); e.Seq != 2 || e.Type != fruit.EventProductUpdated || e.Product.Name != "Apple" || e.Product.Color != "Red" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	// Rolled back changes aren't recorded.
+	if _, err := c.ProductService().Batch(ctx, []fruit.BatchOp{
+		{Op: fruit.BatchDelete, ID: "A", Token: "TOKEN"},
+		{Op: fruit.BatchDelete, ID: "X", Token: "TOKEN"},
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U", Name: "Una"}); err != nil {
+		t.Fatal(err)
+	} else if e := mustReceive(t, sub); e.Seq != 3 || e.Type != fruit.EventUserCreated || e.UserID != "U" || e.User.Name != "Una" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	if err := c.UserService().DeleteUser(ctx, "U"); err != nil {
+		t.Fatal(err)
+	} else if e := mustReceive(t, sub); e.Seq != 4 || e.Type != fruit.EventUserDeleted || e.UserID != "U" || e.User != nil {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	if err := c.ProductService().DeleteProduct(ctx, "A", "TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if e := mustReceive(t, sub); e.Seq != 5 || e.Type != fruit.EventProductDeleted || e.ProductID != "A" || e.Product != nil {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	// Closing ends delivery.
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := <-sub.Events(); ok {
+		t.Fatal("expected closed channel")
+	}
+}
+
+func testEventService_Since(t *testing.T, c Client) {
+	mustCreateProducts(t, c,
+		&fruit.Product{ID: "A"},
+		&fruit.Product{ID: "B"},
+		&fruit.Product{ID: "C"},
+	)
+
+	// Past events after the given sequence number are replayed.
+	sub, err := c.EventService().Subscribe(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if e := mustReceive(t, sub); e.Seq != 2 || e.ProductID != "B" {
+		t.Fatalf("unexpected event: %+v", e)
+	} else if e := mustReceive(t, sub); e.Seq != 3 || e.ProductID != "C" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	// Replay continues into new events.
+	mustCreateProducts(t, c, &fruit.Product{ID: "D"})
+	if e := mustReceive(t, sub); e.Seq != 4 || e.ProductID != "D" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
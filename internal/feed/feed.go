@@ -0,0 +1,143 @@
+// Package feed implements the change feed subscriptions shared by the
+// storage backends.
+package feed
+
+import (
+	"sync"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// BatchSize is the most events a subscription reads at once.
+const BatchSize = 100
+
+// ReadFunc returns up to n events after the sequence number since, in
+// order.
+type ReadFunc func(since uint64, n int) ([]*fruit.Event, error)
+
+// Broker wakes subscriptions when events are appended. The zero value is
+// ready to use.
+type Broker struct {
+	mu      sync.Mutex
+	changed chan struct{}
+	closed  chan struct{}
+}
+
+// Notify wakes every subscription. It must be called once new events have
+// been committed.
+func (b *Broker) Notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.changed != nil {
+		close(b.changed)
+		b.changed = nil
+	}
+}
+
+// Close ends every subscription. Later subscriptions are unaffected, so
+// the store can be reopened.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed != nil {
+		close(b.closed)
+		b.closed = nil
+	}
+}
+
+// wait returns channels which are closed by the next Notify and by Close.
+func (b *Broker) wait() (changed, closed <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.changed == nil {
+		b.changed = make(chan struct{})
+	}
+	if b.closed == nil {
+		b.closed = make(chan struct{})
+	}
+	return b.changed, b.closed
+}
+
+// Subscription delivers events read from a store, replaying past events
+// before waiting for new ones. It implements fruit.Subscription.
+type Subscription struct {
+	c       chan *fruit.Event
+	closing chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// Subscribe returns a subscription to events after the sequence number
+// since. Events are fetched with read whenever b is notified.
+func Subscribe(b *Broker, since uint64, read ReadFunc) *Subscription {
+	s := &Subscription{
+		c:       make(chan *fruit.Event),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// The close signal is taken now so a broker closed before the
+	// subscription starts still ends it.
+	_, closed := b.wait()
+	go s.run(b, closed, since, read)
+	return s
+}
+
+// run delivers events until the subscription or broker is closed.
+func (s *Subscription) run(b *Broker, closed <-chan struct{}, since uint64, read ReadFunc) {
+	defer close(s.done)
+	defer close(s.c)
+
+	for {
+		// Wait on the signal taken before reading, so events appended
+		// during the read aren't missed.
+		changed, _ := b.wait()
+
+		events, err := read(since, BatchSize)
+		if err != nil {
+			// Reads fail once the store is closed, which isn't an error.
+			select {
+			case <-closed:
+			default:
+				s.err = err
+			}
+			return
+		}
+
+		for _, e := range events {
+			select {
+			case s.c <- e:
+				since = e.Seq
+			case <-s.closing:
+				return
+			case <-closed:
+				return
+			}
+		}
+
+		// Read again immediately if there may be more events.
+		if len(events) == BatchSize {
+			continue
+		}
+
+		select {
+		case <-changed:
+		case <-s.closing:
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Events returns the channel events are delivered on.
+func (s *Subscription) Events() <-chan *fruit.Event { return s.c }
+
+// Close ends the subscription and returns the error which ended it early,
+// if any.
+func (s *Subscription) Close() error {
+	s.once.Do(func() { close(s.closing) })
+	<-s.done
+	return s.err
+}
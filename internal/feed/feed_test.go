@@ -0,0 +1,80 @@
+package feed_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/feed"
+)
+
+// Log represents a test event log.
+type Log struct {
+	mu     sync.Mutex
+	events []*fruit.Event
+	broker feed.Broker
+}
+
+// Append adds n events to the log and notifies subscribers.
+func (l *Log) Append(n int) {
+	l.mu.Lock()
+	for i := 0; i < n; i++ {
+		l.events = append(l.events, &fruit.Event{Seq: uint64(len(l.events)) + 1})
+	}
+	l.mu.Unlock()
+	l.broker.Notify()
+}
+
+// Read implements feed.ReadFunc.
+func (l *Log) Read(since uint64, n int) ([]*fruit.Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a := l.events[since:]
+	if len(a) > n {
+		a = a[:n]
+	}
+	return append([]*fruit.Event(nil), a...), nil
+}
+
+func TestSubscribe(t *testing.T) {
+	var l Log
+	l.Append(feed.BatchSize*2 + 1)
+
+	sub := feed.Subscribe(&l.broker, 0, l.Read)
+	defer sub.Close()
+
+	// Events are replayed across several reads, then followed.
+	go l.Append(1)
+	for i := 1; i <= feed.BatchSize*2+2; i++ {
+		select {
+		case e := <-sub.Events():
+			if e.Seq != uint64(i) {
+				t.Fatalf("unexpected seq: %d, expected %d", e.Seq, i)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+}
+
+func TestBroker_Close(t *testing.T) {
+	var l Log
+	sub := feed.Subscribe(&l.broker, 0, l.Read)
+
+	// Closing the broker ends waiting subscriptions.
+	l.broker.Close()
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Fatal("unexpected event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for close")
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,56 @@
+// Package search implements the product text matching shared by the
+// storage backends.
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// Relative weight of a term found in each product field.
+const (
+	NameWeight        = 4
+	SKUWeight         = 4
+	ColorWeight       = 2
+	DescriptionWeight = 1
+)
+
+// Tokenize splits s into lowercase words.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Terms returns the searchable terms of p and their weights.
+func Terms(p *fruit.Product) map[string]int {
+	weights := make(map[string]int)
+	for _, f := range []struct {
+		text   string
+		weight int
+	}{
+		{p.Name, NameWeight},
+		{p.SKU, SKUWeight},
+		{p.Color, ColorWeight},
+		{p.Description, DescriptionWeight},
+	} {
+		for _, term := range Tokenize(f.text) {
+			weights[term] += f.weight
+		}
+	}
+	return weights
+}
+
+// Score returns how well a term matches a query word. Words match any term
+// they are a prefix of, and exact matches score double. Returns zero if the
+// term doesn't match.
+func Score(word, term string, weight int) int {
+	if term == word {
+		return weight * 2
+	} else if strings.HasPrefix(term, word) {
+		return weight
+	}
+	return 0
+}
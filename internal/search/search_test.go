@@ -0,0 +1,38 @@
+package search_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/search"
+)
+
+func TestTokenize(t *testing.T) {
+	if a := search.Tokenize("Granny-Smith APPLE, 2kg!"); !reflect.DeepEqual(a, []string{"granny", "smith", "apple", "2kg"}) {
+		t.Fatalf("unexpected tokens: %v", a)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	p := &fruit.Product{Name: "Red Apple", SKU: "APL-1", Color: "Red", Description: "An apple."}
+	if m := search.Terms(p); !reflect.DeepEqual(m, map[string]int{
+		"red":   search.NameWeight + search.ColorWeight,
+		"apple": search.NameWeight + search.DescriptionWeight,
+		"apl":   search.SKUWeight,
+		"1":     search.SKUWeight,
+		"an":    search.DescriptionWeight,
+	}) {
+		t.Fatalf("unexpected terms: %v", m)
+	}
+}
+
+func TestScore(t *testing.T) {
+	if n := search.Score("apple", "apple", 3); n != 6 {
+		t.Fatalf("unexpected exact score: %d", n)
+	} else if n := search.Score("app", "apple", 3); n != 3 {
+		t.Fatalf("unexpected prefix score: %d", n)
+	} else if n := search.Score("pear", "apple", 3); n != 0 {
+		t.Fatalf("unexpected score: %d", n)
+	}
+}
@@ -0,0 +1,85 @@
+// Package testdb opens storage clients on temporary files for the tests of
+// the storage backends and the services built on them.
+package testdb
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/notjrbauer/fruit/bolt"
+	"github.com/notjrbauer/fruit/sql"
+	_ "modernc.org/sqlite"
+)
+
+// Bolt represents a bolt client on a temporary file. Close removes the
+// file.
+type Bolt struct {
+	*bolt.Client
+}
+
+// NewBolt returns an unopened bolt client on a new temporary file whose
+// clock is fixed at now.
+func NewBolt(now time.Time) *Bolt {
+	c := &Bolt{Client: bolt.NewClient()}
+	c.Path = tempPath("fruit-bolt-")
+	c.Now = func() time.Time { return now }
+	return c
+}
+
+// MustOpenBolt returns an open client from NewBolt.
+func MustOpenBolt(now time.Time) *Bolt {
+	c := NewBolt(now)
+	if err := c.Open(); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *Bolt) Close() error {
+	defer os.Remove(c.Path)
+	return c.Client.Close()
+}
+
+// SQL represents a client on a temporary SQLite database. Close removes the
+// database.
+type SQL struct {
+	*sql.Client
+
+	// Filename of the SQLite database.
+	Path string
+}
+
+// NewSQL returns an unopened SQLite client on a new temporary file whose
+// clock is fixed at now.
+func NewSQL(now time.Time) *SQL {
+	c := &SQL{Client: sql.NewClient(), Path: tempPath("fruit-sql-")}
+	c.Driver = "sqlite"
+	c.DSN = c.Path + "?_pragma=busy_timeout(5000)"
+	c.Now = func() time.Time { return now }
+	return c
+}
+
+// MustOpenSQL returns an open client from NewSQL.
+func MustOpenSQL(now time.Time) *SQL {
+	c := NewSQL(now)
+	if err := c.Open(); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *SQL) Close() error {
+	defer os.Remove(c.Path)
+	return c.Client.Close()
+}
+
+// tempPath returns the name of a new, empty temporary file.
+func tempPath(prefix string) string {
+	f, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		panic(err)
+	}
+	f.Close()
+	return f.Name()
+}
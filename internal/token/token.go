@@ -0,0 +1,34 @@
+// Package token hashes product owner tokens and API keys so the storage
+// backends never keep or return them in plain text.
+package token
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// prefix marks a stored value as a hash rather than a plain token.
+const prefix = "sha256:"
+
+// Hash returns the stored form of the owner token t.
+func Hash(t string) string {
+	sum := sha256.Sum256([]byte(t))
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+// IsHash returns true if s was produced by Hash. Used by migrations to find
+// tokens stored before hashing.
+func IsHash(s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+// Verify returns true if t is the token hashed as hash. An empty token
+// never matches.
+func Verify(hash, t string) bool {
+	if t == "" || hash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(Hash(t))) == 1
+}
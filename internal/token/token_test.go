@@ -0,0 +1,22 @@
+package token_test
+
+import (
+	"testing"
+
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+func TestVerify(t *testing.T) {
+	h := token.Hash("TOKEN")
+	if h == "TOKEN" || !token.IsHash(h) {
+		t.Fatalf("unexpected hash: %q", h)
+	} else if !token.Verify(h, "TOKEN") {
+		t.Fatal("expected match")
+	} else if token.Verify(h, "OTHER") {
+		t.Fatal("expected mismatch")
+	} else if token.Verify(token.Hash(""), "") {
+		t.Fatal("expected empty token to never match")
+	} else if token.Verify("", "TOKEN") {
+		t.Fatal("expected missing hash to never match")
+	}
+}
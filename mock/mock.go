@@ -1,47 +1,425 @@
 package mock
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"github.com/notjrbauer/fruit"
 )
 
 type ProductService struct {
-	ProductFn      func(id fruit.ProductID) (*fruit.Product, error)
+	ProductFn      func(ctx context.Context, id fruit.ProductID) (*fruit.Product, error)
 	ProductInvoked bool
 
-	ProductsFn      func() ([]*fruit.Product, error)
+	ProductsFn      func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error)
 	ProductsInvoked bool
 
-	CreateProductFn      func(p *fruit.Product) error
+	SearchFn      func(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error)
+	SearchInvoked bool
+
+	CreateProductFn      func(ctx context.Context, p *fruit.Product) error
 	CreateProductInvoked bool
 
-	UpdateProductFn      func(id fruit.ProductID, p *fruit.Product) error
+	UpdateProductFn      func(ctx context.Context, id fruit.ProductID, p *fruit.Product) error
 	UpdateProductInvoked bool
 
-	DeleteProductFn      func(id fruit.ProductID, token string) error
+	DeleteProductFn      func(ctx context.Context, id fruit.ProductID, token string) error
 	DeleteProductInvoked bool
+
+	PatchProductFn      func(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error
+	PatchProductInvoked bool
+
+	RestoreProductFn      func(ctx context.Context, id fruit.ProductID) error
+	RestoreProductInvoked bool
+
+	PurgeProductsFn      func(ctx context.Context, before time.Time) (int, error)
+	PurgeProductsInvoked bool
+
+	BatchFn      func(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error)
+	BatchInvoked bool
 }
 
-func (s *ProductService) Product(id fruit.ProductID) (*fruit.Product, error) {
+func (s *ProductService) Product(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
 	s.ProductInvoked = true
-	return s.ProductFn(id)
+	return s.ProductFn(ctx, id)
 }
 
-func (s *ProductService) Products() ([]*fruit.Product, error) {
+func (s *ProductService) Products(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
 	s.ProductsInvoked = true
-	return s.ProductsFn()
+	return s.ProductsFn(ctx, opt)
+}
+
+func (s *ProductService) Search(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	s.SearchInvoked = true
+	return s.SearchFn(ctx, query, opt)
 }
 
-func (s *ProductService) CreateProduct(p *fruit.Product) error {
+func (s *ProductService) CreateProduct(ctx context.Context, p *fruit.Product) error {
 	s.CreateProductInvoked = true
-	return s.CreateProductFn(p)
+	return s.CreateProductFn(ctx, p)
 }
 
-func (s *ProductService) UpdateProduct(id fruit.ProductID, p *fruit.Product) error {
+func (s *ProductService) UpdateProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
 	s.UpdateProductInvoked = true
-	return s.UpdateProductFn(id, p)
+	return s.UpdateProductFn(ctx, id, p)
 }
 
-func (s *ProductService) DeleteProduct(id fruit.ProductID, token string) error {
+func (s *ProductService) DeleteProduct(ctx context.Context, id fruit.ProductID, token string) error {
 	s.DeleteProductInvoked = true
-	return s.DeleteProductFn(id, token)
+	return s.DeleteProductFn(ctx, id, token)
+}
+
+func (s *ProductService) PatchProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
+	s.PatchProductInvoked = true
+	return s.PatchProductFn(ctx, id, p, mask)
+}
+
+func (s *ProductService) RestoreProduct(ctx context.Context, id fruit.ProductID) error {
+	s.RestoreProductInvoked = true
+	return s.RestoreProductFn(ctx, id)
+}
+
+func (s *ProductService) PurgeProducts(ctx context.Context, before time.Time) (int, error) {
+	s.PurgeProductsInvoked = true
+	return s.PurgeProductsFn(ctx, before)
+}
+
+func (s *ProductService) Batch(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+	s.BatchInvoked = true
+	return s.BatchFn(ctx, ops, atomic)
+}
+
+type UserService struct {
+	UserFn      func(ctx context.Context, id fruit.UserID) (*fruit.User, error)
+	UserInvoked bool
+
+	UsersFn      func(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error)
+	UsersInvoked bool
+
+	CreateUserFn      func(ctx context.Context, u *fruit.User) error
+	CreateUserInvoked bool
+
+	DeleteUserFn      func(ctx context.Context, id fruit.UserID) error
+	DeleteUserInvoked bool
+
+	UpdateUserFn      func(ctx context.Context, id fruit.UserID, u *fruit.User) error
+	UpdateUserInvoked bool
+
+	PatchUserFn      func(ctx context.Context, id fruit.UserID, u *fruit.User, mask fruit.FieldMask) error
+	PatchUserInvoked bool
+}
+
+func (s *UserService) User(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+	s.UserInvoked = true
+	return s.UserFn(ctx, id)
+}
+
+func (s *UserService) Users(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error) {
+	s.UsersInvoked = true
+	return s.UsersFn(ctx, opt)
+}
+
+func (s *UserService) CreateUser(ctx context.Context, u *fruit.User) error {
+	s.CreateUserInvoked = true
+	return s.CreateUserFn(ctx, u)
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, id fruit.UserID) error {
+	s.DeleteUserInvoked = true
+	return s.DeleteUserFn(ctx, id)
+}
+
+func (s *UserService) UpdateUser(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+	s.UpdateUserInvoked = true
+	return s.UpdateUserFn(ctx, id, u)
+}
+
+func (s *UserService) PatchUser(ctx context.Context, id fruit.UserID, u *fruit.User, mask fruit.FieldMask) error {
+	s.PatchUserInvoked = true
+	return s.PatchUserFn(ctx, id, u, mask)
+}
+
+type TransactionService struct {
+	TransactionFn      func(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error)
+	TransactionInvoked bool
+
+	TransactionsFn      func(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error)
+	TransactionsInvoked bool
+
+	CreateTransactionFn      func(ctx context.Context, t *fruit.Transaction) error
+	CreateTransactionInvoked bool
+
+	UpdateTransactionFn      func(ctx context.Context, id fruit.TransactionID, t *fruit.Transaction) error
+	UpdateTransactionInvoked bool
+
+	DeleteTransactionFn      func(ctx context.Context, id fruit.TransactionID) error
+	DeleteTransactionInvoked bool
+}
+
+func (s *TransactionService) Transaction(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+	s.TransactionInvoked = true
+	return s.TransactionFn(ctx, id)
+}
+
+func (s *TransactionService) Transactions(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error) {
+	s.TransactionsInvoked = true
+	return s.TransactionsFn(ctx, id)
+}
+
+func (s *TransactionService) CreateTransaction(ctx context.Context, t *fruit.Transaction) error {
+	s.CreateTransactionInvoked = true
+	return s.CreateTransactionFn(ctx, t)
+}
+
+func (s *TransactionService) UpdateTransaction(ctx context.Context, id fruit.TransactionID, t *fruit.Transaction) error {
+	s.UpdateTransactionInvoked = true
+	return s.UpdateTransactionFn(ctx, id, t)
+}
+
+func (s *TransactionService) DeleteTransaction(ctx context.Context, id fruit.TransactionID) error {
+	s.DeleteTransactionInvoked = true
+	return s.DeleteTransactionFn(ctx, id)
+}
+
+type APIKeyService struct {
+	APIKeyFn      func(key string) (*fruit.APIKey, error)
+	APIKeyInvoked bool
+
+	CreateAPIKeyFn      func(k *fruit.APIKey) error
+	CreateAPIKeyInvoked bool
+
+	DeleteAPIKeyFn      func(key string) error
+	DeleteAPIKeyInvoked bool
+}
+
+func (s *APIKeyService) APIKey(key string) (*fruit.APIKey, error) {
+	s.APIKeyInvoked = true
+	return s.APIKeyFn(key)
+}
+
+func (s *APIKeyService) CreateAPIKey(k *fruit.APIKey) error {
+	s.CreateAPIKeyInvoked = true
+	return s.CreateAPIKeyFn(k)
+}
+
+func (s *APIKeyService) DeleteAPIKey(key string) error {
+	s.DeleteAPIKeyInvoked = true
+	return s.DeleteAPIKeyFn(key)
+}
+
+type CartService struct {
+	CartFn      func(id fruit.UserID) (*fruit.Cart, error)
+	CartInvoked bool
+
+	AddCartItemFn      func(id fruit.UserID, productID fruit.ProductID, quantity int) error
+	AddCartItemInvoked bool
+
+	UpdateCartItemFn      func(id fruit.UserID, productID fruit.ProductID, quantity int) error
+	UpdateCartItemInvoked bool
+
+	RemoveCartItemFn      func(id fruit.UserID, productID fruit.ProductID) error
+	RemoveCartItemInvoked bool
+
+	ClearCartFn      func(id fruit.UserID) error
+	ClearCartInvoked bool
+}
+
+func (s *CartService) Cart(id fruit.UserID) (*fruit.Cart, error) {
+	s.CartInvoked = true
+	return s.CartFn(id)
+}
+
+func (s *CartService) AddCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	s.AddCartItemInvoked = true
+	return s.AddCartItemFn(id, productID, quantity)
+}
+
+func (s *CartService) UpdateCartItem(id fruit.UserID, productID fruit.ProductID, quantity int) error {
+	s.UpdateCartItemInvoked = true
+	return s.UpdateCartItemFn(id, productID, quantity)
+}
+
+func (s *CartService) RemoveCartItem(id fruit.UserID, productID fruit.ProductID) error {
+	s.RemoveCartItemInvoked = true
+	return s.RemoveCartItemFn(id, productID)
+}
+
+func (s *CartService) ClearCart(id fruit.UserID) error {
+	s.ClearCartInvoked = true
+	return s.ClearCartFn(id)
+}
+
+type OrderService struct {
+	OrderFn      func(id fruit.OrderID) (*fruit.Order, error)
+	OrderInvoked bool
+
+	OrdersFn      func(id fruit.UserID) ([]*fruit.Order, error)
+	OrdersInvoked bool
+
+	CheckoutFn      func(id fruit.UserID) (*fruit.Order, error)
+	CheckoutInvoked bool
+}
+
+func (s *OrderService) Order(id fruit.OrderID) (*fruit.Order, error) {
+	s.OrderInvoked = true
+	return s.OrderFn(id)
+}
+
+func (s *OrderService) Orders(id fruit.UserID) ([]*fruit.Order, error) {
+	s.OrdersInvoked = true
+	return s.OrdersFn(id)
+}
+
+func (s *OrderService) Checkout(id fruit.UserID) (*fruit.Order, error) {
+	s.CheckoutInvoked = true
+	return s.CheckoutFn(id)
+}
+
+type ImportService struct {
+	ImportProductsFn      func(a []*fruit.Product, dryRun bool) ([]fruit.ImportResult, error)
+	ImportProductsInvoked bool
+
+	ImportUsersFn      func(a []*fruit.User, dryRun bool) ([]fruit.ImportResult, error)
+	ImportUsersInvoked bool
+}
+
+func (s *ImportService) ImportProducts(a []*fruit.Product, dryRun bool) ([]fruit.ImportResult, error) {
+	s.ImportProductsInvoked = true
+	return s.ImportProductsFn(a, dryRun)
+}
+
+func (s *ImportService) ImportUsers(a []*fruit.User, dryRun bool) ([]fruit.ImportResult, error) {
+	s.ImportUsersInvoked = true
+	return s.ImportUsersFn(a, dryRun)
+}
+
+type BackupService struct {
+	BackupFn      func(w io.Writer) error
+	BackupInvoked bool
+}
+
+func (s *BackupService) Backup(w io.Writer) error {
+	s.BackupInvoked = true
+	return s.BackupFn(w)
+}
+
+type EventService struct {
+	SubscribeFn      func(since uint64) (fruit.Subscription, error)
+	SubscribeInvoked bool
+}
+
+func (s *EventService) Subscribe(since uint64) (fruit.Subscription, error) {
+	s.SubscribeInvoked = true
+	return s.SubscribeFn(since)
+}
+
+type Subscription struct {
+	EventsFn      func() <-chan *fruit.Event
+	EventsInvoked bool
+
+	CloseFn      func() error
+	CloseInvoked bool
+}
+
+func (s *Subscription) Events() <-chan *fruit.Event {
+	s.EventsInvoked = true
+	return s.EventsFn()
+}
+
+func (s *Subscription) Close() error {
+	s.CloseInvoked = true
+	return s.CloseFn()
+}
+
+type WebhookService struct {
+	WebhookFn      func(id fruit.WebhookID) (*fruit.Webhook, error)
+	WebhookInvoked bool
+
+	WebhooksFn      func() ([]*fruit.Webhook, error)
+	WebhooksInvoked bool
+
+	CreateWebhookFn      func(w *fruit.Webhook) error
+	CreateWebhookInvoked bool
+
+	UpdateWebhookFn      func(id fruit.WebhookID, w *fruit.Webhook) error
+	UpdateWebhookInvoked bool
+
+	DeleteWebhookFn      func(id fruit.WebhookID) error
+	DeleteWebhookInvoked bool
+
+	DeliveriesFn      func(id fruit.WebhookID) ([]*fruit.Delivery, error)
+	DeliveriesInvoked bool
+
+	ReplayDeliveryFn      func(id fruit.DeliveryID) error
+	ReplayDeliveryInvoked bool
+
+	ReplayFailedFn      func(id fruit.WebhookID) (int, error)
+	ReplayFailedInvoked bool
+}
+
+func (s *WebhookService) Webhook(id fruit.WebhookID) (*fruit.Webhook, error) {
+	s.WebhookInvoked = true
+	return s.WebhookFn(id)
+}
+
+func (s *WebhookService) Webhooks() ([]*fruit.Webhook, error) {
+	s.WebhooksInvoked = true
+	return s.WebhooksFn()
+}
+
+func (s *WebhookService) CreateWebhook(w *fruit.Webhook) error {
+	s.CreateWebhookInvoked = true
+	return s.CreateWebhookFn(w)
+}
+
+func (s *WebhookService) UpdateWebhook(id fruit.WebhookID, w *fruit.Webhook) error {
+	s.UpdateWebhookInvoked = true
+	return s.UpdateWebhookFn(id, w)
+}
+
+func (s *WebhookService) DeleteWebhook(id fruit.WebhookID) error {
+	s.DeleteWebhookInvoked = true
+	return s.DeleteWebhookFn(id)
+}
+
+func (s *WebhookService) Deliveries(id fruit.WebhookID) ([]*fruit.Delivery, error) {
+	s.DeliveriesInvoked = true
+	return s.DeliveriesFn(id)
+}
+
+func (s *WebhookService) ReplayDelivery(id fruit.DeliveryID) error {
+	s.ReplayDeliveryInvoked = true
+	return s.ReplayDeliveryFn(id)
+}
+
+func (s *WebhookService) ReplayFailed(id fruit.WebhookID) (int, error) {
+	s.ReplayFailedInvoked = true
+	return s.ReplayFailedFn(id)
+}
+
+type RevisionService struct {
+	ProductRevisionsFn      func(ctx context.Context, id fruit.ProductID) ([]*fruit.Revision, error)
+	ProductRevisionsInvoked bool
+
+	UserRevisionsFn      func(ctx context.Context, id fruit.UserID) ([]*fruit.Revision, error)
+	UserRevisionsInvoked bool
+
+	RevertProductFn      func(ctx context.Context, id fruit.ProductID, rev fruit.RevisionID, version int) (*fruit.Product, error)
+	RevertProductInvoked bool
+}
+
+func (s *RevisionService) ProductRevisions(ctx context.Context, id fruit.ProductID) ([]*fruit.Revision, error) {
+	s.ProductRevisionsInvoked = true
+	return s.ProductRevisionsFn(ctx, id)
+}
+
+func (s *RevisionService) UserRevisions(ctx context.Context, id fruit.UserID) ([]*fruit.Revision, error) {
+	s.UserRevisionsInvoked = true
+	return s.UserRevisionsFn(ctx, id)
+}
+
+func (s *RevisionService) RevertProduct(ctx context.Context, id fruit.ProductID, rev fruit.RevisionID, version int) (*fruit.Product, error) {
+	s.RevertProductInvoked = true
+	return s.RevertProductFn(ctx, id, rev, version)
 }
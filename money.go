@@ -0,0 +1,83 @@
+package fruit
+
+import "math"
+
+// Money represents an amount in the minor unit of a currency, such as cents
+// for USD. Currency is an ISO 4217 code.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney returns an amount of minor units in currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Validate returns an error if the currency is not a three letter ISO 4217
+// code or the amount is negative.
+func (m Money) Validate() error {
+	if len(m.Currency) != 3 {
+		return ErrInvalidCurrency
+	}
+	for _, c := range m.Currency {
+		if c < 'A' || c > 'Z' {
+			return ErrInvalidCurrency
+		}
+	}
+
+	if m.Amount < 0 {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+// Add returns the sum of m and o. Returns ErrAmountOverflow if the sum
+// can't be represented.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	} else if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns the difference of m and o. Returns ErrAmountOverflow if the
+// difference can't be represented.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	} else if (o.Amount > 0 && m.Amount < math.MinInt64+o.Amount) || (o.Amount < 0 && m.Amount > math.MaxInt64+o.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by n. Returns ErrAmountOverflow if the product
+// can't be represented.
+func (m Money) Mul(n int) (Money, error) {
+	a, b := m.Amount, int64(n)
+	if a == 0 || b == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+
+	// Dividing back only detects overflow when MinInt64 isn't negated.
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: c, Currency: m.Currency}, nil
+}
+
+// Sum returns the total of amounts. All amounts must share a currency.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Money{Currency: currency}
+	for _, m := range amounts {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
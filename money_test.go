@@ -0,0 +1,122 @@
+package fruit_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/notjrbauer/fruit"
+)
+
+func TestMoney_MarshalJSON(t *testing.T) {
+	if buf, err := json.Marshal(fruit.NewMoney(1299, "USD")); err != nil {
+		t.Fatal(err)
+	} else if string(buf) != `{"amount":1299,"currency":"USD"}` {
+		t.Fatalf("unexpected json: %s", buf)
+	}
+}
+
+func TestMoney_Add(t *testing.T) {
+	if m, err := fruit.NewMoney(100, "USD").Add(fruit.NewMoney(250, "USD")); err != nil {
+		t.Fatal(err)
+	} else if m != fruit.NewMoney(350, "USD") {
+		t.Fatalf("unexpected sum: %+v", m)
+	}
+
+	if _, err := fruit.NewMoney(100, "USD").Add(fruit.NewMoney(100, "EUR")); err != fruit.ErrCurrencyMismatch {
+		t.Fatal(err)
+	}
+}
+
+func TestMoney_Sub(t *testing.T) {
+	if m, err := fruit.NewMoney(100, "USD").Sub(fruit.NewMoney(250, "USD")); err != nil {
+		t.Fatal(err)
+	} else if m != fruit.NewMoney(-150, "USD") {
+		t.Fatalf("unexpected difference: %+v", m)
+	}
+
+	if _, err := fruit.NewMoney(100, "USD").Sub(fruit.NewMoney(100, "JPY")); err != fruit.ErrCurrencyMismatch {
+		t.Fatal(err)
+	}
+}
+
+func TestMoney_Mul(t *testing.T) {
+	if m, err := fruit.NewMoney(1299, "USD").Mul(3); err != nil {
+		t.Fatal(err)
+	} else if m != fruit.NewMoney(3897, "USD") {
+		t.Fatalf("unexpected product: %+v", m)
+	}
+}
+
+// Ensure arithmetic past the int64 boundaries is rejected rather than
+// wrapping.
+func TestMoney_Overflow(t *testing.T) {
+	max, min := fruit.NewMoney(math.MaxInt64, "USD"), fruit.NewMoney(math.MinInt64, "USD")
+	one := fruit.NewMoney(1, "USD")
+
+	if m, err := max.Add(fruit.NewMoney(0, "USD")); err != nil || m != max {
+		t.Fatalf("unexpected sum: %+v, %v", m, err)
+	} else if _, err := max.Add(one); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if _, err := min.Add(fruit.NewMoney(-1, "USD")); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if m, err := min.Add(max); err != nil || m != fruit.NewMoney(-1, "USD") {
+		t.Fatalf("unexpected sum: %+v, %v", m, err)
+	}
+
+	if m, err := min.Sub(fruit.NewMoney(0, "USD")); err != nil || m != min {
+		t.Fatalf("unexpected difference: %+v, %v", m, err)
+	} else if _, err := min.Sub(one); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if _, err := max.Sub(fruit.NewMoney(-1, "USD")); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if _, err := fruit.NewMoney(0, "USD").Sub(min); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	}
+
+	if m, err := max.Mul(1); err != nil || m != max {
+		t.Fatalf("unexpected product: %+v, %v", m, err)
+	} else if m, err := max.Mul(-1); err != nil || m != fruit.NewMoney(-math.MaxInt64, "USD") {
+		t.Fatalf("unexpected product: %+v, %v", m, err)
+	} else if _, err := max.Mul(2); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if _, err := min.Mul(-1); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if _, err := fruit.NewMoney(-1, "USD").Mul(math.MinInt64); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if _, err := fruit.NewMoney(1<<32, "USD").Mul(1 << 31); err != fruit.ErrAmountOverflow {
+		t.Fatal(err)
+	} else if m, err := min.Mul(0); err != nil || m != fruit.NewMoney(0, "USD") {
+		t.Fatalf("unexpected product: %+v, %v", m, err)
+	}
+}
+
+func TestSum(t *testing.T) {
+	if m, err := fruit.Sum("USD", fruit.NewMoney(1, "USD"), fruit.NewMoney(2, "USD")); err != nil {
+		t.Fatal(err)
+	} else if m != fruit.NewMoney(3, "USD") {
+		t.Fatalf("unexpected sum: %+v", m)
+	}
+
+	if _, err := fruit.Sum("USD", fruit.NewMoney(1, "USD"), fruit.NewMoney(2, "GBP")); err != fruit.ErrCurrencyMismatch {
+		t.Fatal(err)
+	}
+}
+
+func TestMoney_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		m   fruit.Money
+		err error
+	}{
+		{fruit.NewMoney(0, "USD"), nil},
+		{fruit.NewMoney(1299, "EUR"), nil},
+		{fruit.NewMoney(1, ""), fruit.ErrInvalidCurrency},
+		{fruit.NewMoney(1, "usd"), fruit.ErrInvalidCurrency},
+		{fruit.NewMoney(1, "DOLLAR"), fruit.ErrInvalidCurrency},
+		{fruit.NewMoney(-1, "USD"), fruit.ErrInvalidPrice},
+	} {
+		if err := tt.m.Validate(); err != tt.err {
+			t.Errorf("%+v: unexpected error: %v", tt.m, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package fruit
+
+import "strings"
+
+// FieldMask names the fields changed by a partial update by their JSON
+// names. Nested fields are joined with a dot, as in "address.city".
+type FieldMask []string
+
+// Patch copies the fields named in mask from patch to p. Fields which are
+// removed by a patch are given their zero value. Returns ErrInvalidField,
+// leaving p unchanged, if a field can't be patched.
+func (p *Product) Patch(patch *Product, mask FieldMask) error {
+	other := *p
+	if p.Price != nil {
+		price := *p.Price
+		other.Price = &price
+	}
+
+	for _, field := range mask {
+		switch field {
+		case "name":
+			other.Name = patch.Name
+		case "sku":
+			other.SKU = patch.SKU
+		case "type":
+			other.Type = patch.Type
+		case "color":
+			other.Color = patch.Color
+		case "description":
+			other.Description = patch.Description
+		case "categoryID":
+			other.CategoryID = patch.CategoryID
+		case "price":
+			other.Price = nil
+			if patch.Price != nil {
+				price := *patch.Price
+				other.Price = &price
+			}
+		case "price.amount", "price.currency":
+			var from Money
+			if patch.Price != nil {
+				from = *patch.Price
+			}
+			if other.Price == nil {
+				other.Price = &Money{}
+			}
+			if field == "price.amount" {
+				other.Price.Amount = from.Amount
+			} else {
+				other.Price.Currency = from.Currency
+			}
+		default:
+			return ErrInvalidField
+		}
+	}
+
+	*p = other
+	return nil
+}
+
+// Patch copies the fields named in mask from patch to u. Address fields
+// can be named individually. Returns ErrInvalidField, leaving u unchanged,
+// if a field can't be patched.
+func (u *User) Patch(patch *User, mask FieldMask) error {
+	other := *u
+	if u.Address != nil {
+		addr := *u.Address
+		other.Address = &addr
+	}
+
+	for _, field := range mask {
+		switch field {
+		case "name":
+			other.Name = patch.Name
+		case "card":
+			other.CardID = patch.CardID
+		case "address":
+			other.Address = nil
+			if patch.Address != nil {
+				addr := *patch.Address
+				other.Address = &addr
+			}
+		default:
+			if !strings.HasPrefix(field, "address.") {
+				return ErrInvalidField
+			}
+
+			var from Address
+			if patch.Address != nil {
+				from = *patch.Address
+			}
+			if other.Address == nil {
+				other.Address = &Address{}
+			}
+			if err := other.Address.patch(&from, strings.TrimPrefix(field, "address.")); err != nil {
+				return err
+			}
+		}
+	}
+
+	*u = other
+	return nil
+}
+
+// patch copies one field of patch to a.
+func (a *Address) patch(patch *Address, field string) error {
+	switch field {
+	case "line1":
+		a.Line1 = patch.Line1
+	case "line2":
+		a.Line2 = patch.Line2
+	case "city":
+		a.City = patch.City
+	case "state":
+		a.State = patch.State
+	case "zipCode":
+		a.ZipCode = patch.ZipCode
+	case "country":
+		a.Country = patch.Country
+	default:
+		return ErrInvalidField
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package fruit
+
+// QueryOptions controls which records a list operation returns and in what
+// order. The zero value returns every record sorted by ID.
+type QueryOptions struct {
+	// Maximum number of records to return. Zero returns all records.
+	Limit int
+
+	// Position to resume from, as returned by a previous page.
+	Cursor string
+
+	// Field to sort by and whether to sort in descending order.
+	Sort string
+	Desc bool
+
+	// Product filters. Only records matching every set filter are
+	// returned. Ignored when listing users.
+	Type  string
+	Color string
+	SKU   string
+
+	// Include deleted products. Ignored when listing users.
+	IncludeDeleted bool
+}
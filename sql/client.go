@@ -0,0 +1,202 @@
+// Package sql implements the product, user and transaction services on
+// database/sql. Unlike bolt, several processes can share one database.
+//
+// Queries are written for SQLite, the only database the conformance tests
+// run against. The caller registers the driver by importing it. Pages are
+// read by LIMIT and OFFSET, so unlike bolt they shift when records
+// before the cursor are added or removed.
+package sql
+
+import (
+	"database/sql"
+	"embed"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+// Schema migrations, applied in file name order.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Client represents a client to a SQL database.
+type Client struct {
+	// Driver name and data source passed to sql.Open.
+	Driver string
+	DSN    string
+
+	// Returns the current time.
+	Now func() time.Time
+
+	// Services
+	productService     ProductService
+	userService        UserService
+	transactionService TransactionService
+
+	db *sql.DB
+}
+
+func NewClient() *Client {
+	c := &Client{Now: time.Now}
+	c.productService.client = c
+	c.userService.client = c
+	c.transactionService.client = c
+	return c
+}
+
+// Open connects to the database and applies any pending migrations.
+func (c *Client) Open() error {
+	db, err := sql.Open(c.Driver, c.DSN)
+	if err != nil {
+		return err
+	}
+	c.db = db
+
+	if err := c.migrate(); err != nil {
+		db.Close()
+		return err
+	}
+	return nil
+}
+
+func (c *Client) Close() error {
+	if c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *Client) ProductService() fruit.ProductService {
+	return &c.productService
+}
+
+func (c *Client) UserService() fruit.UserService {
+	return &c.userService
+}
+
+func (c *Client) TransactionService() fruit.TransactionService {
+	return &c.transactionService
+}
+
+// codeMigrations are migrations that can't be written in SQL. They are
+// applied in name order along with the embedded files.
+var codeMigrations = map[string]func(c *Client, tx *sql.Tx) error{
+	"0004_hash_tokens": hashTokens,
+}
+
+// migrate applies each migration that hasn't been recorded in the
+// schema_migrations table, one transaction per migration.
+func (c *Client) migrate() error {
+	if _, err := c.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	files, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	for name := range codeMigrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := c.applyMigration(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMigration runs a migration unless it has already been applied.
+func (c *Client) applyMigration(name string) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRow(c.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), name).Scan(&n); err != nil {
+		return err
+	} else if n > 0 {
+		return nil
+	}
+
+	if fn, ok := codeMigrations[name]; ok {
+		if err := fn(c, tx); err != nil {
+			return err
+		}
+	} else if buf, err := migrations.ReadFile("migrations/" + name); err != nil {
+		return err
+	} else if _, err := tx.Exec(string(buf)); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(c.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// hashTokens replaces owner tokens stored in plain text with their hash.
+// Products saved without a token are left ownerless.
+func hashTokens(c *Client, tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, token FROM products WHERE token <> ''`)
+	if err != nil {
+		return err
+	}
+
+	plain := map[string]string{}
+	for rows.Next() {
+		var id, t string
+		if err := rows.Scan(&id, &t); err != nil {
+			rows.Close()
+			return err
+		} else if !token.IsHash(t) {
+			plain[id] = t
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	} else if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, t := range plain {
+		if _, err := tx.Exec(c.rebind(`UPDATE products SET token = ? WHERE id = ?`), token.Hash(t), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebind converts ? placeholders to the numbered form used by Postgres.
+func (c *Client) rebind(query string) string {
+	if c.Driver != "postgres" && c.Driver != "pgx" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package sql_test
+
+import (
+	"context"
+	stdsql "database/sql"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/testdb"
+)
+
+var Now = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Client represents a client on a temporary SQLite database.
+type Client = testdb.SQL
+
+// NewClient returns a new, unopened instance of Client.
+func NewClient() *Client {
+	return testdb.NewSQL(Now)
+}
+
+// MustOpenClient returns a new, open instance of Client
+func MustOpenClient() *Client {
+	return testdb.MustOpenSQL(Now)
+}
+
+// Ensure an existing database can be reopened without losing data.
+func TestClient_Open_Existing(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U"}); err != nil {
+		t.Fatal(err)
+	} else if err := c.Client.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := c.Open(); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.UserService().User(ctx, "U"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure owner tokens stored in plain text are hashed on open and that
+// products saved without a token may only be changed by an admin.
+func TestClient_Open_HashTokens(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+
+	s := c.ProductService()
+	if err := s.CreateProduct(ctx, &fruit.Product{ID: "A", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	} else if err := s.CreateProduct(ctx, &fruit.Product{ID: "B", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	} else if err := c.Client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Store tokens the way earlier versions did.
+	db, err := stdsql.Open(c.Driver, c.DSN)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := db.Exec(`UPDATE products SET token = CASE id WHEN 'A' THEN '' ELSE 'TOKEN' END`); err != nil {
+		t.Fatal(err)
+	} else if _, err := db.Exec(`DELETE FROM schema_migrations WHERE version = '0004_hash_tokens'`); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The owner's token still works.
+	if err := s.UpdateProduct(ctx, "B", &fruit.Product{Name: "Banana", Token: "TOKEN", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An ownerless product can't be claimed by guessing a token, but
+	// admins may change it.
+	admin := fruit.NewPrincipalContext(ctx, &fruit.Principal{UserID: "ADMIN", Admin: true})
+	if err := s.UpdateProduct(ctx, "A", &fruit.Product{Name: "Apple", Token: "TOKEN", Version: 1}); err != fruit.ErrUnauthorized {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.UpdateProduct(admin, "A", &fruit.Product{Name: "Apple", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
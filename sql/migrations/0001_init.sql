@@ -0,0 +1,42 @@
+CREATE TABLE products (
+	id             TEXT PRIMARY KEY,
+	token          TEXT NOT NULL,
+	name           TEXT NOT NULL,
+	sku            TEXT NOT NULL,
+	type           TEXT NOT NULL,
+	color          TEXT NOT NULL,
+	description    TEXT NOT NULL,
+	price_amount   BIGINT,
+	price_currency TEXT,
+	category_id    TEXT NOT NULL,
+	mod_time       TIMESTAMP NOT NULL
+);
+
+CREATE INDEX products_category_id ON products (category_id);
+
+CREATE TABLE search_terms (
+	term       TEXT NOT NULL,
+	product_id TEXT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+	weight     INTEGER NOT NULL,
+	PRIMARY KEY (term, product_id)
+);
+
+CREATE INDEX search_terms_product_id ON search_terms (product_id);
+
+CREATE TABLE users (
+	id       TEXT PRIMARY KEY,
+	name     TEXT NOT NULL,
+	address  TEXT,
+	card_id  TEXT NOT NULL,
+	mod_time TIMESTAMP NOT NULL
+);
+
+CREATE TABLE transactions (
+	id       TEXT PRIMARY KEY,
+	user_id  TEXT NOT NULL,
+	count    INTEGER NOT NULL,
+	active   BOOLEAN NOT NULL,
+	mod_time TIMESTAMP NOT NULL
+);
+
+CREATE INDEX transactions_user_id ON transactions (user_id);
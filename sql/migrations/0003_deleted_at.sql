@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP;
@@ -0,0 +1,640 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/search"
+	"github.com/notjrbauer/fruit/internal/token"
+)
+
+// Columns selected for a product, in scan order. The owner token is only
+// read to authorize changes.
+const productColumns = `id, name, sku, type, color, description, price_amount, price_currency, category_id, version, mod_time, deleted_at`
+
+type ProductService struct {
+	client *Client
+}
+
+// Product returns a product by ID.
+func (s *ProductService) Product(ctx context.Context, id fruit.ProductID) (*fruit.Product, error) {
+	return findProduct(ctx, s.client.db, s.client, id, false)
+}
+
+// Products returns a page of products matching opt.
+func (s *ProductService) Products(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	order, offset, err := orderBy(opt, productSortColumns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	where, args := productFilter(opt)
+	rows, err := s.client.db.QueryContext(ctx, s.client.rebind(`SELECT `+productColumns+` FROM products`+where+order), args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	products := []*fruit.Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	start, end, next := page(len(products), offset, opt.Limit)
+	return products[start:end], next, nil
+}
+
+// Search returns a page of products matching query, best match first. The
+// sort options are ignored.
+func (s *ProductService) Search(ctx context.Context, query string, opt fruit.QueryOptions) ([]*fruit.Product, string, error) {
+	words := search.Tokenize(query)
+	if len(words) == 0 {
+		return nil, "", fruit.ErrSearchQueryRequired
+	} else if opt.Limit < 0 {
+		return nil, "", fruit.ErrInvalidLimit
+	}
+
+	offset, err := parseCursor(opt.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Start transaction so the index and products agree.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	ids, err := s.searchIndex(ctx, tx, words)
+	if err != nil {
+		return nil, "", err
+	}
+
+	products := []*fruit.Product{}
+	for _, id := range ids {
+		p, err := findProduct(ctx, tx, s.client, id, opt.IncludeDeleted)
+		if err == fruit.ErrProductNotFound {
+			continue
+		} else if err != nil {
+			return nil, "", err
+		}
+
+		// Apply filters.
+		if (opt.Type != "" && p.Type != opt.Type) ||
+			(opt.Color != "" && p.Color != opt.Color) ||
+			(opt.SKU != "" && p.SKU != opt.SKU) {
+			continue
+		}
+		products = append(products, p)
+	}
+
+	// Skip to the cursor.
+	if offset > len(products) {
+		offset = len(products)
+	}
+	products = products[offset:]
+
+	if opt.Limit > 0 && len(products) > opt.Limit {
+		return products[:opt.Limit], strconv.Itoa(offset + opt.Limit), nil
+	}
+	return products, "", nil
+}
+
+// searchIndex returns the IDs of products matching every word, best match
+// first.
+func (s *ProductService) searchIndex(ctx context.Context, tx *sql.Tx, words []string) ([]fruit.ProductID, error) {
+	var scores map[fruit.ProductID]int
+	for _, word := range words {
+		// Words only contain letters and digits so they can't hold wildcards.
+		rows, err := tx.QueryContext(ctx, s.client.rebind(`SELECT term, product_id, weight FROM search_terms WHERE term LIKE ?`), word+"%")
+		if err != nil {
+			return nil, err
+		}
+
+		// Score each product by its best matching term.
+		matches := make(map[fruit.ProductID]int)
+		for rows.Next() {
+			var term string
+			var id fruit.ProductID
+			var weight int
+			if err := rows.Scan(&term, &id, &weight); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			if score := search.Score(word, term, weight); score > matches[id] {
+				matches[id] = score
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		// Keep only products matched by every word so far.
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for id := range scores {
+			if score, ok := matches[id]; ok {
+				scores[id] += score
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	ids := make([]fruit.ProductID, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids, nil
+}
+
+// CreateProduct creates a new product. The product's token identifies its
+// owner and must be supplied on later updates and deletes. Categories
+// aren't stored in SQL yet, so the category ID isn't verified.
+func (s *ProductService) CreateProduct(ctx context.Context, p *fruit.Product) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.createProduct(ctx, tx, p); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// createProduct creates a product within a transaction. Validation errors
+// are returned before anything is written.
+func (s *ProductService) createProduct(ctx context.Context, tx *sql.Tx, p *fruit.Product) error {
+	// Require id
+	if p.ID == "" {
+		return fruit.ErrProductIDRequired
+	}
+
+	// Require owner token.
+	if p.Token == "" {
+		return fruit.ErrUnauthorized
+	}
+
+	// Validate price.
+	if p.Price != nil {
+		if err := p.Price.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Verify product doesn't already exist.
+	if _, err := findProduct(ctx, tx, s.client, p.ID, true); err == nil {
+		return fruit.ErrProductExists
+	} else if err != fruit.ErrProductNotFound {
+		return err
+	}
+
+	// Set initial version and modified time. New products are never in the
+	// trash.
+	p.Version = 1
+	p.ModTime = s.client.Now().UTC()
+	p.DeletedAt = nil
+
+	amount, currency := priceColumns(p.Price)
+	if _, err := tx.ExecContext(ctx, s.client.rebind(`INSERT INTO products (token, `+productColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL)`),
+		token.Hash(p.Token), p.ID, p.Name, p.SKU, p.Type, p.Color, p.Description, amount, currency, p.CategoryID, p.Version, p.ModTime,
+	); err != nil {
+		return err
+	}
+
+	return s.indexProduct(ctx, tx, p)
+}
+
+// UpdateProduct updates an existing product. Blank fields are left
+// unchanged.
+func (s *ProductService) UpdateProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product) error {
+	// Start read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.updateProduct(ctx, tx, id, p); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updateProduct updates a product within a transaction and copies its new
+// version and modified time to p. Validation errors are returned before
+// anything is written.
+func (s *ProductService) updateProduct(ctx context.Context, tx *sql.Tx, id fruit.ProductID, p *fruit.Product) error {
+	// Require the version the changes are based on.
+	if p.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	// Validate price.
+	if p.Price != nil {
+		if err := p.Price.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Find record.
+	d, err := findProduct(ctx, tx, s.client, id, false)
+	if err != nil {
+		return err
+	}
+
+	// Only the owner may update the product.
+	if err := authorize(ctx, tx, s.client, id, p.Token); err != nil {
+		return err
+	}
+
+	// Reject changes based on an old version.
+	if p.Version != d.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes.
+	if p.Name != "" {
+		d.Name = p.Name
+	}
+	if p.SKU != "" {
+		d.SKU = p.SKU
+	}
+	if p.Type != "" {
+		d.Type = p.Type
+	}
+	if p.Color != "" {
+		d.Color = p.Color
+	}
+	if p.Description != "" {
+		d.Description = p.Description
+	}
+	if p.Price != nil {
+		d.Price = p.Price
+	}
+	if p.CategoryID != "" {
+		d.CategoryID = p.CategoryID
+	}
+
+	if err := s.saveProduct(ctx, tx, d); err != nil {
+		return err
+	}
+	p.Version, p.ModTime = d.Version, d.ModTime
+	return nil
+}
+
+// PatchProduct changes the fields of a product named in mask.
+func (s *ProductService) PatchProduct(ctx context.Context, id fruit.ProductID, p *fruit.Product, mask fruit.FieldMask) error {
+	// Require the version the changes are based on.
+	if p.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	// Start read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record.
+	d, err := findProduct(ctx, tx, s.client, id, false)
+	if err != nil {
+		return err
+	}
+
+	// Only the owner may update the product.
+	if err := authorize(ctx, tx, s.client, id, p.Token); err != nil {
+		return err
+	}
+
+	// Reject changes based on an old version.
+	if p.Version != 0 && p.Version != d.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes and validate the result.
+	if err := d.Patch(p, mask); err != nil {
+		return err
+	} else if d.Price != nil {
+		if err := d.Price.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.saveProduct(ctx, tx, d); err != nil {
+		return err
+	} else if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.Token = p.Token
+	*p = *d
+	return nil
+}
+
+// saveProduct writes every field of d, which was read in tx, as its next
+// version and reindexes it.
+func (s *ProductService) saveProduct(ctx context.Context, tx *sql.Tx, d *fruit.Product) error {
+	d.Version++
+	d.ModTime = s.client.Now().UTC()
+
+	// The version is compared again in case another writer got in first.
+	amount, currency := priceColumns(d.Price)
+	res, err := tx.ExecContext(ctx, s.client.rebind(`UPDATE products SET name = ?, sku = ?, type = ?, color = ?, description = ?, price_amount = ?, price_currency = ?, category_id = ?, version = ?, mod_time = ? WHERE id = ? AND version = ?`),
+		d.Name, d.SKU, d.Type, d.Color, d.Description, amount, currency, d.CategoryID, d.Version, d.ModTime, d.ID, d.Version-1,
+	)
+	if err != nil {
+		return err
+	} else if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fruit.ErrConflict
+	}
+
+	return s.indexProduct(ctx, tx, d)
+}
+
+// DeleteProduct removes an existing product.
+func (s *ProductService) DeleteProduct(ctx context.Context, id fruit.ProductID, token string) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.deleteProduct(ctx, tx, id, token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteProduct removes a product within a transaction. Validation errors
+// are returned before anything is written.
+func (s *ProductService) deleteProduct(ctx context.Context, tx *sql.Tx, id fruit.ProductID, token string) error {
+	// Find record.
+	if _, err := findProduct(ctx, tx, s.client, id, false); err != nil {
+		return err
+	}
+
+	// Only the owner may delete the product.
+	if err := authorize(ctx, tx, s.client, id, token); err != nil {
+		return err
+	}
+
+	// Keep the record, along with its search terms, so it can be restored.
+	if _, err := tx.ExecContext(ctx, s.client.rebind(`UPDATE products SET deleted_at = ? WHERE id = ?`), s.client.Now().UTC(), id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreProduct moves a deleted product out of the trash.
+func (s *ProductService) RestoreProduct(ctx context.Context, id fruit.ProductID) error {
+	// Start the read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record, including deleted records.
+	p, err := findProduct(ctx, tx, s.client, id, true)
+	if err != nil {
+		return err
+	} else if p.DeletedAt == nil {
+		return fruit.ErrProductNotDeleted
+	}
+
+	if _, err := tx.ExecContext(ctx, s.client.rebind(`UPDATE products SET deleted_at = NULL WHERE id = ?`), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeProducts permanently removes products deleted before t.
+func (s *ProductService) PurgeProducts(ctx context.Context, before time.Time) (int, error) {
+	// Start the read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Deletion times are compared here as drivers store timestamps
+	// differently.
+	rows, err := tx.QueryContext(ctx, `SELECT id, deleted_at FROM products WHERE deleted_at IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	var ids []fruit.ProductID
+	for rows.Next() {
+		var id fruit.ProductID
+		var deletedAt time.Time
+		if err := rows.Scan(&id, &deletedAt); err != nil {
+			rows.Close()
+			return 0, err
+		} else if deletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	} else if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		// Index entries are removed first as SQLite doesn't enforce
+		// foreign keys by default.
+		if _, err := tx.ExecContext(ctx, s.client.rebind(`DELETE FROM search_terms WHERE product_id = ?`), id); err != nil {
+			return 0, err
+		} else if _, err := tx.ExecContext(ctx, s.client.rebind(`DELETE FROM products WHERE id = ?`), id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// Batch applies a series of operations in one transaction. Failed
+// operations are skipped unless atomic is set, in which case the whole
+// batch is rolled back.
+func (s *ProductService) Batch(ctx context.Context, ops []fruit.BatchOp, atomic bool) ([]fruit.BatchResult, error) {
+	// Start the read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]fruit.BatchResult, len(ops))
+	failed := false
+	for i, op := range ops {
+		// Domain errors are returned before anything is written, so only
+		// other errors leave the transaction in an unknown state.
+		err := s.batchOp(ctx, tx, op)
+		if _, ok := err.(fruit.Error); err != nil && !ok {
+			return nil, err
+		}
+		results[i] = fruit.NewBatchResult(i, op, err)
+		failed = failed || err != nil
+	}
+
+	// Report the successful operations of a failed atomic batch as aborted.
+	if atomic && failed {
+		for i := range results {
+			if results[i].Err == "" {
+				results[i] = fruit.NewBatchResult(i, ops[i], fruit.ErrBatchAborted)
+			}
+		}
+		return results, nil
+	}
+
+	return results, tx.Commit()
+}
+
+// batchOp applies a single batch operation within a transaction.
+func (s *ProductService) batchOp(ctx context.Context, tx *sql.Tx, op fruit.BatchOp) error {
+	switch op.Op {
+	case fruit.BatchCreate, fruit.BatchUpdate:
+		if op.Product == nil {
+			return fruit.ErrProductRequired
+		}
+		op.Product.Token = op.Token
+
+		if op.Op == fruit.BatchCreate {
+			return s.createProduct(ctx, tx, op.Product)
+		} else if op.ID == "" {
+			return fruit.ErrProductIDRequired
+		}
+		return s.updateProduct(ctx, tx, op.ID, op.Product)
+	case fruit.BatchDelete:
+		if op.ID == "" {
+			return fruit.ErrProductIDRequired
+		}
+		return s.deleteProduct(ctx, tx, op.ID, op.Token)
+	default:
+		return fruit.ErrInvalidBatchOp
+	}
+}
+
+// indexProduct replaces the search index entries for p.
+func (s *ProductService) indexProduct(ctx context.Context, tx *sql.Tx, p *fruit.Product) error {
+	if _, err := tx.ExecContext(ctx, s.client.rebind(`DELETE FROM search_terms WHERE product_id = ?`), p.ID); err != nil {
+		return err
+	}
+
+	for term, weight := range search.Terms(p) {
+		if _, err := tx.ExecContext(ctx, s.client.rebind(`INSERT INTO search_terms (term, product_id, weight) VALUES (?, ?, ?)`), term, p.ID, weight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// findProduct returns a product by ID. Deleted
+// products are reported as not found unless includeDeleted is set.
+func findProduct(ctx context.Context, q queryer, c *Client, id fruit.ProductID, includeDeleted bool) (*fruit.Product, error) {
+	p, err := scanProduct(q.QueryRowContext(ctx, c.rebind(`SELECT `+productColumns+` FROM products WHERE id = ?`), id))
+	if err == sql.ErrNoRows {
+		return nil, fruit.ErrProductNotFound
+	} else if err != nil {
+		return nil, err
+	} else if p.DeletedAt != nil && !includeDeleted {
+		return nil, fruit.ErrProductNotFound
+	}
+	return p, nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct reads a product selected with productColumns.
+func scanProduct(row scanner) (*fruit.Product, error) {
+	var p fruit.Product
+	var amount sql.NullInt64
+	var currency sql.NullString
+	var deletedAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.Name, &p.SKU, &p.Type, &p.Color, &p.Description, &amount, &currency, &p.CategoryID, &p.Version, &p.ModTime, &deletedAt); err != nil {
+		return nil, err
+	}
+
+	if amount.Valid {
+		p.Price = &fruit.Money{Amount: amount.Int64, Currency: currency.String}
+	}
+	if deletedAt.Valid {
+		t := deletedAt.Time.UTC()
+		p.DeletedAt = &t
+	}
+	p.ModTime = p.ModTime.UTC()
+	return &p, nil
+}
+
+// priceColumns returns the column values for a price, which are NULL for
+// unpriced products.
+func priceColumns(m *fruit.Money) (sql.NullInt64, sql.NullString) {
+	if m == nil {
+		return sql.NullInt64{}, sql.NullString{}
+	}
+	return sql.NullInt64{Int64: m.Amount, Valid: true}, sql.NullString{String: m.Currency, Valid: true}
+}
+
+// authorize returns ErrUnauthorized if t does not match the owner token
+// stored for the product. Products without an owner token may only be
+// changed by an admin.
+func authorize(ctx context.Context, q queryer, c *Client, id fruit.ProductID, t string) error {
+	var owner string
+	if err := q.QueryRowContext(ctx, c.rebind(`SELECT token FROM products WHERE id = ?`), id).Scan(&owner); err != nil {
+		return err
+	}
+
+	if owner == "" {
+		if p := fruit.PrincipalFromContext(ctx); p != nil && p.Admin {
+			return nil
+		}
+		return fruit.ErrUnauthorized
+	} else if !token.Verify(owner, t) {
+		return fruit.ErrUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package sql
+
+import (
+	"strconv"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// Columns that products and users can be sorted by.
+var (
+	productSortColumns = map[string]string{
+		"":          "id",
+		"productID": "id",
+		"name":      "name",
+		"sku":       "sku",
+		"type":      "type",
+		"color":     "color",
+		"modTime":   "mod_time",
+	}
+
+	userSortColumns = map[string]string{
+		"":        "id",
+		"userID":  "id",
+		"name":    "name",
+		"modTime": "mod_time",
+	}
+)
+
+// orderBy returns the ORDER BY and LIMIT clauses for a page of records
+// sorted by opt and the offset to start from. One extra record is fetched
+// so the caller can tell if there is a next page. Ties are broken by ID.
+func orderBy(opt fruit.QueryOptions, columns map[string]string) (string, int, error) {
+	if opt.Limit < 0 {
+		return "", 0, fruit.ErrInvalidLimit
+	}
+
+	offset, err := parseCursor(opt.Cursor)
+	if err != nil {
+		return "", 0, err
+	}
+
+	column, ok := columns[opt.Sort]
+	if !ok {
+		return "", 0, fruit.ErrInvalidSort
+	}
+
+	dir := ""
+	if opt.Desc {
+		dir = " DESC"
+	}
+
+	clause := " ORDER BY " + column + dir + ", id" + dir
+	if opt.Limit > 0 {
+		clause += " LIMIT " + strconv.Itoa(opt.Limit+1) + " OFFSET " + strconv.Itoa(offset)
+	}
+	return clause, offset, nil
+}
+
+// parseCursor returns the offset encoded in cursor. Cursors are the offset
+// of the next record.
+func parseCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	offset, err := strconv.Atoi(cursor)
+	if err != nil || offset < 0 {
+		return 0, fruit.ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// page returns the bounds of the requested page within the n records
+// fetched by orderBy, and the cursor for the next page. Unlimited queries
+// fetch every record, so the offset is applied here.
+func page(n, offset, limit int) (start, end int, next string) {
+	if limit == 0 {
+		if offset > n {
+			offset = n
+		}
+		return offset, n, ""
+	} else if n <= limit {
+		return 0, n, ""
+	}
+	return 0, limit, strconv.Itoa(offset + limit)
+}
+
+// productFilter returns the WHERE clause and arguments for the product
+// filters in opt.
+func productFilter(opt fruit.QueryOptions) (string, []interface{}) {
+	var clause string
+	var args []interface{}
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"type", opt.Type},
+		{"color", opt.Color},
+		{"sku", opt.SKU},
+	} {
+		if f.value == "" {
+			continue
+		}
+
+		if clause == "" {
+			clause = " WHERE "
+		} else {
+			clause += " AND "
+		}
+		clause += f.column + " = ?"
+		args = append(args, f.value)
+	}
+
+	if !opt.IncludeDeleted {
+		if clause == "" {
+			clause = " WHERE "
+		} else {
+			clause += " AND "
+		}
+		clause += "deleted_at IS NULL"
+	}
+	return clause, args
+}
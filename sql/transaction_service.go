@@ -0,0 +1,160 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// Columns selected for a transaction, in scan order.
+const transactionColumns = `id, user_id, count, active, mod_time`
+
+type TransactionService struct {
+	client *Client
+}
+
+// Transaction returns a transaction by ID.
+func (s *TransactionService) Transaction(ctx context.Context, id fruit.TransactionID) (*fruit.Transaction, error) {
+	return findTransaction(ctx, s.client.db, s.client, id)
+}
+
+// Transactions returns all transactions belonging to a user.
+func (s *TransactionService) Transactions(ctx context.Context, id fruit.UserID) ([]*fruit.Transaction, error) {
+	rows, err := s.client.db.QueryContext(ctx, s.client.rebind(`SELECT `+transactionColumns+` FROM transactions WHERE user_id = ? ORDER BY id`), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []*fruit.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// CreateTransaction creates a new transaction.
+func (s *TransactionService) CreateTransaction(ctx context.Context, t *fruit.Transaction) error {
+	// Validate arguments.
+	if t == nil {
+		return fruit.ErrTransactionRequired
+	} else if t.ID == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify transaction doesn't already exist.
+	if _, err := findTransaction(ctx, tx, s.client, t.ID); err == nil {
+		return fruit.ErrTransactionExists
+	} else if err != fruit.ErrTransactionNotFound {
+		return err
+	}
+
+	// Update modified time.
+	t.ModTime = s.client.Now().UTC()
+
+	if _, err := tx.ExecContext(ctx, s.client.rebind(`INSERT INTO transactions (`+transactionColumns+`) VALUES (?, ?, ?, ?, ?)`),
+		t.ID, t.UserID, t.Count, t.Active, t.ModTime,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateTransaction updates an existing transaction.
+func (s *TransactionService) UpdateTransaction(ctx context.Context, id fruit.TransactionID, t *fruit.Transaction) error {
+	// Validate arguments.
+	if t == nil {
+		return fruit.ErrTransactionRequired
+	} else if id == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	// Start read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find record.
+	other, err := findTransaction(ctx, tx, s.client, id)
+	if err != nil {
+		return err
+	}
+
+	// Apply changes.
+	other.UserID = t.UserID
+	other.Count = t.Count
+	other.Active = t.Active
+	other.ModTime = s.client.Now().UTC()
+
+	if _, err := tx.ExecContext(ctx, s.client.rebind(`UPDATE transactions SET user_id = ?, count = ?, active = ?, mod_time = ? WHERE id = ?`),
+		other.UserID, other.Count, other.Active, other.ModTime, id,
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	*t = *other
+	return nil
+}
+
+// DeleteTransaction removes an existing transaction.
+func (s *TransactionService) DeleteTransaction(ctx context.Context, id fruit.TransactionID) error {
+	// Validate arguments.
+	if id == "" {
+		return fruit.ErrTransactionIDRequired
+	}
+
+	res, err := s.client.db.ExecContext(ctx, s.client.rebind(`DELETE FROM transactions WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fruit.ErrTransactionNotFound
+	}
+	return nil
+}
+
+// findTransaction returns a transaction by ID.
+func findTransaction(ctx context.Context, q queryer, c *Client, id fruit.TransactionID) (*fruit.Transaction, error) {
+	t, err := scanTransaction(q.QueryRowContext(ctx, c.rebind(`SELECT `+transactionColumns+` FROM transactions WHERE id = ?`), id))
+	if err == sql.ErrNoRows {
+		return nil, fruit.ErrTransactionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// scanTransaction reads a transaction selected with transactionColumns.
+func scanTransaction(row scanner) (*fruit.Transaction, error) {
+	var t fruit.Transaction
+	if err := row.Scan(&t.ID, &t.UserID, &t.Count, &t.Active, &t.ModTime); err != nil {
+		return nil, err
+	}
+	t.ModTime = t.ModTime.UTC()
+	return &t, nil
+}
@@ -0,0 +1,251 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/notjrbauer/fruit"
+)
+
+// Columns selected for a user, in scan order.
+const userColumns = `id, name, address, card_id, version, mod_time`
+
+type UserService struct {
+	client *Client
+}
+
+// User returns a user by ID.
+func (s *UserService) User(ctx context.Context, id fruit.UserID) (*fruit.User, error) {
+	return findUser(ctx, s.client.db, s.client, id)
+}
+
+// Users returns a page of users.
+func (s *UserService) Users(ctx context.Context, opt fruit.QueryOptions) ([]*fruit.User, string, error) {
+	order, offset, err := orderBy(opt, userSortColumns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := s.client.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users`+order)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	users := []*fruit.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	start, end, next := page(len(users), offset, opt.Limit)
+	return users[start:end], next, nil
+}
+
+// CreateUser creates a new user.
+func (s *UserService) CreateUser(ctx context.Context, u *fruit.User) error {
+	// Require id
+	if u.ID == "" {
+		return fruit.ErrUserIDRequired
+	}
+
+	address, err := addressColumn(u.Address)
+	if err != nil {
+		return err
+	}
+
+	// Start the read-write transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify user doesn't already exist.
+	if _, err := findUser(ctx, tx, s.client, u.ID); err == nil {
+		return fruit.ErrUserExists
+	} else if err != fruit.ErrUserNotFound {
+		return err
+	}
+
+	// Set initial version and modified time.
+	u.Version = 1
+	u.ModTime = s.client.Now().UTC()
+
+	if _, err := tx.ExecContext(ctx, s.client.rebind(`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?)`),
+		u.ID, u.Name, address, u.CardID, u.Version, u.ModTime,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteUser removes an existing user.
+func (s *UserService) DeleteUser(ctx context.Context, id fruit.UserID) error {
+	res, err := s.client.db.ExecContext(ctx, s.client.rebind(`DELETE FROM users WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fruit.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateUser updates an existing user.
+func (s *UserService) UpdateUser(ctx context.Context, id fruit.UserID, u *fruit.User) error {
+	// Require the version the changes are based on.
+	if u.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	// Start transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find user.
+	user, err := findUser(ctx, tx, s.client, id)
+	if err != nil {
+		return err
+	}
+
+	// Reject changes based on an old version.
+	if u.Version != 0 && u.Version != user.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes
+	user.Name = u.Name
+	user.CardID = u.CardID
+	user.Address = u.Address
+
+	if err := s.saveUser(ctx, tx, user); err != nil {
+		return err
+	} else if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	*u = *user
+	return nil
+}
+
+// PatchUser changes the fields of a user named in mask.
+func (s *UserService) PatchUser(ctx context.Context, id fruit.UserID, u *fruit.User, mask fruit.FieldMask) error {
+	// Require the version the changes are based on.
+	if u.Version == 0 {
+		return fruit.ErrVersionRequired
+	}
+
+	// Start transaction.
+	tx, err := s.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find user.
+	user, err := findUser(ctx, tx, s.client, id)
+	if err != nil {
+		return err
+	}
+
+	// Reject changes based on an old version.
+	if u.Version != 0 && u.Version != user.Version {
+		return fruit.ErrConflict
+	}
+
+	// Apply changes
+	if err := user.Patch(u, mask); err != nil {
+		return err
+	}
+
+	if err := s.saveUser(ctx, tx, user); err != nil {
+		return err
+	} else if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	*u = *user
+	return nil
+}
+
+// saveUser writes every field of user, which was read in tx, as its next
+// version.
+func (s *UserService) saveUser(ctx context.Context, tx *sql.Tx, user *fruit.User) error {
+	address, err := addressColumn(user.Address)
+	if err != nil {
+		return err
+	}
+
+	user.Version++
+	user.ModTime = s.client.Now().UTC()
+
+	// The version is compared again in case another writer got in first.
+	res, err := tx.ExecContext(ctx, s.client.rebind(`UPDATE users SET name = ?, address = ?, card_id = ?, version = ?, mod_time = ? WHERE id = ? AND version = ?`),
+		user.Name, address, user.CardID, user.Version, user.ModTime, user.ID, user.Version-1,
+	)
+	if err != nil {
+		return err
+	} else if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fruit.ErrConflict
+	}
+	return nil
+}
+
+// findUser returns a user by ID.
+func findUser(ctx context.Context, q queryer, c *Client, id fruit.UserID) (*fruit.User, error) {
+	u, err := scanUser(q.QueryRowContext(ctx, c.rebind(`SELECT `+userColumns+` FROM users WHERE id = ?`), id))
+	if err == sql.ErrNoRows {
+		return nil, fruit.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// scanUser reads a user selected with userColumns.
+func scanUser(row scanner) (*fruit.User, error) {
+	var u fruit.User
+	var address sql.NullString
+	if err := row.Scan(&u.ID, &u.Name, &address, &u.CardID, &u.Version, &u.ModTime); err != nil {
+		return nil, err
+	}
+
+	if address.Valid {
+		if err := json.Unmarshal([]byte(address.String), &u.Address); err != nil {
+			return nil, err
+		}
+	}
+	u.ModTime = u.ModTime.UTC()
+	return &u, nil
+}
+
+// addressColumn returns the column value for an address. Addresses are
+// stored as JSON and are NULL when blank.
+func addressColumn(a *fruit.Address) (sql.NullString, error) {
+	if a == nil {
+		return sql.NullString{}, nil
+	}
+
+	buf, err := json.Marshal(a)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(buf), Valid: true}, nil
+}
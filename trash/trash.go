@@ -0,0 +1,74 @@
+// Package trash permanently removes deleted products once they have been in
+// the trash longer than a retention period.
+package trash
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/background"
+)
+
+// Default purger settings.
+const (
+	DefaultRetention = 30 * 24 * time.Hour
+	DefaultInterval  = time.Hour
+)
+
+// Purger empties expired products from the trash in the background.
+type Purger struct {
+	ProductService fruit.ProductService
+
+	// Returns the current time.
+	Now func() time.Time
+
+	// How long deleted products are kept before they are purged.
+	Retention time.Duration
+
+	// How often the trash is checked.
+	Interval time.Duration
+
+	Logger *log.Logger
+
+	loop background.Loop
+}
+
+// NewPurger returns a new instance of Purger with default settings.
+func NewPurger() *Purger {
+	return &Purger{
+		Now:       time.Now,
+		Retention: DefaultRetention,
+		Interval:  DefaultInterval,
+		Logger:    log.New(os.Stderr, "", log.LstdFlags),
+	}
+}
+
+// Open starts purging the trash every interval.
+func (p *Purger) Open() error {
+	p.loop.Start(p.Interval, p.run)
+	return nil
+}
+
+// Close stops purging the trash.
+func (p *Purger) Close() error {
+	p.loop.Stop()
+	return nil
+}
+
+// run empties the trash once and logs the outcome.
+func (p *Purger) run(ctx context.Context) {
+	if n, err := p.Purge(); err != nil && ctx.Err() == nil {
+		p.Logger.Printf("trash error: %s", err)
+	} else if n > 0 {
+		p.Logger.Printf("purged %d deleted products", n)
+	}
+}
+
+// Purge removes products deleted longer than the retention ago and returns
+// how many were removed.
+func (p *Purger) Purge() (int, error) {
+	return p.ProductService.PurgeProducts(p.loop.Context(), p.Now().UTC().Add(-p.Retention))
+}
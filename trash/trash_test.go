@@ -0,0 +1,97 @@
+package trash_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/inmem"
+	"github.com/notjrbauer/fruit/trash"
+)
+
+// Now is the mock time products are deleted at.
+var Now = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// mustCreateTrash returns a store holding product "A", deleted at Now, and
+// product "B", which is not deleted.
+func mustCreateTrash(t *testing.T) *inmem.Client {
+	ctx := context.Background()
+	c := inmem.NewClient()
+	c.Now = func() time.Time { return Now }
+
+	s := c.ProductService()
+	for _, id := range []fruit.ProductID{"A", "B"} {
+		if err := s.CreateProduct(ctx, &fruit.Product{ID: id, Token: "TOKEN"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.DeleteProduct(ctx, "A", "TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// NewPurger returns a purger for c with a retention of one day.
+func NewPurger(c *inmem.Client) *trash.Purger {
+	p := trash.NewPurger()
+	p.ProductService = c.ProductService()
+	p.Retention = 24 * time.Hour
+	p.Logger = log.New(ioutil.Discard, "", 0)
+	return p
+}
+
+func TestPurger_Purge(t *testing.T) {
+	ctx := context.Background()
+	c := mustCreateTrash(t)
+	p := NewPurger(c)
+
+	// Products are kept until the retention has passed.
+	p.Now = func() time.Time { return Now.Add(23 * time.Hour) }
+	if n, err := p.Purge(); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatalf("unexpected purged: %d", n)
+	} else if err := c.ProductService().RestoreProduct(ctx, "A"); err != nil {
+		t.Fatal(err)
+	} else if err := c.ProductService().DeleteProduct(ctx, "A", "TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only deleted products are purged.
+	p.Now = func() time.Time { return Now.Add(25 * time.Hour) }
+	if n, err := p.Purge(); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("unexpected purged: %d", n)
+	} else if err := c.ProductService().RestoreProduct(ctx, "A"); err != fruit.ErrProductNotFound {
+		t.Fatal(err)
+	} else if _, err := c.ProductService().Product(ctx, "B"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurger_Open(t *testing.T) {
+	ctx := context.Background()
+	c := mustCreateTrash(t)
+	p := NewPurger(c)
+	p.Now = func() time.Time { return Now.Add(48 * time.Hour) }
+
+	// The trash is purged as soon as the purger opens.
+	if err := p.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if a, _, err := c.ProductService().Products(ctx, fruit.QueryOptions{IncludeDeleted: true}); err != nil {
+			t.Fatal(err)
+		} else if len(a) == 1 {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("unexpected products: %d", len(a))
+		}
+	}
+}
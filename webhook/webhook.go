@@ -0,0 +1,215 @@
+// Package webhook delivers queued events to webhooks as signed HTTP POST
+// requests, retrying failures with exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/background"
+)
+
+// Headers sent with each delivery.
+const (
+	EventHeader     = "X-Fruit-Event"
+	DeliveryHeader  = "X-Fruit-Delivery"
+	SignatureHeader = "X-Fruit-Signature"
+)
+
+// Default dispatcher settings.
+const (
+	DefaultInterval    = time.Second
+	DefaultMaxAttempts = 8
+	DefaultMinBackoff  = 10 * time.Second
+	DefaultMaxBackoff  = time.Hour
+	DefaultTimeout     = 10 * time.Second
+)
+
+// batchSize is the most deliveries read from the queue at once.
+const batchSize = 100
+
+// Sign returns the signature sent with a delivery of body: the hex encoded
+// HMAC-SHA256 of body keyed by the webhook secret, prefixed by "sha256=".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if signature is valid for body. Receivers use it to
+// check that a delivery came from the store.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Dispatcher sends due deliveries in the background.
+type Dispatcher struct {
+	WebhookService  fruit.WebhookService
+	DeliveryService fruit.DeliveryService
+
+	// HTTP client used to send deliveries.
+	Client *http.Client
+
+	// Returns the current time.
+	Now func() time.Time
+
+	// How often the queue is checked for due deliveries.
+	Interval time.Duration
+
+	// Retry policy. The wait after each failed attempt doubles from
+	// MinBackoff up to MaxBackoff, and a delivery fails for good after
+	// MaxAttempts.
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+
+	Logger *log.Logger
+
+	loop background.Loop
+}
+
+// NewDispatcher returns a new instance of Dispatcher with default settings.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		Client:      &http.Client{Timeout: DefaultTimeout},
+		Now:         time.Now,
+		Interval:    DefaultInterval,
+		MaxAttempts: DefaultMaxAttempts,
+		MinBackoff:  DefaultMinBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		Logger:      log.New(os.Stderr, "", log.LstdFlags),
+	}
+}
+
+// Open starts checking the queue for due deliveries every interval.
+func (d *Dispatcher) Open() error {
+	d.loop.Start(d.Interval, d.run)
+	return nil
+}
+
+// Close stops sending deliveries. Requests in flight are cancelled and
+// retried once the dispatcher is reopened.
+func (d *Dispatcher) Close() error {
+	d.loop.Stop()
+	return nil
+}
+
+// run sends the due deliveries once and logs any error.
+func (d *Dispatcher) run(ctx context.Context) {
+	if err := d.DeliverDue(); err != nil && ctx.Err() == nil {
+		d.Logger.Printf("webhook error: %s", err)
+	}
+}
+
+// DeliverDue sends each due delivery once and records the outcome.
+func (d *Dispatcher) DeliverDue() error {
+	ctx := d.loop.Context()
+
+	for {
+		deliveries, err := d.DeliveryService.DueDeliveries(d.Now().UTC(), batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, dl := range deliveries {
+			if err := d.deliver(ctx, dl); err != nil {
+				return err
+			}
+		}
+
+		// Deliveries which were sent are no longer due, so a full batch
+		// means more may be waiting.
+		if len(deliveries) < batchSize {
+			return nil
+		}
+	}
+}
+
+// deliver makes one attempt to send dl and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, dl *fruit.Delivery) error {
+	// The webhook may have been deleted since the delivery was read.
+	w, err := d.WebhookService.Webhook(dl.WebhookID)
+	if err == fruit.ErrWebhookNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	code, err := d.send(ctx, w, dl)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	dl.Attempts++
+	dl.ResponseCode = code
+	if err == nil {
+		dl.Status = fruit.DeliverySucceeded
+		dl.LastError = ""
+	} else if dl.LastError = err.Error(); dl.Attempts >= d.MaxAttempts {
+		dl.Status = fruit.DeliveryFailed
+	} else {
+		dl.NextAttempt = d.Now().UTC().Add(d.backoff(dl.Attempts))
+	}
+
+	if err := d.DeliveryService.UpdateDelivery(dl); err != nil && err != fruit.ErrDeliveryNotFound {
+		return err
+	}
+	return nil
+}
+
+// send POSTs the event of dl to the webhook. Returns the response status
+// code, if any, and an error unless the status is 2xx.
+func (d *Dispatcher) send(ctx context.Context, w *fruit.Webhook, dl *fruit.Delivery) (int, error) {
+	body, err := json.Marshal(dl.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, dl.Event.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(dl.ID), 10))
+	req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+
+	resp, err := d.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain the response so the connection can be reused.
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.MinBackoff
+	for i := 1; i < attempts && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+	return wait
+}
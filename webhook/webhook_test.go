@@ -0,0 +1,261 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notjrbauer/fruit"
+	"github.com/notjrbauer/fruit/internal/testdb"
+	"github.com/notjrbauer/fruit/webhook"
+)
+
+// Now is the mock time used by the store and dispatcher.
+var Now = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Client represents a test wrapper for a bolt client with a controllable
+// clock.
+type Client struct {
+	*testdb.Bolt
+	Now time.Time
+}
+
+// MustOpenClient returns a new, open store on a temporary file.
+func MustOpenClient() *Client {
+	c := &Client{Bolt: testdb.NewBolt(Now), Now: Now}
+	c.Client.Now = func() time.Time { return c.Now }
+	if err := c.Open(); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewDispatcher returns a dispatcher reading from c on c's clock.
+func NewDispatcher(c *Client) *webhook.Dispatcher {
+	d := webhook.NewDispatcher()
+	d.WebhookService = c.WebhookService()
+	d.DeliveryService = c.DeliveryService()
+	d.Now = func() time.Time { return c.Now }
+	d.MaxAttempts = 3
+	d.MinBackoff = time.Minute
+	d.Logger = log.New(ioutil.Discard, "", 0)
+	return d
+}
+
+// Receiver represents a test webhook endpoint which records requests and
+// replies with the next queued status.
+type Receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int
+	received chan struct{}
+}
+
+func NewReceiver(statuses ...int) *Receiver {
+	r := &Receiver{statuses: statuses, received: make(chan struct{}, 100)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		r.mu.Lock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+		r.received <- struct{}{}
+	}))
+	return r
+}
+
+// Count returns the number of requests received.
+func (r *Receiver) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func TestSign(t *testing.T) {
+	sig := webhook.Sign("SECRET", []byte(`{}`))
+	if !webhook.Verify("SECRET", []byte(`{}`), sig) {
+		t.Fatal("expected valid signature")
+	} else if webhook.Verify("OTHER", []byte(`{}`), sig) {
+		t.Fatal("expected invalid signature for other secret")
+	} else if webhook.Verify("SECRET", []byte(`{"a":1}`), sig) {
+		t.Fatal("expected invalid signature for other body")
+	}
+}
+
+func TestDispatcher_DeliverDue(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	r := NewReceiver()
+	defer r.Close()
+
+	if err := c.WebhookService().CreateWebhook(&fruit.Webhook{ID: "W", URL: r.URL, Secret: "SECRET"}); err != nil {
+		t.Fatal(err)
+	} else if err := c.ProductService().CreateProduct(ctx, &fruit.Product{ID: "A", Name: "Apple", Token: "TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewDispatcher(c).DeliverDue(); err != nil {
+		t.Fatal(err)
+	} else if n := r.Count(); n != 1 {
+		t.Fatalf("unexpected request count: %d", n)
+	}
+
+	// The event is sent as signed JSON.
+	req, body := r.requests[0], r.bodies[0]
+	var e fruit.Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		t.Fatal(err)
+	} else if e.Type != fruit.EventProductCreated || e.Product.Name != "Apple" {
+		t.Fatalf("unexpected event: %+v", e)
+	} else if req.Header.Get(webhook.EventHeader) != fruit.EventProductCreated || req.Header.Get(webhook.DeliveryHeader) != "1" {
+		t.Fatalf("unexpected headers: %v", req.Header)
+	} else if !webhook.Verify("SECRET", body, req.Header.Get(webhook.SignatureHeader)) {
+		t.Fatal("expected valid signature")
+	}
+
+	if a, err := c.WebhookService().Deliveries("W"); err != nil {
+		t.Fatal(err)
+	} else if d := a[0]; d.Status != fruit.DeliverySucceeded || d.Attempts != 1 || d.ResponseCode != http.StatusOK {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+
+	// Sent deliveries aren't sent again.
+	if err := NewDispatcher(c).DeliverDue(); err != nil {
+		t.Fatal(err)
+	} else if n := r.Count(); n != 1 {
+		t.Fatalf("unexpected request count: %d", n)
+	}
+}
+
+func TestDispatcher_DeliverDue_Retry(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	r := NewReceiver(http.StatusInternalServerError, http.StatusBadGateway)
+	defer r.Close()
+	d := NewDispatcher(c)
+
+	if err := c.WebhookService().CreateWebhook(&fruit.Webhook{ID: "W", URL: r.URL}); err != nil {
+		t.Fatal(err)
+	} else if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each failure doubles the wait before the next attempt.
+	for _, tt := range []struct {
+		wait     time.Duration
+		requests int
+		attempts int
+		next     time.Duration
+	}{
+		{0, 1, 1, time.Minute},
+		{30 * time.Second, 1, 1, time.Minute},
+		{30 * time.Second, 2, 2, 3 * time.Minute},
+		{2 * time.Minute, 3, 3, 3 * time.Minute},
+	} {
+		c.Now = c.Now.Add(tt.wait)
+		if err := d.DeliverDue(); err != nil {
+			t.Fatal(err)
+		} else if n := r.Count(); n != tt.requests {
+			t.Fatalf("unexpected request count: %d, expected %d", n, tt.requests)
+		}
+
+		if a, err := c.WebhookService().Deliveries("W"); err != nil {
+			t.Fatal(err)
+		} else if dl := a[0]; dl.Attempts != tt.attempts || !dl.NextAttempt.Equal(Now.Add(tt.next)) {
+			t.Fatalf("unexpected delivery: %+v", dl)
+		}
+	}
+
+	if a, err := c.WebhookService().Deliveries("W"); err != nil {
+		t.Fatal(err)
+	} else if dl := a[0]; dl.Status != fruit.DeliverySucceeded || dl.LastError != "" {
+		t.Fatalf("unexpected delivery: %+v", dl)
+	}
+}
+
+func TestDispatcher_DeliverDue_Failed(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	r := NewReceiver(http.StatusNotFound, http.StatusNotFound, http.StatusNotFound)
+	defer r.Close()
+	d := NewDispatcher(c)
+	d.MinBackoff = 0
+
+	if err := c.WebhookService().CreateWebhook(&fruit.Webhook{ID: "W", URL: r.URL}); err != nil {
+		t.Fatal(err)
+	} else if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deliveries fail for good after the last attempt.
+	for i := 0; i < 4; i++ {
+		if err := d.DeliverDue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := r.Count(); n != 3 {
+		t.Fatalf("unexpected request count: %d", n)
+	} else if a, err := c.WebhookService().Deliveries("W"); err != nil {
+		t.Fatal(err)
+	} else if dl := a[0]; dl.Status != fruit.DeliveryFailed || dl.ResponseCode != http.StatusNotFound || dl.LastError != "unexpected status: 404" {
+		t.Fatalf("unexpected delivery: %+v", dl)
+	}
+
+	// Replayed deliveries are sent again.
+	if n, err := c.WebhookService().ReplayFailed("W"); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("unexpected replay count: %d", n)
+	} else if err := d.DeliverDue(); err != nil {
+		t.Fatal(err)
+	} else if n := r.Count(); n != 4 {
+		t.Fatalf("unexpected request count: %d", n)
+	}
+}
+
+func TestDispatcher_Open(t *testing.T) {
+	ctx := context.Background()
+	c := MustOpenClient()
+	defer c.Close()
+	r := NewReceiver()
+	defer r.Close()
+
+	d := NewDispatcher(c)
+	d.Interval = 10 * time.Millisecond
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if err := c.WebhookService().CreateWebhook(&fruit.Webhook{URL: r.URL, Events: []string{fruit.EventUserCreated}}); err != nil {
+		t.Fatal(err)
+	} else if err := c.UserService().CreateUser(ctx, &fruit.User{ID: "U"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deliveries are sent in the background.
+	select {
+	case <-r.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+}